@@ -12,10 +12,13 @@ OUTPUT_NAME="lottery_linux_amd64"
 export GOOS=linux
 export GOARCH=amd64
 
+# Resolve the build version from git, falling back to "dev"
+VERSION=$(git describe --tags --always --dirty 2>/dev/null || echo "dev")
+
 # Build the Go application
 # The main package is now located in the cmd/ directory
-go build -o "$OUTPUT_NAME" ./cmd/main.go
+go build -ldflags "-X main.version=$VERSION" -o "$OUTPUT_NAME" ./cmd/main.go
 
 echo " "
 echo "Build successful!"
-echo "Binary created: $OUTPUT_NAME"
\ No newline at end of file
+echo "Binary created: $OUTPUT_NAME ($VERSION)"
\ No newline at end of file
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"lottery/internal/models"
+	"lottery/internal/services"
+	"os"
+)
+
+// headlessTenant is the session a headless draw runs in.
+const headlessTenant = "headless"
+
+// headlessConfig is the JSON file read by -config.
+type headlessConfig struct {
+	Prizes       []models.Prize       `json:"prizes"`
+	Participants []models.Participant `json:"participants"`
+	Seed         *int64               `json:"seed,omitempty"` // Overridden by the -seed flag
+}
+
+// headlessResultsHeader matches the first four columns of the web results export.
+// Draw times are left out so the same seed always produces an identical file.
+var headlessResultsHeader = []string{"獎項名稱", "員工編號", "員工姓名", "獎品名稱"}
+
+// runHeadless loads prizes and participants from configPath, draws every prize
+// unit in display order as the "抽出所有剩餘獎項" button does, and writes the
+// results to outPath. A non-nil seed takes precedence over the config's seed.
+func runHeadless(service *services.LotteryService, configPath, outPath string, seed *int64) error {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return err
+	}
+	var cfg headlessConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("invalid config %s: %w", configPath, err)
+	}
+
+	for _, p := range cfg.Prizes {
+		if err := service.InsertPrize(headlessTenant, p); err != nil {
+			return fmt.Errorf("prize %q: %w", p.Name, err)
+		}
+	}
+	for _, p := range cfg.Participants {
+		if err := service.InsertParticipant(headlessTenant, p); err != nil {
+			return fmt.Errorf("participant %q: %w", p.ID, err)
+		}
+	}
+	if seed == nil {
+		seed = cfg.Seed
+	}
+	if seed != nil {
+		service.SetSeed(headlessTenant, *seed)
+	}
+
+	results, remaining, err := service.DrawAllRemaining(headlessTenant)
+	if err != nil {
+		return err
+	}
+	for name, n := range remaining {
+		fmt.Fprintf(os.Stderr, "prize %q: %d units left undrawn, not enough eligible participants\n", name, n)
+	}
+
+	f, err := os.Create(outPath)
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(f)
+	w.Write(headlessResultsHeader)
+	for _, r := range results {
+		w.Write([]string{r.PrizeName, r.WinnerID, r.WinnerName, r.PrizeItem})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"lottery/internal/services"
+)
+
+const headlessTestConfig = `{
+	"prizes": [
+		{"name": "頭獎", "item": "電視", "quantity": 1},
+		{"name": "參加獎", "item": "紅包", "quantity": 2, "drawFromAll": true}
+	],
+	"participants": [
+		{"id": "001", "name": "Alice"},
+		{"id": "002", "name": "Bob"},
+		{"id": "003", "name": "Carol"}
+	],
+	"seed": 7
+}`
+
+func runHeadlessForTest(t *testing.T, seed *int64) [][]string {
+	t.Helper()
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.json")
+	outPath := filepath.Join(dir, "results.csv")
+	if err := os.WriteFile(configPath, []byte(headlessTestConfig), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := runHeadless(services.NewLotteryService(), configPath, outPath, seed); err != nil {
+		t.Fatalf("runHeadless failed: %v", err)
+	}
+	f, err := os.Open(outPath)
+	if err != nil {
+		t.Fatalf("Expected the results CSV to be written: %v", err)
+	}
+	defer f.Close()
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to parse results CSV: %v", err)
+	}
+	return records
+}
+
+func TestRunHeadless(t *testing.T) {
+	records := runHeadlessForTest(t, nil)
+	if len(records) != 4 || !reflect.DeepEqual(records[0], headlessResultsHeader) {
+		t.Fatalf("Expected a header and three results, but got %v", records)
+	}
+	names := map[string]string{"001": "Alice", "002": "Bob", "003": "Carol"}
+	wantPrizes := []string{"頭獎", "參加獎", "參加獎"}
+	wantItems := []string{"電視", "紅包", "紅包"}
+	for i, row := range records[1:] {
+		if row[0] != wantPrizes[i] || row[3] != wantItems[i] || names[row[1]] != row[2] {
+			t.Errorf("Unexpected result row %d: %v", i+1, row)
+		}
+	}
+	if records[2][1] == records[3][1] {
+		t.Errorf("Expected two different 參加獎 winners, but got %v", records)
+	}
+
+	if again := runHeadlessForTest(t, nil); !reflect.DeepEqual(again, records) {
+		t.Errorf("Expected the config's seed to reproduce %v, but got %v", records, again)
+	}
+	seed := int64(7)
+	if flagged := runHeadlessForTest(t, &seed); !reflect.DeepEqual(flagged, records) {
+		t.Errorf("Expected -seed 7 to match the config's seed 7, but got %v", flagged)
+	}
+}
@@ -1,17 +1,37 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"html/template"
 	"io"
 	"log"
+	"lottery/internal/config"
 	"lottery/internal/handlers"
 	"lottery/internal/services"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/logger"
 )
 
+// version is overridden at build time via -ldflags "-X main.version=...".
+var version = "dev"
+
+// defaultStateFile is where sessions are persisted when LOTTERY_STATE_FILE is unset.
+const defaultStateFile = "lottery_state.json"
+
+// shutdownTimeout bounds how long in-flight requests may take to finish on shutdown.
+const shutdownTimeout = 15 * time.Second
+
 func init() {
 	log.SetFlags(log.Ldate | log.Ltime | log.Lshortfile)
 	// log.SetOutput(io.Discard)
@@ -20,45 +40,222 @@ func init() {
 }
 
 func main() {
-	// 1. Initialize the Lottery Service
-	lotteryService := services.NewLotteryService()
+	configPath := flag.String("config", "", "run a headless draw from this JSON config instead of starting the server")
+	outPath := flag.String("out", "lottery_results.csv", "where a headless draw writes its results")
+	seedFlag := flag.String("seed", "", "seed for a reproducible headless draw; overrides the config's seed")
+	flag.Parse()
+	if *configPath != "" {
+		var seed *int64
+		if *seedFlag != "" {
+			v, err := strconv.ParseInt(*seedFlag, 10, 64)
+			if err != nil {
+				log.Fatalf("Invalid -seed %q: expected an integer", *seedFlag)
+			}
+			seed = &v
+		}
+		if err := runHeadless(services.NewLotteryService(), *configPath, *outPath, seed); err != nil {
+			log.Fatalf("Headless draw failed: %v", err)
+		}
+		log.Printf("Results written to %s", *outPath)
+		return
+	}
+
+	startTime := time.Now()
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// 1. Initialize the Lottery Service and restore any persisted sessions
+	sessionTTL := services.DefaultSessionTTL
+	if v := os.Getenv("LOTTERY_SESSION_TTL"); v != "" {
+		ttl, err := time.ParseDuration(v)
+		if err != nil || ttl <= 0 {
+			log.Fatalf("Invalid LOTTERY_SESSION_TTL %q: must be a positive duration such as 8h", v)
+		}
+		sessionTTL = ttl
+	}
+	appLogger := services.DefaultLogger()
+	lotteryService := services.NewLotteryServiceWithTTL(sessionTTL)
+	lotteryService.SetLogger(appLogger)
+	if v := os.Getenv("LOTTERY_HOT_RESULTS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			log.Fatalf("Invalid LOTTERY_HOT_RESULTS %q: expected a non-negative number of results", v)
+		}
+		lotteryService.SetHotResultsLimit(n)
+	}
+	if v := os.Getenv("LOTTERY_DEBUG_CLEANUP"); v != "" {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			log.Fatalf("Invalid LOTTERY_DEBUG_CLEANUP %q: expected true or false", v)
+		}
+		lotteryService.SetCleanupDebug(enabled)
+	}
+	stateFile := os.Getenv("LOTTERY_STATE_FILE")
+	if stateFile == "" {
+		stateFile = defaultStateFile
+	}
+	if err := lotteryService.LoadFromFile(stateFile); err != nil {
+		log.Fatalf("Failed to load state from %s: %v", stateFile, err)
+	}
 
 	// 2. Load all HTML templates into a single template set.
 	// The template names will be their file names.
-	templates, err := template.ParseGlob("internal/templates/*.html")
+	templates, err := template.New("").Funcs(handlers.TemplateFuncs).ParseGlob("internal/templates/*.html")
 	if err != nil {
 		log.Fatalf("Failed to parse templates: %v", err)
 	}
 
 	// 3. Initialize the HTTP Handler
-	httpHandler := handlers.NewHTTPHandler(lotteryService, templates)
+	httpHandler := handlers.NewHTTPHandler(lotteryService, templates, handlers.BuildInfo{
+		Version:   version,
+		StartTime: startTime,
+	})
+	httpHandler.SetLogger(appLogger)
+	if secret := os.Getenv("LOTTERY_COOKIE_SECRET"); secret != "" {
+		httpHandler.SetCookieSecret([]byte(secret))
+	} else {
+		log.Println("LOTTERY_COOKIE_SECRET is not set; using a random key, so tenant cookies will not survive a restart")
+	}
+
+	cookieOpts := handlers.DefaultCookieOptions
+	if v := os.Getenv("LOTTERY_COOKIE_MAX_AGE"); v != "" {
+		maxAge, err := time.ParseDuration(v)
+		if err != nil || maxAge < 0 {
+			log.Fatalf("Invalid LOTTERY_COOKIE_MAX_AGE %q: expected a duration such as 720h", v)
+		}
+		cookieOpts.MaxAge = maxAge
+	}
+	if v := os.Getenv("LOTTERY_COOKIE_SECURE"); v != "" {
+		secure, err := strconv.ParseBool(v)
+		if err != nil {
+			log.Fatalf("Invalid LOTTERY_COOKIE_SECURE %q: expected true or false", v)
+		}
+		cookieOpts.Secure = &secure
+	}
+	if v := os.Getenv("LOTTERY_COOKIE_SAMESITE"); v != "" {
+		sameSite, err := handlers.ParseSameSite(v)
+		if err != nil {
+			log.Fatalf("Invalid LOTTERY_COOKIE_SAMESITE: %v", err)
+		}
+		cookieOpts.SameSite = sameSite
+	}
+	httpHandler.SetCookieOptions(cookieOpts)
+
+	if token := os.Getenv("LOTTERY_ADMIN_TOKEN"); token != "" {
+		httpHandler.SetAdminToken(token)
+	}
+	if v := os.Getenv("LOTTERY_BROWSER_TOKENS"); v != "" {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			log.Fatalf("Invalid LOTTERY_BROWSER_TOKENS %q: expected true or false", v)
+		}
+		httpHandler.SetBrowserTokens(enabled)
+	}
+	if d := os.Getenv("LOTTERY_REVEAL_DURATION"); d != "" {
+		duration, err := time.ParseDuration(d)
+		if err != nil || duration < 0 {
+			log.Fatalf("Invalid LOTTERY_REVEAL_DURATION %q: expected a duration such as 3s", d)
+		}
+		httpHandler.SetRevealDuration(duration)
+	}
 
 	// 4. Set up the Gin router
-	r := gin.Default()
+	r := gin.New()
+	proxies, err := config.TrustedProxies(os.Getenv)
+	if err != nil {
+		log.Fatal(err)
+	}
+	// Tenants default to the client IP, so a forwarded IP is only believed from known proxies.
+	if err := r.SetTrustedProxies(proxies); err != nil {
+		log.Fatalf("Failed to set trusted proxies: %v", err)
+	}
+	// Uploads are rejected past DefaultUploadLimits.MaxBytes anyway, so buffer no
+	// more than that in memory instead of gin's 32 MB default.
+	r.MaxMultipartMemory = handlers.DefaultUploadLimits.MaxBytes
+	r.Use(gin.Logger(), httpHandler.RecoveryMiddleware())
 
 	// Serve static files from the web/assets directory
 	r.Static("/assets", "./web/assets")
 
-	// 5. Register public routes (before middleware)
+	// 5. Register public and admin routes (before middleware)
 	httpHandler.RegisterPublicRoutes(r)
+	httpHandler.RegisterAdminRoutes(r)
 
 	// 6. Group routes that require tenant identification and apply middleware
 	tenantRoutes := r.Group("/")
 	tenantRoutes.Use(httpHandler.TenantMiddleware())
 	httpHandler.RegisterTenantRoutes(tenantRoutes)
 
-	// 7. Start the background janitor to clean up inactive sessions
+	// 7. Start the background janitor to clean up inactive sessions.
+	// It runs every 10 minutes, or more often when the TTL is shorter than that.
+	var background sync.WaitGroup
+	janitorInterval := min(10*time.Minute, sessionTTL)
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		lotteryService.RunJanitor(ctx, janitorInterval)
+	}()
+
+	// 8. Persist sessions periodically; the final save happens after shutdown
+	background.Add(1)
 	go func() {
+		defer background.Done()
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
 		for {
-			time.Sleep(10 * time.Minute) // Run every 10 minutes
-			lotteryService.CleanUpInactiveSessions()
-			log.Println("Performed cleanup of inactive sessions.")
+			select {
+			case <-ticker.C:
+				if err := lotteryService.SaveToFile(stateFile); err != nil {
+					log.Printf("Failed to save state: %v", err)
+				}
+			case <-ctx.Done():
+				return
+			}
 		}
 	}()
 
-	// 8. Run the server
-	log.Println("Server starting on http://localhost:8080")
-	if err := r.Run(":8080"); err != nil {
-		log.Fatalf("Failed to run server: %v", err)
+	// 9. Run the server until SIGINT/SIGTERM, then drain in-flight requests.
+	// Long-lived streams (SSE) share streamCtx, which is cancelled when shutdown starts.
+	addr, err := config.ResolveAddr(os.Getenv)
+	if err != nil {
+		log.Fatal(err)
+	}
+	streamCtx, cancelStreams := context.WithCancel(context.Background())
+	srv := &http.Server{
+		Addr:        addr,
+		Handler:     r,
+		BaseContext: func(net.Listener) context.Context { return streamCtx },
+	}
+	srv.RegisterOnShutdown(cancelStreams)
+
+	// State was loaded in step 1, before any request can reach a session.
+	httpHandler.SetReady(true)
+	serveErr := make(chan error, 1)
+	go func() {
+		log.Printf("Server starting on %s", addr)
+		serveErr <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Failed to run server: %v", err)
+		}
+	case <-ctx.Done():
+		stop() // A second signal now kills the process immediately
+		httpHandler.SetReady(false)
+		log.Println("Shutting down...")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Printf("Server shutdown did not complete: %v", err)
+		}
+	}
+
+	stop() // Stops the janitor and autosave loops if the server exited on its own
+	background.Wait()
+	if err := lotteryService.SaveToFile(stateFile); err != nil {
+		log.Printf("Failed to save state on shutdown: %v", err)
 	}
+	log.Println("State saved, shutting down.")
 }
@@ -0,0 +1,67 @@
+// Package config resolves the server's runtime settings from the environment.
+package config
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// DefaultAddr is the listen address used when neither ADDR nor PORT is set.
+const DefaultAddr = ":8080"
+
+// ResolveAddr returns the address the server should listen on. ADDR
+// ("host:port" or ":port") takes precedence over PORT (a bare port number);
+// with neither set DefaultAddr is used. getenv is usually os.Getenv.
+func ResolveAddr(getenv func(string) string) (string, error) {
+	if addr := getenv("ADDR"); addr != "" {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return "", fmt.Errorf("invalid ADDR %q: %w", addr, err)
+		}
+		if err := validatePort(port); err != nil {
+			return "", fmt.Errorf("invalid ADDR %q: %w", addr, err)
+		}
+		return net.JoinHostPort(host, port), nil
+	}
+	if port := getenv("PORT"); port != "" {
+		if err := validatePort(port); err != nil {
+			return "", fmt.Errorf("invalid PORT %q: %w", port, err)
+		}
+		return ":" + port, nil
+	}
+	return DefaultAddr, nil
+}
+
+// validatePort accepts a decimal TCP port between 1 and 65535.
+func validatePort(port string) error {
+	n, err := strconv.Atoi(port)
+	if err != nil || n < 1 || n > 65535 {
+		return fmt.Errorf("port must be a number between 1 and 65535")
+	}
+	return nil
+}
+
+// TrustedProxies returns the proxies whose X-Forwarded-For header may be
+// believed, from LOTTERY_TRUSTED_PROXIES: a comma-separated list of IPs or
+// CIDR ranges. Unset means none, so the client IP, and with it the default
+// tenant, is always the connection's own address and cannot be claimed by
+// sending the header.
+func TrustedProxies(getenv func(string) string) ([]string, error) {
+	v := getenv("LOTTERY_TRUSTED_PROXIES")
+	if v == "" {
+		return nil, nil
+	}
+	var proxies []string
+	for _, proxy := range strings.Split(v, ",") {
+		proxy = strings.TrimSpace(proxy)
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				return nil, fmt.Errorf("invalid LOTTERY_TRUSTED_PROXIES entry %q: expected an IP or CIDR range", proxy)
+			}
+		}
+		proxies = append(proxies, proxy)
+	}
+	return proxies, nil
+}
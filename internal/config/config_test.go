@@ -0,0 +1,67 @@
+package config
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestResolveAddr(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    string
+		wantErr bool
+	}{
+		{"default", nil, DefaultAddr, false},
+		{"port override", map[string]string{"PORT": "9090"}, ":9090", false},
+		{"addr override", map[string]string{"ADDR": "127.0.0.1:3000"}, "127.0.0.1:3000", false},
+		{"addr without host", map[string]string{"ADDR": ":3000"}, ":3000", false},
+		{"ipv6 addr", map[string]string{"ADDR": "[::1]:3000"}, "[::1]:3000", false},
+		{"addr wins over port", map[string]string{"ADDR": ":3000", "PORT": "9090"}, ":3000", false},
+		{"non-numeric port", map[string]string{"PORT": "http"}, "", true},
+		{"port out of range", map[string]string{"PORT": "70000"}, "", true},
+		{"port zero", map[string]string{"PORT": "0"}, "", true},
+		{"addr missing port", map[string]string{"ADDR": "localhost"}, "", true},
+		{"addr bad port", map[string]string{"ADDR": "localhost:abc"}, "", true},
+	}
+	for _, tt := range tests {
+		got, err := ResolveAddr(func(key string) string { return tt.env[key] })
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: expected error %t, but got %v", tt.name, tt.wantErr, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: expected %q, but got %q", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestTrustedProxies(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    []string
+		wantErr bool
+	}{
+		{"unset trusts none", "", nil, false},
+		{"single ip", "10.0.0.1", []string{"10.0.0.1"}, false},
+		{"ips and ranges", "10.0.0.1, 192.168.0.0/16,::1", []string{"10.0.0.1", "192.168.0.0/16", "::1"}, false},
+		{"hostname", "proxy.local", nil, true},
+		{"bad range", "10.0.0.0/33", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := TrustedProxies(func(key string) string {
+			if key == "LOTTERY_TRUSTED_PROXIES" {
+				return tt.value
+			}
+			return ""
+		})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: expected error %t, but got %v", tt.name, tt.wantErr, err)
+			continue
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: expected %q, but got %q", tt.name, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"lottery/internal/services"
+)
+
+// SetAdminToken enables the /admin routes for requests carrying the token as
+// "Authorization: Bearer <token>". Without a token, the default, every admin
+// route answers 404. It must be called before routes are served.
+func (h *HTTPHandler) SetAdminToken(token string) {
+	h.adminToken = token
+}
+
+// RegisterAdminRoutes registers the operator routes behind AdminMiddleware.
+// They see every tenant, so they never go through TenantMiddleware.
+func (h *HTTPHandler) RegisterAdminRoutes(router *gin.Engine) {
+	admin := router.Group("/admin", h.AdminMiddleware())
+	admin.GET("/sessions", h.ListSessions)
+	admin.POST("/sessions/clear", h.AdminClearSession)
+	admin.POST("/clone", h.AdminCloneConfig)
+}
+
+// AdminMiddleware rejects requests without the token set by SetAdminToken:
+// 404 while no token is configured, so the routes do not advertise
+// themselves, and 401 for a missing or wrong token.
+func (h *HTTPHandler) AdminMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if h.adminToken == "" {
+			c.AbortWithStatus(http.StatusNotFound)
+			return
+		}
+		token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(h.adminToken)) != 1 {
+			h.log.Infof("Rejected admin request from %s", c.ClientIP())
+			c.Header("WWW-Authenticate", `Bearer realm="lottery-admin"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// ListSessions returns every tenant's session as JSON: its ID, last activity
+// and how many prizes, participants and results it holds.
+func (h *HTTPHandler) ListSessions(c *gin.Context) {
+	c.JSON(http.StatusOK, h.service.ListSessions())
+}
+
+// AdminClearSession force-clears the session named by the "tenantID" form
+// value, as ClearTenant does for the tenant's own browser.
+func (h *HTTPHandler) AdminClearSession(c *gin.Context) {
+	tenantID := c.PostForm("tenantID")
+	if tenantID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "tenantID is required"})
+		return
+	}
+	h.service.ClearSession(tenantID)
+	h.log.Infof("Admin cleared session %q", tenantID)
+	c.Status(http.StatusNoContent)
+}
+
+// AdminCloneConfig copies the prizes, participants and settings of the
+// "source" tenant to the "target" tenant, as CloneConfig does. It answers 404
+// for an unknown source and 409 when the target already has data.
+func (h *HTTPHandler) AdminCloneConfig(c *gin.Context) {
+	source, target := c.PostForm("source"), c.PostForm("target")
+	if source == "" || target == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "source and target are required"})
+		return
+	}
+	if err := h.service.CloneConfig(source, target); err != nil {
+		status := http.StatusConflict
+		if errors.Is(err, services.ErrCloneSourceMissing) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": h.localize(c, err)})
+		return
+	}
+	h.log.Infof("Admin cloned session %q to %q", source, target)
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"lottery/internal/services"
+)
+
+const testAdminToken = "admin-secret"
+
+func newAdminRequest(method, target, token string, body *bytes.Buffer) *http.Request {
+	var req *http.Request
+	if body == nil {
+		req = httptest.NewRequest(method, target, nil)
+	} else {
+		req = httptest.NewRequest(method, target, body)
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req
+}
+
+func TestAdminSessions(t *testing.T) {
+	r, h := newTestRouter(t)
+	h.SetAdminToken(testAdminToken)
+	h.service.AddPrize(testTenantID, "頭獎", "電視", 1, false)
+	h.service.AddParticipant(testTenantID, "001", "Alice")
+	h.service.AddParticipant("other-tenant", "002", "Bob")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newAdminRequest(http.MethodGet, "/admin/sessions", testAdminToken, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d: %s", w.Code, w.Body.String())
+	}
+	var infos []services.SessionInfo
+	if err := json.Unmarshal(w.Body.Bytes(), &infos); err != nil {
+		t.Fatalf("Failed to decode sessions: %v", err)
+	}
+	if len(infos) != 2 || infos[0].TenantID != "other-tenant" || infos[1].TenantID != testTenantID {
+		t.Fatalf("Expected both tenants, but got %+v", infos)
+	}
+	if infos[1].Prizes != 1 || infos[1].Participants != 1 {
+		t.Errorf("Expected %s to report 1 prize and 1 participant, but got %+v", testTenantID, infos[1])
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, newAdminRequest(http.MethodPost, "/admin/sessions/clear", testAdminToken, bytes.NewBufferString("tenantID=other-tenant")))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("Expected status 204, but got %d: %s", w.Code, w.Body.String())
+	}
+	if infos := h.service.ListSessions(); len(infos) != 1 || infos[0].TenantID != testTenantID {
+		t.Errorf("Expected only %s to remain, but got %+v", testTenantID, infos)
+	}
+}
+
+func TestAdminCloneConfig(t *testing.T) {
+	r, h := newTestRouter(t)
+	h.SetAdminToken(testAdminToken)
+	h.service.AddPrize(testTenantID, "頭獎", "電視", 1, false)
+	h.service.AddParticipant(testTenantID, "001", "Alice")
+	h.service.Draw(testTenantID, "頭獎")
+
+	clone := func(form string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, newAdminRequest(http.MethodPost, "/admin/clone", testAdminToken, bytes.NewBufferString(form)))
+		return w
+	}
+	if w := clone("source=" + testTenantID + "&target=room-2"); w.Code != http.StatusNoContent {
+		t.Fatalf("Expected status 204, but got %d: %s", w.Code, w.Body.String())
+	}
+	if prizes := h.service.GetPrizes("room-2"); len(prizes) != 1 || prizes[0].Quantity != 1 {
+		t.Errorf("Expected the clone to have the prize back at quantity 1, but got %+v", prizes)
+	}
+	if n := len(h.service.GetLotteryResults("room-2")); n != 0 {
+		t.Errorf("Expected the clone to have no results, but got %d", n)
+	}
+
+	tests := []struct {
+		name string
+		form string
+		want int
+	}{
+		{"target has data", "source=" + testTenantID + "&target=room-2", http.StatusConflict},
+		{"unknown source", "source=nobody&target=room-3", http.StatusNotFound},
+		{"missing target", "source=" + testTenantID, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		if w := clone(tt.form); w.Code != tt.want {
+			t.Errorf("%s: expected status %d, but got %d: %s", tt.name, tt.want, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestAdminMiddleware_RejectsUnauthenticated(t *testing.T) {
+	tests := []struct {
+		name       string
+		configured string
+		token      string
+		want       int
+	}{
+		{"disabled without a configured token", "", testAdminToken, http.StatusNotFound},
+		{"missing token", testAdminToken, "", http.StatusUnauthorized},
+		{"wrong token", testAdminToken, "guess", http.StatusUnauthorized},
+		{"correct token", testAdminToken, testAdminToken, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, h := newTestRouter(t)
+			h.SetAdminToken(tt.configured)
+			h.service.AddParticipant(testTenantID, "001", "Alice")
+
+			for _, req := range []*http.Request{
+				newAdminRequest(http.MethodGet, "/admin/sessions", tt.token, nil),
+				newAdminRequest(http.MethodPost, "/admin/sessions/clear", tt.token, bytes.NewBufferString("tenantID="+testTenantID)),
+			} {
+				w := httptest.NewRecorder()
+				r.ServeHTTP(w, req)
+				want := tt.want
+				if want == http.StatusOK && req.Method == http.MethodPost {
+					want = http.StatusNoContent
+				}
+				if w.Code != want {
+					t.Errorf("%s %s: expected status %d, but got %d", req.Method, req.URL.Path, want, w.Code)
+				}
+			}
+			if cleared := len(h.service.ListSessions()) == 0; cleared != (tt.want == http.StatusOK) {
+				t.Errorf("Expected the session to be cleared only with the correct token, but cleared=%t", cleared)
+			}
+		})
+	}
+}
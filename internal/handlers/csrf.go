@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"lottery/internal/i18n"
+)
+
+const (
+	csrfHeaderName = "X-CSRF-Token" // Sent by htmx on every request; see layout.html
+	csrfFormField  = "csrfToken"    // For plain HTML forms
+)
+
+// csrfTokenFor returns the CSRF token of a tenant: an HMAC of the tenant ID under
+// the cookie secret. Another site can make a browser send the tenant's cookies,
+// or share its IP, but cannot compute the token.
+func csrfTokenFor(secret []byte, tenantID string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte("csrf:" + tenantID))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// csrfToken returns the CSRF token pages rendered for c must send back.
+func (h *HTTPHandler) csrfToken(c *gin.Context) string {
+	return csrfTokenFor(h.cookieSecret, c.GetString(tenantIDKey))
+}
+
+// CSRFMiddleware rejects unsafe requests to the tenant routes that do not carry
+// the tenant's CSRF token. It must run after TenantMiddleware.
+func (h *HTTPHandler) CSRFMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if h.checkCSRF(c, c.GetString(tenantIDKey)) {
+			c.Next()
+		}
+	}
+}
+
+// csrfGuardedGETs are the GET routes that change state and so need the token
+// despite their method. EventSource cannot set headers, so they also accept it
+// as the csrfToken query parameter.
+var csrfGuardedGETs = map[string]bool{
+	"/draw/stream": true, // Draws winners until the prize is empty
+}
+
+// checkCSRF reports whether c may act on tenantID, writing a 403 response if
+// not. Safe methods pass, except on csrfGuardedGETs, and so do JSON bodies: a
+// cross-site form cannot send them without a CORS preflight, which this server
+// never grants. Everything else needs the token in the X-CSRF-Token header or,
+// for plain forms, in the csrfToken field; the form is only read when the
+// header is missing, so handlers that limit the body size still see it unread.
+func (h *HTTPHandler) checkCSRF(c *gin.Context, tenantID string) bool {
+	switch c.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		if !csrfGuardedGETs[c.FullPath()] {
+			return true
+		}
+		token := c.Query(csrfFormField)
+		if token == "" {
+			token = c.GetHeader(csrfHeaderName)
+		}
+		return h.requireCSRFToken(c, tenantID, token)
+	}
+	if c.ContentType() == "application/json" {
+		return true
+	}
+
+	token := c.GetHeader(csrfHeaderName)
+	if token == "" && c.ContentType() == "application/x-www-form-urlencoded" {
+		token = c.PostForm(csrfFormField)
+	}
+	return h.requireCSRFToken(c, tenantID, token)
+}
+
+// requireCSRFToken reports whether token is tenantID's CSRF token, writing a
+// 403 response if not.
+func (h *HTTPHandler) requireCSRFToken(c *gin.Context, tenantID, token string) bool {
+	if !hmac.Equal([]byte(token), []byte(csrfTokenFor(h.cookieSecret, tenantID))) {
+		h.logFor(c).Infof("Rejected %s %s without a valid CSRF token", c.Request.Method, c.Request.URL.Path)
+		c.String(http.StatusForbidden, i18n.T(h.lang(c), "csrf_invalid"))
+		c.Abort()
+		return false
+	}
+	return true
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCSRFMiddleware(t *testing.T) {
+	r, h := newTestRouter(t)
+	addPrize := func(token string) *httptest.ResponseRecorder {
+		req := newTenantRequest(http.MethodPost, "/prizes", bytes.NewBufferString("prizeName=頭獎&itemName=電視&quantity=1"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if token == "" {
+			req.Header.Del(csrfHeaderName)
+		} else {
+			req.Header.Set(csrfHeaderName, token)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"missing token", ""},
+		{"invalid token", "not-the-token"},
+		{"another tenant's token", csrfTokenFor([]byte(testCookieSecret), "other-192.0.2.1")},
+	}
+	for _, tt := range tests {
+		w := addPrize(tt.token)
+		if w.Code != http.StatusForbidden || !strings.Contains(w.Body.String(), "請重新整理頁面") {
+			t.Errorf("%s: expected 403 with a reload hint, but got %d %q", tt.name, w.Code, w.Body.String())
+		}
+	}
+	if n := len(h.service.GetPrizes(testTenantID)); n != 0 {
+		t.Fatalf("Expected rejected requests to change nothing, but got %d prizes", n)
+	}
+
+	if w := addPrize(csrfTokenFor([]byte(testCookieSecret), testTenantID)); w.Code != http.StatusOK {
+		t.Errorf("Expected the valid token to pass, but got %d: %s", w.Code, w.Body.String())
+	}
+	if n := len(h.service.GetPrizes(testTenantID)); n != 1 {
+		t.Errorf("Expected the prize to be added, but got %d prizes", n)
+	}
+
+	// JSON bodies cannot be sent cross-site without a preflight, so the API needs no token.
+	req := newTenantRequest(http.MethodPost, "/api/prizes/bulk", bytes.NewBufferString(`[{"name":"二獎","item":"手機","quantity":1}]`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Del(csrfHeaderName)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code == http.StatusForbidden {
+		t.Errorf("Expected the JSON API to be exempt, but got 403")
+	}
+}
+
+func TestCSRFToken_RenderedInLayout(t *testing.T) {
+	r, _ := newTestRouter(t)
+	req := newTenantRequest(http.MethodGet, "/prizes", nil)
+	req.Header.Del(csrfHeaderName)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	want := `<meta name="csrf-token" content="` + csrfTokenFor([]byte(testCookieSecret), testTenantID) + `">`
+	if !strings.Contains(w.Body.String(), want) {
+		t.Errorf("Expected the page to carry %s, but got:\n%s", want, w.Body.String())
+	}
+}
+
+func TestSetTenant_RequiresCSRFToken(t *testing.T) {
+	r, _ := newTestRouter(t)
+	req := httptest.NewRequest(http.MethodPost, "/set-tenant", strings.NewReader("tenantName=attacker"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden || len(w.Result().Cookies()) != 0 {
+		t.Errorf("Expected a forged tenant change to be rejected, but got %d with cookies %v", w.Code, w.Result().Cookies())
+	}
+}
+
+func TestCSRFMiddleware_DrawStreamNeedsToken(t *testing.T) {
+	r, h := newTestRouter(t)
+	h.service.AddPrize(testTenantID, "參加獎", "紅包", 2, false)
+	h.service.AddParticipant(testTenantID, "001", "Alice")
+	h.service.AddParticipant(testTenantID, "002", "Bob")
+
+	// A cross-site link or navigation carries the cookie but not the token.
+	req := newTenantRequest(http.MethodGet, "/draw/stream?prize=參加獎&intervalMs=1", nil)
+	req.Header.Del(csrfHeaderName)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected a tokenless draw stream to be rejected with 403, but got %d", w.Code)
+	}
+	if n := len(h.service.GetLotteryResults(testTenantID)); n != 0 {
+		t.Errorf("Expected the rejected stream not to draw, but got %d results", n)
+	}
+
+	req = newTenantRequest(http.MethodGet, "/draw/stream?prize=參加獎&intervalMs=1&csrfToken="+csrfTokenFor([]byte(testCookieSecret), testTenantID), nil)
+	req.Header.Del(csrfHeaderName)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || len(h.service.GetLotteryResults(testTenantID)) != 2 {
+		t.Errorf("Expected the token in the query to be accepted, but got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestCSRFToken_NotForgeableThroughTenantName(t *testing.T) {
+	r, h := newTestRouter(t)
+
+	// POST /set-tenant signs any name; the MAC in the returned cookie must not
+	// double as the victim's CSRF token.
+	forged := signTenantName([]byte(testCookieSecret), "csrf:"+testTenantID)
+	token := forged[strings.LastIndexByte(forged, '.')+1:]
+	req := newTenantRequest(http.MethodPost, "/prizes", bytes.NewBufferString("prizeName=頭獎&itemName=電視&quantity=1"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set(csrfHeaderName, token)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected a token taken from a csrf:-prefixed tenant cookie to be rejected, but got %d", w.Code)
+	}
+	if n := len(h.service.GetPrizes(testTenantID)); n != 0 {
+		t.Errorf("Expected the forged request to change nothing, but got %d prizes", n)
+	}
+}
+
+func TestClearTenant_RequiresCSRFToken(t *testing.T) {
+	r, h := newTestRouter(t)
+	h.service.AddPrize(testTenantID, "頭獎", "電視", 1, false)
+
+	// A cross-site navigation carries the cookie but neither the token nor a POST body.
+	for _, method := range []string{http.MethodGet, http.MethodPost} {
+		req := newTenantRequest(method, "/clear-tenant", nil)
+		req.Header.Del(csrfHeaderName)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if n := len(h.service.GetPrizes(testTenantID)); n != 1 {
+			t.Fatalf("%s: expected a tokenless clear to leave the session intact, but got %d prizes (status %d)", method, n, w.Code)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newTenantRequest(http.MethodPost, "/clear-tenant", nil))
+	if w.Code != http.StatusFound || len(h.service.GetPrizes(testTenantID)) != 0 {
+		t.Errorf("Expected the clear with a valid token to wipe the session, but got %d", w.Code)
+	}
+}
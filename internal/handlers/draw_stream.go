@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"lottery/internal/i18n"
+	"lottery/internal/models"
+	"lottery/internal/services"
+)
+
+// Bounds and default of the "intervalMs" query parameter of StreamDraws.
+const (
+	drawStreamMinInterval     = 100 * time.Millisecond
+	drawStreamMaxInterval     = 10 * time.Second
+	drawStreamDefaultInterval = time.Second
+)
+
+// drawStreamWinner is the payload of a "winner" event.
+type drawStreamWinner struct {
+	Result       *models.LotteryResult `json:"result"`
+	Announcement string                `json:"announcement"`
+}
+
+// drawStreamDone is the payload of the final "done" event.
+type drawStreamDone struct {
+	Drawn  int    `json:"drawn"`
+	Reason string `json:"reason"` // Why the stream stopped, e.g. the prize ran out
+}
+
+// StreamDraws draws the prize named by the "prize" query parameter one winner
+// at a time, emitting each as a "winner" event, until it is exhausted or nobody
+// eligible is left; a final "done" event says why it stopped. Draws are
+// "intervalMs" milliseconds apart, clamped to [drawStreamMinInterval,
+// drawStreamMaxInterval]. No winner is drawn after the client disconnects. If
+// not even the first winner can be drawn, the error is returned as a plain
+// response with the status of drawErrorStatus (422 where that would be 200)
+// instead of a stream.
+//
+// The route is in csrfGuardedGETs, so CSRFMiddleware has checked the token.
+func (h *HTTPHandler) StreamDraws(c *gin.Context) {
+	tenantID := c.GetString(tenantIDKey)
+	prizeName := c.Query("prize")
+	interval := drawStreamDefaultInterval
+	if v := c.Query("intervalMs"); v != "" {
+		ms, err := strconv.Atoi(v)
+		if err != nil {
+			c.String(http.StatusBadRequest, "Invalid intervalMs")
+			return
+		}
+		interval = min(max(time.Duration(ms)*time.Millisecond, drawStreamMinInterval), drawStreamMaxInterval)
+	}
+
+	ctx := c.Request.Context()
+	lang := h.lang(c)
+	drawn := 0
+	for {
+		result, err := h.service.Draw(tenantID, prizeName)
+		if err != nil {
+			if drawn == 0 {
+				status := drawErrorStatus(err)
+				if status == http.StatusOK {
+					status = http.StatusUnprocessableEntity
+				}
+				c.String(status, i18n.Localize(lang, err))
+				return
+			}
+			reason := i18n.Localize(lang, err)
+			if errors.Is(err, services.ErrPrizeExhausted) {
+				reason = i18n.T(lang, "stream_all_drawn")
+			}
+			c.SSEvent("done", drawStreamDone{Drawn: drawn, Reason: reason})
+			c.Writer.Flush()
+			h.logFor(c).Infof("Draw stream for prize %q stopped after %d winners: %v", prizeName, drawn, err)
+			return
+		}
+		if drawn == 0 {
+			c.Header("Content-Type", "text/event-stream")
+			c.Header("Cache-Control", "no-cache")
+			c.Header("Connection", "keep-alive")
+		}
+		drawn++
+		c.SSEvent("winner", drawStreamWinner{Result: result, Announcement: h.service.AnnounceIn(lang, tenantID, result)})
+		c.Writer.Flush()
+
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			h.logFor(c).Infof("Draw stream for prize %q cancelled by the client after %d winners", prizeName, drawn)
+			return
+		}
+	}
+}
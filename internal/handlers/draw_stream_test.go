@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStreamDraws(t *testing.T) {
+	r, h := newTestRouter(t)
+	h.service.AddPrize(testTenantID, "參加獎", "紅包", 3, false)
+	for _, id := range []string{"001", "002", "003", "004", "005"} {
+		h.service.AddParticipant(testTenantID, id, "P"+id)
+	}
+
+	w := httptest.NewRecorder()
+	// EventSource cannot set headers, so the page sends the token in the query.
+	req := newTenantRequest(http.MethodGet, "/draw/stream?prize=參加獎&intervalMs=1&csrfToken="+csrfTokenFor([]byte(testCookieSecret), testTenantID), nil)
+	req.Header.Del(csrfHeaderName)
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d: %s", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/event-stream") {
+		t.Errorf("Expected an event stream, but got content type %q", got)
+	}
+	body := w.Body.String()
+	if n := strings.Count(body, "event:winner"); n != 3 {
+		t.Errorf("Expected 3 winner events, one per unit, but got %d:\n%s", n, body)
+	}
+	if !strings.Contains(body, "event:done") || !strings.Contains(body, `"drawn":3`) {
+		t.Errorf("Expected a final done event, but got:\n%s", body)
+	}
+	if n := len(h.service.GetLotteryResults(testTenantID)); n != 3 {
+		t.Errorf("Expected 3 results, but got %d", n)
+	}
+}
+
+func TestStreamDraws_NothingToDraw(t *testing.T) {
+	r, _ := newTestRouter(t)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newTenantRequest(http.MethodGet, "/draw/stream?prize=不存在", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown prize, but got %d", w.Code)
+	}
+}
+
+func TestStreamDraws_StopsOnDisconnect(t *testing.T) {
+	r, h := newTestRouter(t)
+	h.service.AddPrize(testTenantID, "參加獎", "紅包", 5, false)
+	for _, id := range []string{"001", "002", "003", "004", "005"} {
+		h.service.AddParticipant(testTenantID, id, "P"+id)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req := newTenantRequest(http.MethodGet, "/draw/stream?prize=參加獎&intervalMs=200", nil).WithContext(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for len(h.service.GetLotteryResults(testTenantID)) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the stream to end once the client disconnected")
+	}
+	if n := len(h.service.GetLotteryResults(testTenantID)); n != 1 {
+		t.Errorf("Expected no draws after the disconnect, but got %d results", n)
+	}
+}
@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// notModified sets an ETag derived from the tenant's services.LotteryService.Version
+// and the response language, and answers 304 Not Modified if the request's
+// If-None-Match already names it. Handlers of polled list partials call it
+// before rendering and stop when it reports true.
+func (h *HTTPHandler) notModified(c *gin.Context) bool {
+	etag := fmt.Sprintf(`W/"%d-%s"`, h.service.Version(c.GetString(tenantIDKey)), h.lang(c))
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "no-cache") // Revalidate on every poll
+	for _, candidate := range strings.Split(c.GetHeader("If-None-Match"), ",") {
+		if candidate = strings.TrimSpace(candidate); candidate == etag || candidate == "*" {
+			c.Status(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestListPartials_ETag(t *testing.T) {
+	tests := []struct {
+		path   string
+		mutate string // Form posted to /prizes or /participants between the polls
+		target string
+	}{
+		{"/prizes/list", "prizeName=頭獎&itemName=電視&quantity=1", "/prizes"},
+		{"/participants/list", "participantID=001&participantName=Alice", "/participants"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			r, _ := newTestRouter(t)
+			poll := func(etag string) *httptest.ResponseRecorder {
+				req := newTenantRequest(http.MethodGet, tt.path, nil)
+				if etag != "" {
+					req.Header.Set("If-None-Match", etag)
+				}
+				w := httptest.NewRecorder()
+				r.ServeHTTP(w, req)
+				return w
+			}
+
+			first := poll("")
+			etag := first.Header().Get("ETag")
+			if first.Code != http.StatusOK || etag == "" {
+				t.Fatalf("Expected 200 with an ETag, but got %d with %q", first.Code, etag)
+			}
+			if w := poll(etag); w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+				t.Errorf("Expected 304 with no body for an unchanged list, but got %d: %s", w.Code, w.Body.String())
+			}
+
+			req := newTenantRequest(http.MethodPost, tt.target, bytes.NewBufferString(tt.mutate))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			r.ServeHTTP(httptest.NewRecorder(), req)
+
+			w := poll(etag)
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected 200 after a change, but got %d", w.Code)
+			}
+			if next := w.Header().Get("ETag"); next == etag {
+				t.Errorf("Expected a new ETag after a change, but got %q again", next)
+			}
+		})
+	}
+}
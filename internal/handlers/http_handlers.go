@@ -1,47 +1,137 @@
 package handlers
 
 import (
+	"bufio"
 	"bytes"
+	"crypto/rand"
 	"encoding/csv"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"html/template"
 	"io"
-	"log"
+	"maps"
+	"mime/multipart"
 	"net/http"
+	"runtime"
+	"slices"
 	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"lottery/internal/i18n"
+	"lottery/internal/models"
 	"lottery/internal/services"
 )
 
 const tenantCookieName = "lottery_tenant_name"
 const tenantIDKey = "tenantID"
 
+// csvTimeLayout is how timestamps are written in CSV exports, chosen to be Excel friendly.
+const csvTimeLayout = "2006-01-02 15:04:05"
+
+// Header rows written by the prize and participant exports. The uploaders skip a
+// first row that matches exactly, so an exported file can be uploaded again as is.
+var (
+	prizeCSVHeader       = []string{"獎項名稱", "獎品名稱", "數量", "從全體抽取", "限定部門", "圖片網址", "全數頒發", "得獎人數上限", "獎品清單", "排除得主獎項"}
+	participantCSVHeader = []string{"員工編號", "員工姓名", "權重", "部門"}
+)
+
+// BuildInfo describes the running binary, reported by the /version endpoint.
+type BuildInfo struct {
+	Version   string    // Injected at build time via -ldflags "-X main.version=..."
+	StartTime time.Time // When the process started, used to compute uptime
+}
+
+// UploadLimits bounds what a single upload may contain.
+type UploadLimits struct {
+	MaxBytes int64 // Largest accepted file, in bytes
+	MaxRows  int   // Most data rows accepted from one CSV file
+}
+
+// DefaultUploadLimits is used by NewHTTPHandler.
+var DefaultUploadLimits = UploadLimits{MaxBytes: 5 << 20, MaxRows: 50000}
+
+// multipartOverhead is the slack allowed on top of MaxBytes for the multipart
+// boundaries and headers around the uploaded file.
+const multipartOverhead = 64 << 10
+
 // HTTPHandler holds the dependencies for the HTTP handlers, like the lottery service.
 type HTTPHandler struct {
 	service   *services.LotteryService
 	templates *template.Template
+	build     BuildInfo
+	imports   *importTracker
+	wsHubs    *wsHubs
+	log       services.Logger
+	limits    UploadLimits
+
+	cookieSecret   []byte        // HMAC key for tenant cookies; see SetCookieSecret
+	cookieOpts     CookieOptions // Attributes of the tenant cookies; see SetCookieOptions
+	browserTokens  bool          // Key tenants by a per-browser token instead of IP; see SetBrowserTokens
+	adminToken     string        // Bearer token for the /admin routes; empty disables them. See SetAdminToken
+	revealDuration time.Duration // How long the front end spins before a reveal; see SetRevealDuration
+	ready          atomic.Bool   // Set by SetReady once the persisted state is loaded
+	csvUploads     atomic.Int64  // Accepted CSV uploads, exported by Metrics
+	drawLimiter    *rateLimiter  // Shared by the draw routes and the WebSocket "draw" command
+}
+
+// TemplateFuncs are the functions the page templates rely on. Parse the
+// templates with them before handing them to NewHTTPHandler.
+var TemplateFuncs = template.FuncMap{
+	"maskID": services.MaskID,
+	"t":      i18n.T, // {{ t .Lang "id" args... }}, with Lang from the handler's data
 }
 
-// NewHTTPHandler creates a new HTTPHandler.
-func NewHTTPHandler(service *services.LotteryService, templates *template.Template) *HTTPHandler {
+// NewHTTPHandler creates a new HTTPHandler with DefaultUploadLimits.
+func NewHTTPHandler(service *services.LotteryService, templates *template.Template, build BuildInfo) *HTTPHandler {
+	return NewHTTPHandlerWithLimits(service, templates, build, DefaultUploadLimits)
+}
+
+// NewHTTPHandlerWithLimits creates a new HTTPHandler with custom upload limits.
+func NewHTTPHandlerWithLimits(service *services.LotteryService, templates *template.Template, build BuildInfo, limits UploadLimits) *HTTPHandler {
 	return &HTTPHandler{
 		service:   service,
 		templates: templates,
+		build:     build,
+		imports:   newImportTracker(),
+		wsHubs:    newWSHubs(),
+		log:       services.DefaultLogger(),
+		limits:    limits,
+
+		cookieSecret: newCookieSecret(),
+		cookieOpts:   DefaultCookieOptions,
+
+		revealDuration: DefaultRevealDuration,
+		drawLimiter:    newRateLimiter(defaultDrawRate, defaultDrawBurst),
 	}
 }
 
+// DefaultRevealDuration is how long the draw reveal spins unless SetRevealDuration is called.
+const DefaultRevealDuration = 3 * time.Second
+
+// SetRevealDuration changes how long the front end animates before revealing a
+// winner drawn via POST /draw/reveal. It must be called before routes are served.
+func (h *HTTPHandler) SetRevealDuration(d time.Duration) {
+	h.revealDuration = d
+}
+
+// SetLogger replaces the handler's logger. It must be called before routes are served.
+func (h *HTTPHandler) SetLogger(l services.Logger) {
+	h.log = l
+}
+
+// logFor returns the handler logger tagged with the request's tenant ID, if any.
+func (h *HTTPHandler) logFor(c *gin.Context) services.Logger {
+	return services.WithTenant(h.log, c.GetString(tenantIDKey))
+}
+
 // TenantMiddleware identifies the tenant for each request.
 func (h *HTTPHandler) TenantMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		tenantName, err := c.Cookie(tenantCookieName)
-		if err != nil {
-			// If cookie is not set, use a default name based on IP
-			tenantName = fmt.Sprintf("user-%s", c.ClientIP())
-		}
-
-		// Combine name and IP for a unique tenant ID
-		tenantID := fmt.Sprintf("%s-%s", tenantName, c.ClientIP())
+		tenantID := h.resolveTenantID(c)
 		c.Set(tenantIDKey, tenantID)
 
 		// This call also updates the LastActivity timestamp for the session
@@ -54,77 +144,200 @@ func (h *HTTPHandler) TenantMiddleware() gin.HandlerFunc {
 // renderPage is a helper for two-step template rendering.
 func (h *HTTPHandler) renderPage(c *gin.Context, pageData gin.H, contentTmpl string) {
 	// Automatically add current tenant name to all page renders
-	currentTenant, _ := c.Cookie(tenantCookieName)
+	currentTenant, _ := h.tenantName(c)
 	pageData["CurrentTenant"] = currentTenant
+	pageData["CSRFToken"] = h.csrfToken(c)
 
 	buf := new(bytes.Buffer)
 	err := h.templates.ExecuteTemplate(buf, contentTmpl, pageData)
 	if err != nil {
-		log.Printf("Error executing content template %s: %v", contentTmpl, err)
+		h.logFor(c).Errorf("Error executing content template %s: %v", contentTmpl, err)
 		c.String(http.StatusInternalServerError, "Template rendering error")
 		return
 	}
 
 	pageData["PageContent"] = template.HTML(buf.String())
 
-	err = h.templates.ExecuteTemplate(c.Writer, "layout.html", pageData)
-	if err != nil {
-		log.Printf("Error executing layout template: %v", err)
+	h.renderPartial(c, "layout.html", pageData)
+}
+
+// renderPartial executes the named template into a buffer and only then sends
+// it, with the status already set on c (200 unless a handler chose another).
+// A template error therefore never leaves a half-written page behind a 200:
+// the client gets a plain 500 instead.
+func (h *HTTPHandler) renderPartial(c *gin.Context, name string, data any) {
+	buf := new(bytes.Buffer)
+	if err := h.templates.ExecuteTemplate(buf, name, data); err != nil {
+		h.logFor(c).Errorf("Error executing template %s: %v", name, err)
 		c.String(http.StatusInternalServerError, "Template rendering error")
+		return
 	}
+	c.Data(c.Writer.Status(), "text/html; charset=utf-8", buf.Bytes())
 }
 
 // RegisterPublicRoutes registers routes that do not require tenant identification.
 func (h *HTTPHandler) RegisterPublicRoutes(router *gin.Engine) {
 	router.POST("/set-tenant", h.SetTenant)
-	router.GET("/clear-tenant", h.ClearTenant) // New route
+	router.POST("/clear-tenant", h.ClearTenant)
+	router.GET("/set-lang", h.SetLang)
+	router.GET("/version", h.ShowVersion)
+	router.GET("/healthz", h.Healthz)
+	router.GET("/readyz", h.Readyz)
+	router.GET("/metrics", h.Metrics)
 }
 
 // RegisterTenantRoutes registers routes that require the tenant middleware.
+// Draws and uploads are rate limited per tenant, each with its own allowance;
+// draws sent over /ws spend the same allowance as the draw routes.
+// Every unsafe request must pass CSRFMiddleware.
 func (h *HTTPHandler) RegisterTenantRoutes(router *gin.RouterGroup) {
+	router.Use(h.CSRFMiddleware())
+	drawLimit := rateLimitMiddleware(h.drawLimiter)
+	uploadLimit := DrawRateLimitMiddleware(defaultDrawRate, defaultDrawBurst)
+
 	router.GET("/", h.ShowIndex)
 	router.GET("/prizes", h.ShowPrizesPage)
 	router.POST("/prizes", h.AddPrize)
-	router.POST("/upload-prizes-csv", h.UploadPrizesCSV)
+	router.POST("/prizes/update", h.UpdatePrize)
+	router.POST("/prizes/rename", h.RenamePrize)
+	router.POST("/prizes/reorder", h.ReorderPrizes)
+	router.POST("/prizes/delete", h.DeletePrize)
+	router.POST("/upload-prizes-csv", uploadLimit, h.UploadPrizesCSV)
+	router.POST("/api/prizes/bulk", uploadLimit, h.BulkImportPrizes)
+	router.GET("/api/participants/:id/wins", h.GetParticipantWins)
+	router.GET("/api/summary", h.GetPrizeSummary)
+	router.GET("/api/progress", h.GetDrawProgress)
+	router.GET("/api/prizes/:name/odds", h.GetPrizeOdds)
+	router.GET("/api/eligibility", h.GetEligibility)
 	router.GET("/participants", h.ShowParticipantsPage)
 	router.POST("/participants", h.AddParticipant)
-	router.POST("/upload-participants-csv", h.UploadParticipantsCSV)
+	router.POST("/participants/delete", h.RemoveParticipant)
+	router.POST("/participants/clear", h.ClearParticipants)
+	router.POST("/participants/presence", h.SetPresence)
+	router.POST("/upload-participants-csv", uploadLimit, h.UploadParticipantsCSV)
+	router.POST("/participants/paste", uploadLimit, h.PasteParticipants)
+	router.POST("/upload-participants-csv/async", uploadLimit, h.StartParticipantImport)
+	router.GET("/import-progress/:id", h.StreamImportProgress)
+	router.GET("/import-errors.csv", h.ExportImportErrorsCSV)
+	router.GET("/participants/list", h.GetParticipantListPartial)
 	router.GET("/lottery", h.ShowLotteryPage)
-	router.POST("/draw/animation", h.PerformDrawAnimation) // New route
+	router.POST("/draw/animation", drawLimit, h.PerformDrawAnimation) // New route
+	router.POST("/draw/reveal", drawLimit, h.DrawWithReveal)
+	router.POST("/draw/group", drawLimit, h.DrawGroup)
+	router.GET("/draw/stream", drawLimit, h.StreamDraws)
+	router.GET("/draw/preview", h.PreviewDraw)
+	router.POST("/draw-all", drawLimit, h.DrawAllRemaining)
+	router.POST("/undo-draw", h.UndoLastDraw)
+	router.POST("/undo-batch", h.UndoBatch)
+	router.POST("/reset-results", h.ResetResults)
 	router.GET("/prizes/list", h.GetPrizeListPartial)
+	router.GET("/prizes/:name/eligible-csv", h.ExportEligibleCSV)
 	router.GET("/export-results-csv", h.ExportResultsCSV)
+	router.GET("/export-results-xlsx", h.ExportResultsXLSX)
+	router.GET("/export-prizes-csv", h.ExportPrizesCSV)
+	router.GET("/export-participants-csv", h.ExportParticipantsCSV)
+	router.GET("/export-session-json", h.ExportSessionJSON)
+	router.GET("/audit", h.GetAuditLog)
+	router.POST("/import-session-json", uploadLimit, h.ImportSessionJSON)
+	router.GET("/results/grouped", h.ShowGroupedResults)
+	router.GET("/events", h.StreamEvents)
+	router.GET("/ws", h.ServeWebSocket)
+	router.GET("/projection", h.ShowProjection)
+	router.POST("/results/lock", h.LockResult)
+	router.POST("/results/claim", h.ClaimResult)
+	router.POST("/results/redraw", h.RedrawWinner)
+	router.POST("/settings/min-participants", h.SetMinParticipants)
+	router.POST("/settings/max-wins", h.SetMaxWins)
+	router.POST("/settings/unique-across-all", h.SetUniqueAcrossAll)
+	router.POST("/settings/allow-repeat-wins", h.SetAllowRepeatWins)
+	router.POST("/settings/no-consecutive-repeat", h.SetNoConsecutiveRepeat)
+	router.POST("/settings/seed", h.SetSeed)
+	router.POST("/settings/announcement", h.SetAnnouncementTemplate)
+	router.POST("/settings/mask-ids", h.SetMaskIDs)
 }
 
 // SetTenant handles setting the tenant name cookie.
 func (h *HTTPHandler) SetTenant(c *gin.Context) {
+	// The form was rendered for the tenant the browser has before the change.
+	if !h.checkCSRF(c, h.resolveTenantID(c)) {
+		return
+	}
 	tenantName := c.PostForm("tenantName")
 	if tenantName != "" {
-		// Set cookie for a year
-		c.SetCookie(tenantCookieName, tenantName, 3600*24*365, "/", "", false, true)
+		h.setCookie(c, tenantCookieName, signTenantName(h.cookieSecret, tenantName), int(h.cookieOpts.MaxAge/time.Second))
 	}
 	c.Redirect(http.StatusFound, "/")
 }
 
 // ClearTenant clears the user's session and cookie, then redirects to home.
+// Like SetTenant it is a POST that must carry the session's CSRF token, so
+// another site cannot wipe a victim's session with a link.
 func (h *HTTPHandler) ClearTenant(c *gin.Context) {
+	if !h.checkCSRF(c, h.resolveTenantID(c)) {
+		return
+	}
 	// This handler is on a public route, so it needs to construct the tenantID itself
 	// before clearing the cookie.
-	tenantName, err := c.Cookie(tenantCookieName)
-	if err == nil && tenantName != "" {
+	tenantName, ok := h.tenantName(c)
+	if ok && tenantName != "" {
 		// If the cookie exists, construct the tenantID and clear the session data.
-		tenantID := fmt.Sprintf("%s-%s", tenantName, c.ClientIP())
-		h.service.ClearSession(tenantID)
+		h.service.ClearSession(h.resolveTenantID(c))
 	}
 
-	// Clear the cookie by setting its max age to -1
-	c.SetCookie(tenantCookieName, "", -1, "/", "", false, true)
+	// Clear the cookies by setting their max age to -1
+	h.setCookie(c, tenantCookieName, "", -1)
+	if h.browserTokens {
+		h.setCookie(c, browserTokenCookieName, "", -1)
+	}
 
 	c.Redirect(http.StatusFound, "/")
 }
 
+// ShowVersion reports the build version, Go version and process uptime.
+// It is public and does not touch any tenant session.
+func (h *HTTPHandler) ShowVersion(c *gin.Context) {
+	uptime := time.Since(h.build.StartTime)
+	c.JSON(http.StatusOK, gin.H{
+		"version":       h.build.Version,
+		"goVersion":     runtime.Version(),
+		"uptime":        uptime.Round(time.Second).String(),
+		"uptimeSeconds": int64(uptime.Seconds()),
+	})
+}
+
+// SetReady marks whether the server has finished loading its state and can take traffic.
+func (h *HTTPHandler) SetReady(ready bool) {
+	h.ready.Store(ready)
+}
+
+// Healthz reports liveness with the uptime and session totals. It is public,
+// cheap, and does not create or touch any tenant session.
+func (h *HTTPHandler) Healthz(c *gin.Context) {
+	stats := h.service.Stats()
+	c.JSON(http.StatusOK, gin.H{
+		"status":        "ok",
+		"uptimeSeconds": int64(time.Since(h.build.StartTime).Seconds()),
+		"sessions":      stats.Sessions,
+		"participants":  stats.Participants,
+	})
+}
+
+// Readyz returns 503 until SetReady(true) is called and 200 afterwards.
+func (h *HTTPHandler) Readyz(c *gin.Context) {
+	if !h.ready.Load() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "loading"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ready"})
+}
+
 // ShowIndex handles the request for the home page.
 func (h *HTTPHandler) ShowIndex(c *gin.Context) {
-	h.renderPage(c, gin.H{"title": "首頁"}, "index.html")
+	data := gin.H{
+		"title":       "首頁",
+		"SetupStatus": h.service.GetSetupStatus(c.GetString(tenantIDKey)),
+	}
+	h.renderPage(c, data, "index.html")
 }
 
 // ShowPrizesPage handles the request for the prize setting page.
@@ -145,33 +358,239 @@ func (h *HTTPHandler) AddPrize(c *gin.Context) {
 	quantityStr := c.PostForm("quantity")
 	drawFromAllStr := c.PostForm("drawFromAll")
 
-	quantity, err := strconv.Atoi(quantityStr)
+	data := gin.H{}
+	quantity, err := strconv.Atoi(strings.TrimSpace(quantityStr))
 	if err != nil {
-		c.String(http.StatusBadRequest, "Invalid quantity")
+		data["Notice"] = i18n.T(h.lang(c), "prize_row_quantity", quantityStr)
+		data["Prizes"] = h.service.GetPrizes(tenantID)
+		h.renderPartial(c, "prize_list_container.html", data)
 		return
 	}
+	maxWinners := 0
+	if v := strings.TrimSpace(c.PostForm("maxWinners")); v != "" {
+		if maxWinners, err = strconv.Atoi(v); err != nil {
+			data["Notice"] = i18n.T(h.lang(c), "prize_row_max_winners", v)
+			data["Prizes"] = h.service.GetPrizes(tenantID)
+			h.renderPartial(c, "prize_list_container.html", data)
+			return
+		}
+	}
 	drawAllFlag := drawFromAllStr == "true"
+	allRemainingFlag := c.PostForm("allRemaining") == "true"
+
+	err = h.service.InsertPrize(tenantID, models.Prize{
+		Name:         prizeName,
+		Item:         itemName,
+		Quantity:     quantity,
+		DrawFromAll:  drawAllFlag,
+		AllRemaining: allRemainingFlag,
+		Group:        strings.TrimSpace(c.PostForm("group")),
+		ImageURL:     strings.TrimSpace(c.PostForm("imageURL")),
+		MaxWinners:   maxWinners,
+	})
+	if err != nil {
+		data["Notice"] = h.localize(c, err)
+	}
+	data["Prizes"] = h.service.GetPrizes(tenantID)
+	h.renderPartial(c, "prize_list_container.html", data)
+}
+
+// UpdatePrize handles the form submission for editing an existing prize.
+// Validation errors are shown above the re-rendered prize list.
+func (h *HTTPHandler) UpdatePrize(c *gin.Context) {
+	tenantID := c.GetString(tenantIDKey)
+	quantity, err := strconv.Atoi(c.PostForm("quantity"))
+	if err != nil {
+		c.String(http.StatusBadRequest, "Invalid quantity")
+		return
+	}
+
+	data := gin.H{}
+	if err := h.service.UpdatePrize(tenantID, c.PostForm("prizeName"), c.PostForm("itemName"), quantity, c.PostForm("drawFromAll") == "true"); err != nil {
+		data["Notice"] = h.localize(c, err)
+	}
+	data["Prizes"] = h.service.GetPrizes(tenantID)
+	h.renderPartial(c, "prize_list_container.html", data)
+}
+
+// RenamePrize renames a prize, carrying its winners and results over, and
+// re-renders the prize list with any error as a notice.
+func (h *HTTPHandler) RenamePrize(c *gin.Context) {
+	tenantID := c.GetString(tenantIDKey)
+
+	data := gin.H{}
+	if err := h.service.RenamePrize(tenantID, c.PostForm("prizeName"), c.PostForm("newName")); err != nil {
+		data["Notice"] = h.localize(c, err)
+	}
+	data["Prizes"] = h.service.GetPrizes(tenantID)
+	h.renderPartial(c, "prize_list_container.html", data)
+}
+
+// ReorderPrizes accepts a JSON array of prize names in the desired display order
+// and re-renders the prize list.
+func (h *HTTPHandler) ReorderPrizes(c *gin.Context) {
+	tenantID := c.GetString(tenantIDKey)
+	var names []string
+	if err := c.ShouldBindJSON(&names); err != nil {
+		c.String(http.StatusBadRequest, "Expected a JSON array of prize names: %v", err)
+		return
+	}
 
-	h.service.AddPrize(tenantID, prizeName, itemName, quantity, drawAllFlag)
+	data := gin.H{}
+	if err := h.service.ReorderPrizes(tenantID, names); err != nil {
+		data["Notice"] = h.localize(c, err)
+	}
+	data["Prizes"] = h.service.GetPrizes(tenantID)
+	h.renderPartial(c, "prize_list_container.html", data)
+}
+
+// DeletePrize handles the form submission for removing a prize.
+func (h *HTTPHandler) DeletePrize(c *gin.Context) {
+	tenantID := c.GetString(tenantIDKey)
+	if err := h.service.DeletePrize(tenantID, c.PostForm("prizeName")); err != nil {
+		c.String(http.StatusNotFound, h.localize(c, err))
+		return
+	}
 
 	data := gin.H{"Prizes": h.service.GetPrizes(tenantID)}
-	if err := h.templates.ExecuteTemplate(c.Writer, "prize_list_container.html", data); err != nil {
-		log.Printf("Error executing template: %v", err)
+	h.renderPartial(c, "prize_list_container.html", data)
+}
+
+// isPrizeCSVHeader reports whether record is prizeCSVHeader, or one of the
+// shorter headers exported before later columns were added.
+func isPrizeCSVHeader(record []string) bool {
+	return len(record) >= 5 && len(record) <= len(prizeCSVHeader) && slices.Equal(record, prizeCSVHeader[:len(record)])
+}
+
+// splitCSVList splits a ";"-separated CSV cell, dropping empty entries.
+func splitCSVList(cell string) []string {
+	var list []string
+	for _, entry := range strings.Split(cell, ";") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			list = append(list, entry)
+		}
+	}
+	return list
+}
+
+// parsePrizeRecord converts a prize CSV row of the form
+// "獎項名稱,獎品名稱,數量,從全體抽取[,限定部門[,圖片網址[,全數頒發[,得獎人數上限[,獎品清單[,排除得主獎項]]]]]]"
+// into a Prize. Optional columns may be left empty.
+func parsePrizeRecord(record []string) (models.Prize, error) {
+	if len(record) < 4 || len(record) > len(prizeCSVHeader) {
+		return models.Prize{}, i18n.NewError("prize_row_columns", len(prizeCSVHeader), len(record))
+	}
+	quantity, err := strconv.Atoi(strings.TrimSpace(record[2]))
+	if err != nil {
+		return models.Prize{}, i18n.NewError("prize_row_quantity", record[2])
+	}
+	drawFromAll, err := strconv.ParseBool(strings.TrimSpace(record[3]))
+	if err != nil {
+		return models.Prize{}, i18n.NewError("prize_row_draw_from_all", record[3])
+	}
+	prize := models.Prize{Name: record[0], Item: record[1], Quantity: quantity, DrawFromAll: drawFromAll}
+	if strings.Contains(record[1], ";") {
+		// "A;B;C" hands out A, B and C to successive winners.
+		prize.Item = ""
+		prize.Items = splitCSVList(record[1])
+	}
+	cell := func(i int) string {
+		if i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+	prize.Group = cell(4)
+	prize.ImageURL = cell(5)
+	if v := cell(6); v != "" {
+		if prize.AllRemaining, err = strconv.ParseBool(v); err != nil {
+			return models.Prize{}, i18n.NewError("prize_row_all_remaining", v)
+		}
+	}
+	if v := cell(7); v != "" {
+		if prize.MaxWinners, err = strconv.Atoi(v); err != nil {
+			return models.Prize{}, i18n.NewError("prize_row_max_winners", v)
+		}
+	}
+	if items := splitCSVList(cell(8)); len(items) > 0 {
+		prize.Items = items
+	}
+	prize.ExcludeWinnersOf = splitCSVList(cell(9))
+	return prize, nil
+}
+
+// bulkItemError reports why one item of a bulk import was rejected.
+type bulkItemError struct {
+	Index int    `json:"index"` // Position of the item in the request array
+	Name  string `json:"name"`
+	Error string `json:"error"`
+}
+
+// bulkImportSummary is the response of the bulk import endpoints.
+type bulkImportSummary struct {
+	Added    int             `json:"added"`
+	Rejected int             `json:"rejected"`
+	Errors   []bulkItemError `json:"errors"`
+}
+
+// BulkImportPrizes adds prizes from a JSON array of Prize objects. Invalid items
+// are rejected individually and listed in the summary; the rest are still added.
+func (h *HTTPHandler) BulkImportPrizes(c *gin.Context) {
+	tenantID := c.GetString(tenantIDKey)
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.limits.MaxBytes)
+
+	var prizes []models.Prize
+	if err := c.ShouldBindJSON(&prizes); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			h.rejectTooLarge(c)
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Expected a JSON array of prizes: %v", err)})
+		return
 	}
+	if len(prizes) > h.limits.MaxRows {
+		h.rejectTooManyRows(c)
+		return
+	}
+
+	summary := bulkImportSummary{Errors: []bulkItemError{}}
+	for i, prize := range prizes {
+		if err := h.service.InsertPrize(tenantID, prize); err != nil {
+			summary.Rejected++
+			summary.Errors = append(summary.Errors, bulkItemError{Index: i, Name: prize.Name, Error: h.localize(c, err)})
+			continue
+		}
+		summary.Added++
+	}
+	h.logFor(c).Infof("Bulk prize import: %d added, %d rejected", summary.Added, summary.Rejected)
+	c.JSON(http.StatusOK, summary)
 }
 
-// UploadPrizesCSV handles the CSV upload for prizes.
+// UploadPrizesCSV handles the CSV upload for prizes. Rows that fail to parse or
+// reuse an existing prize name are skipped and reported by line number above the
+// re-rendered prize list. A first row equal to prizeCSVHeader is always skipped;
+// setting the "hasHeader" form field to "true" skips whatever the first row holds.
 func (h *HTTPHandler) UploadPrizesCSV(c *gin.Context) {
 	tenantID := c.GetString(tenantIDKey)
-	file, _, err := c.Request.FormFile("prizeCSV")
-	if err != nil {
-		c.String(http.StatusBadRequest, "Error retrieving file: %v", err)
+	data, ok := h.readUpload(c, "prizeCSV")
+	if !ok {
 		return
 	}
-	defer file.Close()
+	h.csvUploads.Add(1)
+	hasHeader := c.PostForm("hasHeader") == "true"
 
-	reader := csv.NewReader(file)
-	for {
+	// Parse the whole file before inserting anything, so an oversized file
+	// is rejected without a partial import.
+	reader := newUploadCSVReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1 // Row widths are checked by parsePrizeRecord so bad rows can be reported
+	type parsedPrize struct {
+		prize models.Prize
+		line  int
+	}
+	var prizes []parsedPrize
+	var rowErrors []string
+	for first, rows := true, 0; ; first = false {
 		record, err := reader.Read()
 		if err == io.EOF {
 			break
@@ -180,32 +599,78 @@ func (h *HTTPHandler) UploadPrizesCSV(c *gin.Context) {
 			c.String(http.StatusInternalServerError, "Error reading CSV: %v", err)
 			return
 		}
-		if len(record) != 4 {
-			log.Printf("Skipping malformed CSV record: %v", record)
+		if first && (hasHeader || isPrizeCSVHeader(record)) {
+			continue
+		}
+		if rows++; rows > h.limits.MaxRows {
+			h.rejectTooManyRows(c)
+			return
+		}
+		line, _ := reader.FieldPos(0)
+		prize, err := parsePrizeRecord(record)
+		if err != nil {
+			h.logFor(c).Infof("Skipping malformed prize CSV record %v: %v", record, err)
+			rowErrors = append(rowErrors, i18n.T(h.lang(c), "row_error", line, h.localize(c, err)))
 			continue
 		}
-		prizeName, itemName := record[0], record[1]
-		quantity, _ := strconv.Atoi(record[2])
-		drawFromAll, _ := strconv.ParseBool(record[3])
-		h.service.AddPrize(tenantID, prizeName, itemName, quantity, drawFromAll)
+		prizes = append(prizes, parsedPrize{prize, line})
 	}
-
-	data := gin.H{"Prizes": h.service.GetPrizes(tenantID)}
-	if err := h.templates.ExecuteTemplate(c.Writer, "prize_list_container.html", data); err != nil {
-		log.Printf("Error executing template: %v", err)
+	for _, p := range prizes {
+		if err := h.service.InsertPrize(tenantID, p.prize); err != nil {
+			rowErrors = append(rowErrors, i18n.T(h.lang(c), "row_error_named", p.line, h.localize(c, err), p.prize.Name))
+		}
 	}
+
+	page := gin.H{"Prizes": h.service.GetPrizes(tenantID), "RowErrors": rowErrors}
+	h.renderPartial(c, "prize_list_container.html", page)
 }
 
+// defaultParticipantPageSize is how many participants the roster shows per page.
+const defaultParticipantPageSize = 50
+
 // ShowParticipantsPage handles the request for the participant setting page.
+// The roster honors the q, offset and limit query parameters; see participantPageData.
 func (h *HTTPHandler) ShowParticipantsPage(c *gin.Context) {
-	tenantID := c.GetString(tenantIDKey)
-	data := gin.H{
-		"title":        "參與者設定",
-		"Participants": h.service.GetParticipants(tenantID),
-	}
+	data := gin.H{"title": "參與者設定"}
+	h.participantPageData(c, data)
 	h.renderPage(c, data, "participant_setting.html")
 }
 
+// participantPageData adds one page of the roster to data, filtered by the "q"
+// query parameter and positioned by "offset" and "limit".
+func (h *HTTPHandler) participantPageData(c *gin.Context, data gin.H) {
+	query := c.Query("q")
+	offset, _ := strconv.Atoi(c.Query("offset"))
+	limit, err := strconv.Atoi(c.Query("limit"))
+	if err != nil || limit <= 0 {
+		limit = defaultParticipantPageSize
+	}
+	offset = max(offset, 0)
+
+	participants, total := h.service.GetParticipantsPage(c.GetString(tenantIDKey), query, offset, limit)
+	data["Participants"] = participants
+	data["Query"] = query
+	data["Total"] = total
+	data["Limit"] = limit
+	data["Offset"] = offset
+	data["PageStart"] = min(offset+1, total)
+	data["PageEnd"] = offset + len(participants)
+	if offset > 0 {
+		data["PrevOffset"] = max(offset-limit, 0)
+		data["HasPrev"] = true
+	}
+	if offset+limit < total {
+		data["NextOffset"] = offset + limit
+		data["HasNext"] = true
+	}
+}
+
+// renderParticipantList renders the roster container with data plus the current page.
+func (h *HTTPHandler) renderParticipantList(c *gin.Context, data gin.H) {
+	h.participantPageData(c, data)
+	h.renderPartial(c, "participant_list_container.html", data)
+}
+
 // AddParticipant handles the form submission for adding a new participant.
 func (h *HTTPHandler) AddParticipant(c *gin.Context) {
 	tenantID := c.GetString(tenantIDKey)
@@ -217,26 +682,116 @@ func (h *HTTPHandler) AddParticipant(c *gin.Context) {
 		return
 	}
 
-	h.service.AddParticipant(tenantID, participantID, participantName)
+	weight := 1
+	if weightStr := c.PostForm("weight"); weightStr != "" {
+		n, err := strconv.Atoi(weightStr)
+		if err != nil || n <= 0 {
+			c.String(http.StatusBadRequest, "Invalid weight")
+			return
+		}
+		weight = n
+	}
+
+	err := h.service.InsertParticipant(tenantID, models.Participant{
+		ID:     participantID,
+		Name:   participantName,
+		Weight: weight,
+		Group:  strings.TrimSpace(c.PostForm("group")),
+	})
+
+	data := gin.H{}
+	if err != nil {
+		data["Notice"] = h.localize(c, err)
+	}
+	h.renderParticipantList(c, data)
+}
+
+// RemoveParticipant handles the form submission for removing a participant.
+func (h *HTTPHandler) RemoveParticipant(c *gin.Context) {
+	tenantID := c.GetString(tenantIDKey)
+	if err := h.service.RemoveParticipant(tenantID, c.PostForm("participantID")); err != nil {
+		c.String(http.StatusNotFound, h.localize(c, err))
+		return
+	}
+
+	h.renderParticipantList(c, gin.H{})
+}
+
+// SetPresence marks a participant present or absent and re-renders the roster.
+// The "present" form field is "true" for present; anything else marks them absent.
+// The lottery page is told to refresh so its eligible counts follow.
+func (h *HTTPHandler) SetPresence(c *gin.Context) {
+	tenantID := c.GetString(tenantIDKey)
+	data := gin.H{}
+	if err := h.service.SetPresence(tenantID, c.PostForm("participantID"), c.PostForm("present") == "true"); err != nil {
+		data["Notice"] = h.localize(c, err)
+	} else {
+		c.Header("HX-Trigger", "updateLotteryPage")
+	}
+	h.renderParticipantList(c, data)
+}
+
+// ClearParticipants removes participants in bulk. Unless the "alsoClearResults"
+// form field is "true", participants who have already won are kept.
+func (h *HTTPHandler) ClearParticipants(c *gin.Context) {
+	tenantID := c.GetString(tenantIDKey)
+	alsoClearResults := c.PostForm("alsoClearResults") == "true"
+
+	data := gin.H{}
+	removed, err := h.service.ClearParticipants(tenantID, alsoClearResults)
+	switch {
+	case errors.Is(err, services.ErrResultLocked):
+		data["Notice"] = i18n.T(h.lang(c), "participants_clear_locked")
+	case err != nil:
+		data["Notice"] = h.localize(c, err)
+	case alsoClearResults:
+		data["Notice"] = i18n.T(h.lang(c), "participants_cleared_all", removed)
+	default:
+		data["Notice"] = i18n.T(h.lang(c), "participants_cleared", removed)
+	}
+	h.renderParticipantList(c, data)
+}
 
-	data := gin.H{"Participants": h.service.GetParticipants(tenantID)}
-	if err := h.templates.ExecuteTemplate(c.Writer, "participant_list_container.html", data); err != nil {
-		log.Printf("Error executing template: %v", err)
+// parseParticipantRecord converts a participant CSV row of the form
+// "員工編號,員工姓名[,權重[,部門]]" into a Participant. The weight and group
+// columns are optional; leave the weight blank to set a group with the default weight.
+func parseParticipantRecord(record []string) (models.Participant, error) {
+	if len(record) < 2 || len(record) > 4 {
+		return models.Participant{}, i18n.NewError("row_columns", len(record))
+	}
+	participant := models.Participant{ID: record[0], Name: record[1], Weight: 1}
+	if len(record) == 4 {
+		participant.Group = strings.TrimSpace(record[3])
+	}
+	if len(record) >= 3 && record[2] != "" {
+		weight, err := strconv.Atoi(record[2])
+		if err != nil || weight <= 0 {
+			return models.Participant{}, i18n.NewError("row_weight", record[2])
+		}
+		participant.Weight = weight
 	}
+	return participant, nil
 }
 
-// UploadParticipantsCSV handles the CSV upload for participants.
+// UploadParticipantsCSV handles the CSV upload for participants. The first row is
+// skipped as a header under the same rules as UploadPrizesCSV. Rows whose ID is
+// already on the roster are skipped, unless the "upsert" field is "true", in
+// which case they update that participant's name.
 func (h *HTTPHandler) UploadParticipantsCSV(c *gin.Context) {
 	tenantID := c.GetString(tenantIDKey)
-	file, _, err := c.Request.FormFile("participantCSV")
-	if err != nil {
-		c.String(http.StatusBadRequest, "Error retrieving file: %v", err)
+	data, ok := h.readUpload(c, "participantCSV")
+	if !ok {
 		return
 	}
-	defer file.Close()
+	h.csvUploads.Add(1)
+	hasHeader := c.PostForm("hasHeader") == "true"
+	upsert := c.PostForm("upsert") == "true"
 
-	reader := csv.NewReader(file)
-	for {
+	reader := newUploadCSVReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1 // Row widths are checked below so malformed rows can be counted
+	var participants []participantRow
+	var rejected []services.ImportError
+	for first, rows := true, 0; ; first = false {
 		record, err := reader.Read()
 		if err == io.EOF {
 			break
@@ -245,42 +800,187 @@ func (h *HTTPHandler) UploadParticipantsCSV(c *gin.Context) {
 			c.String(http.StatusInternalServerError, "Error reading CSV: %v", err)
 			return
 		}
-		if len(record) != 2 {
-			log.Printf("Skipping malformed participant CSV record: %v", record)
+		if first && (hasHeader || slices.Equal(record, participantCSVHeader)) {
 			continue
 		}
-		h.service.AddParticipant(tenantID, record[0], record[1])
+		if rows++; rows > h.limits.MaxRows {
+			h.rejectTooManyRows(c)
+			return
+		}
+		line, _ := reader.FieldPos(0)
+		participant, err := parseParticipantRecord(record)
+		if err != nil {
+			h.logFor(c).Infof("Skipping malformed participant CSV record %v: %v", record, err)
+			rejected = append(rejected, services.ImportError{Line: line, Record: record, Reason: h.localize(c, err)})
+			continue
+		}
+		participants = append(participants, participantRow{line, record, participant})
+	}
+	if upsert {
+		inserted, updated, malformed := 0, 0, len(rejected)
+		for _, row := range participants {
+			switch wasUpdated, err := h.service.UpsertParticipant(tenantID, row.participant); {
+			case err != nil:
+				h.logFor(c).Infof("Skipping participant %q: %v", row.participant.ID, err)
+				rejected = append(rejected, row.rejected(h.localize(c, err)))
+				malformed++
+			case wasUpdated:
+				updated++
+			default:
+				inserted++
+			}
+		}
+		h.service.SetImportErrors(tenantID, rejected)
+		h.renderParticipantList(c, gin.H{
+			"ImportSummary": i18n.T(h.lang(c), "import_summary_upsert", inserted, updated, malformed),
+			"ImportErrors":  len(rejected),
+		})
+		return
+	}
+	h.insertParticipants(c, tenantID, participants, rejected)
+}
+
+// participantRow is a parsed import row with where it came from, so a row
+// the roster rejects can still be reported by line.
+type participantRow struct {
+	line        int
+	record      []string
+	participant models.Participant
+}
+
+// rejected reports the row as an import error with the given reason.
+func (row participantRow) rejected(reason string) services.ImportError {
+	return services.ImportError{Line: row.line, Record: row.record, Reason: reason}
+}
+
+// insertParticipants adds parsed participants to the roster, skipping IDs that
+// are already on it, and renders the participant list with a summary that
+// also counts the malformed rows the caller rejected while parsing. Every
+// rejected row is kept for GET /import-errors.csv.
+func (h *HTTPHandler) insertParticipants(c *gin.Context, tenantID string, participants []participantRow, rejected []services.ImportError) {
+	inserted, duplicates, malformed := 0, 0, len(rejected)
+	for _, row := range participants {
+		switch err := h.service.InsertParticipant(tenantID, row.participant); {
+		case err == nil:
+			inserted++
+		case errors.Is(err, services.ErrDuplicateParticipant):
+			rejected = append(rejected, row.rejected(h.localize(c, err)))
+			duplicates++
+		default:
+			h.logFor(c).Infof("Skipping participant %q: %v", row.participant.ID, err)
+			rejected = append(rejected, row.rejected(h.localize(c, err)))
+			malformed++
+		}
 	}
+	slices.SortStableFunc(rejected, func(a, b services.ImportError) int { return a.Line - b.Line })
+	h.service.SetImportErrors(tenantID, rejected)
 
-	data := gin.H{"Participants": h.service.GetParticipants(tenantID)}
-	if err := h.templates.ExecuteTemplate(c.Writer, "participant_list_container.html", data); err != nil {
-		log.Printf("Error executing template: %v", err)
+	h.renderParticipantList(c, gin.H{
+		"ImportSummary": i18n.T(h.lang(c), "import_summary", inserted, duplicates, malformed),
+		"ImportErrors":  len(rejected),
+	})
+}
+
+// ExportImportErrorsCSV downloads the rows rejected by the tenant's last
+// participant import, each with its line number and the reason, followed by
+// the row's own fields so it can be fixed in place and uploaded again. Rows
+// are padded to the widest one so every line has the same number of fields.
+func (h *HTTPHandler) ExportImportErrorsCSV(c *gin.Context) {
+	header := append([]string{"行號", "原因"}, participantCSVHeader...)
+	var rows [][]string
+	width := len(header)
+	for _, e := range h.service.GetImportErrors(c.GetString(tenantIDKey)) {
+		row := append([]string{strconv.Itoa(e.Line), e.Reason}, e.Record...)
+		width = max(width, len(row))
+		rows = append(rows, row)
 	}
+	pad := func(row []string) []string { return append(row, make([]string, width-len(row))...) }
+	for i := range rows {
+		rows[i] = pad(rows[i])
+	}
+	h.writeCSV(c, "import_errors.csv", pad(header), rows)
 }
 
-// ShowLotteryPage handles the request for the main lottery drawing page.
-func (h *HTTPHandler) ShowLotteryPage(c *gin.Context) {
+// PasteParticipants imports participants from the "participantText" textarea,
+// for lists copied out of a spreadsheet or an email. Each line holds the same
+// columns as a participant CSV row, separated by tabs if the line has any and
+// by commas otherwise, so both spreadsheet copies and typed lists work. Blank
+// lines are ignored, and so is a first non-blank line holding
+// participantCSVHeader or its leading columns.
+// Rows are validated and deduplicated like UploadParticipantsCSV, under the
+// same size and row limits.
+func (h *HTTPHandler) PasteParticipants(c *gin.Context) {
 	tenantID := c.GetString(tenantIDKey)
-	data := gin.H{
-		"title":          "抽獎介面",
-		"Prizes":         h.service.GetPrizes(tenantID),
-		"Participants":   h.service.GetParticipants(tenantID),
-		"LotteryResults": h.service.GetLotteryResults(tenantID),
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.limits.MaxBytes+multipartOverhead)
+	if err := c.Request.ParseForm(); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			h.rejectTooLarge(c)
+			return
+		}
+		c.String(http.StatusBadRequest, "Error reading form: %v", err)
+		return
+	}
+	text := c.PostForm("participantText")
+	if int64(len(text)) > h.limits.MaxBytes {
+		h.rejectTooLarge(c)
+		return
+	}
+
+	var participants []participantRow
+	var rejected []services.ImportError
+	rows, first := 0, true
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(strings.TrimPrefix(line, "\ufeff"))
+		if line == "" {
+			continue
+		}
+		sep := ","
+		if strings.Contains(line, "\t") {
+			sep = "\t"
+		}
+		record := strings.Split(line, sep)
+		for j := range record {
+			record[j] = strings.TrimSpace(record[j])
+		}
+		if first && len(record) >= 2 && len(record) <= len(participantCSVHeader) &&
+			slices.Equal(record, participantCSVHeader[:len(record)]) {
+			first = false
+			continue // A header row, possibly copied without the optional columns
+		}
+		first = false
+		if rows++; rows > h.limits.MaxRows {
+			h.rejectTooManyRows(c)
+			return
+		}
+		participant, err := parseParticipantRecord(record)
+		if err != nil {
+			h.logFor(c).Infof("Skipping malformed pasted participant line %q: %v", line, err)
+			rejected = append(rejected, services.ImportError{Line: i + 1, Record: record, Reason: h.localize(c, err)})
+			continue
+		}
+		participants = append(participants, participantRow{i + 1, record, participant})
 	}
+	h.insertParticipants(c, tenantID, participants, rejected)
+}
+
+// ShowLotteryPage handles the request for the main lottery drawing page.
+func (h *HTTPHandler) ShowLotteryPage(c *gin.Context) {
+	data := h.lotteryInterfaceData(c)
+	data["title"] = "抽獎介面"
 
 	// If it's an HTMX request, only render the partial content.
 	// Otherwise, render the full page with the layout.
 	if c.GetHeader("HX-Request") == "true" {
-		if err := h.templates.ExecuteTemplate(c.Writer, "lottery_interface.html", data); err != nil {
-			log.Printf("Error executing partial template: %v", err)
-		}
+		h.renderPartial(c, "lottery_interface.html", data)
 	} else {
 		h.renderPage(c, data, "lottery_interface.html")
 	}
 }
 
-// PerformDrawAnimation handles the request to draw a winner and show the animation.
-func (h *HTTPHandler) PerformDrawAnimation(c *gin.Context) {
+// DrawWithReveal draws a single winner and renders it together with the decoy
+// names and duration the front end needs to animate the reveal.
+func (h *HTTPHandler) DrawWithReveal(c *gin.Context) {
 	tenantID := c.GetString(tenantIDKey)
 	prizeName := c.PostForm("prizeName")
 	if prizeName == "" {
@@ -288,63 +988,724 @@ func (h *HTTPHandler) PerformDrawAnimation(c *gin.Context) {
 		return
 	}
 
-	// We need the list of people for the animation reel
-	eligible, err := h.service.GetEligibleParticipants(tenantID, prizeName)
+	reveal, err := h.service.DrawWithReveal(tenantID, prizeName)
 	if err != nil {
-		c.String(http.StatusOK, "<p>%s</p>", err.Error())
+		c.String(drawErrorStatus(err), "<p>%s</p>", h.localize(c, err))
 		return
 	}
 
-	// Now, perform the actual draw
-	winner, err := h.service.Draw(tenantID, prizeName)
+	prizes := h.service.GetPrizes(tenantID)
+	lang := h.lang(c)
+	data := gin.H{
+		"Lang":             lang,
+		"Result":           reveal.Result,
+		"Announcement":     h.service.AnnounceIn(lang, tenantID, reveal.Result),
+		"Decoys":           reveal.Decoys,
+		"RevealDurationMs": h.revealDuration.Milliseconds(),
+		"Prizes":           prizes,
+	}
+	for _, p := range prizes {
+		if p.Name == reveal.Result.PrizeName {
+			data["ImageURL"] = p.ImageURL
+		}
+	}
+	h.renderPartial(c, "lottery_draw_response.html", data)
+}
+
+// groupWinner is one winner of a DrawGroup response with its announcement.
+type groupWinner struct {
+	*models.LotteryResult
+	Announcement string
+}
+
+// DrawGroup draws the "count" form field's number of winners of a prize at once
+// and renders them side by side for a simultaneous reveal.
+func (h *HTTPHandler) DrawGroup(c *gin.Context) {
+	tenantID := c.GetString(tenantIDKey)
+	prizeName := c.PostForm("prizeName")
+	if prizeName == "" {
+		c.String(http.StatusBadRequest, "Please select a prize.")
+		return
+	}
+	count, err := strconv.Atoi(c.PostForm("count"))
+	if err != nil || count <= 0 {
+		c.String(http.StatusBadRequest, "Invalid count")
+		return
+	}
+
+	results, err := h.service.DrawGroup(tenantID, prizeName, count)
 	if err != nil {
-		c.String(http.StatusOK, "<p>%s</p>", err.Error())
+		c.String(drawErrorStatus(err), "<p>%s</p>", h.localize(c, err))
 		return
 	}
 
-	// Render the animation template with all the data it needs
+	lang := h.lang(c)
+	winners := make([]groupWinner, len(results))
+	for i, r := range results {
+		winners[i] = groupWinner{r, h.service.AnnounceIn(lang, tenantID, r)}
+	}
+	data := gin.H{
+		"Lang":    lang,
+		"Winners": winners,
+		"Prizes":  h.service.GetPrizes(tenantID),
+	}
+	h.renderPartial(c, "lottery_group_response.html", data)
+}
+
+// drawErrorStatus maps a failed draw to its HTTP status: 404 for an unknown
+// prize, 409 for an exhausted one and 422 when the roster is empty or nobody
+// on it is eligible. Other
+// failures keep 200 so the message is swapped in like a normal response.
+func drawErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrPrizeNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrPrizeExhausted):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrNoParticipants), errors.Is(err, services.ErrNoEligibleParticipants):
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusOK
+	}
+}
+
+// PerformDrawAnimation handles the request to draw one or more winners and show the animation.
+// An optional "count" form field draws several distinct winners in a single batch.
+func (h *HTTPHandler) PerformDrawAnimation(c *gin.Context) {
+	tenantID := c.GetString(tenantIDKey)
+	prizeName := c.PostForm("prizeName")
+	if prizeName == "" {
+		c.String(http.StatusBadRequest, "Please select a prize.")
+		return
+	}
+
+	count := 1
+	if countStr := c.PostForm("count"); countStr != "" {
+		n, err := strconv.Atoi(countStr)
+		if err != nil || n <= 0 {
+			c.String(http.StatusBadRequest, "Invalid count")
+			return
+		}
+		count = n
+	}
+
+	// A repeated idempotency key (e.g. a double-clicked draw button) replays the
+	// original draw instead of consuming more prize units.
+	key := c.GetHeader("Idempotency-Key")
+	if key == "" {
+		key = c.PostForm("idempotencyKey")
+	}
+
+	// We need the list of people for the animation reel
+	eligible, eligibleErr := h.service.GetEligibleParticipants(tenantID, prizeName)
+
+	// Now, perform the actual draw
+	winners, err := h.service.DrawBatchOnce(tenantID, key, prizeName, count)
+	if len(winners) == 0 {
+		if err == nil {
+			err = eligibleErr
+		}
+		c.String(drawErrorStatus(err), "<p>%s</p>", h.localize(c, err))
+		return
+	}
+	if eligibleErr != nil {
+		// A replayed draw may have emptied the pool; spin the reel over its winners instead.
+		eligible = nil
+		for _, w := range winners {
+			eligible = append(eligible, &models.Participant{ID: w.WinnerID, Name: w.WinnerName})
+		}
+	}
+
+	// Render the animation template with all the data it needs.
+	// The reel lands on the last winner; the rest are listed once it stops.
 	data := gin.H{
 		"EligibleParticipants": eligible,
-		"Winner":               winner,
+		"Winner":               winners[len(winners)-1],
+		"Winners":              winners,
+	}
+	if err != nil {
+		data["Warning"] = h.localize(c, err)
+	}
+
+	h.renderPartial(c, "animation.html", data)
+}
+
+// UndoLastDraw reverses the most recent draw and re-renders the lottery interface.
+func (h *HTTPHandler) UndoLastDraw(c *gin.Context) {
+	tenantID := c.GetString(tenantIDKey)
+	if _, err := h.service.UndoLastDraw(tenantID); err != nil {
+		h.renderLotteryInterface(c, h.localize(c, err))
+		return
+	}
+	h.renderLotteryInterface(c, "")
+}
+
+// UndoBatch reverses every result of a batch draw and re-renders the lottery interface.
+func (h *HTTPHandler) UndoBatch(c *gin.Context) {
+	tenantID := c.GetString(tenantIDKey)
+	if err := h.service.UndoBatch(tenantID, c.PostForm("batchID")); err != nil {
+		h.renderLotteryInterface(c, h.localize(c, err))
+		return
+	}
+	h.renderLotteryInterface(c, "")
+}
+
+// resultGroup is one prize's section of the grouped results page.
+type resultGroup struct {
+	PrizeName string
+	Results   []*models.LotteryResult
+}
+
+// PreviewDraw renders who could win the selected prize and how many units remain,
+// without drawing. Errors are shown inside the partial so the swap target stays intact.
+func (h *HTTPHandler) PreviewDraw(c *gin.Context) {
+	tenantID := c.GetString(tenantIDKey)
+	prizeName := c.Query("prizeName")
+	data := gin.H{"PrizeName": prizeName}
+
+	eligible, err := h.service.PreviewEligible(tenantID, prizeName)
+	if err != nil {
+		data["Error"] = h.localize(c, err)
+	} else {
+		data["Participants"] = eligible
+		for _, p := range h.service.GetPrizes(tenantID) {
+			if p.Name == prizeName {
+				data["Remaining"] = p.Quantity
+				break
+			}
+		}
+	}
+
+	h.renderPartial(c, "draw_preview.html", data)
+}
+
+// ShowGroupedResults renders the results grouped by prize for announcement.
+// Prizes appear in their configured order, followed by deleted prizes that still have results.
+func (h *HTTPHandler) ShowGroupedResults(c *gin.Context) {
+	tenantID := c.GetString(tenantIDKey)
+	grouped := h.service.GetResultsGroupedByPrize(tenantID)
+
+	var groups []resultGroup
+	for _, prize := range h.service.GetPrizes(tenantID) {
+		if results, ok := grouped[prize.Name]; ok {
+			groups = append(groups, resultGroup{PrizeName: prize.Name, Results: results})
+			delete(grouped, prize.Name)
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(grouped)) {
+		groups = append(groups, resultGroup{PrizeName: name, Results: grouped[name]})
+	}
+
+	data := gin.H{"title": "得獎名單", "Groups": groups}
+	if c.GetHeader("HX-Request") == "true" {
+		h.renderPartial(c, "results_grouped.html", data)
+	} else {
+		h.renderPage(c, data, "results_grouped.html")
+	}
+}
+
+// ResetResults clears all results for a rehearsal and re-renders the lottery interface.
+func (h *HTTPHandler) ResetResults(c *gin.Context) {
+	tenantID := c.GetString(tenantIDKey)
+	if err := h.service.ResetResults(tenantID); err != nil {
+		h.renderLotteryInterface(c, h.localize(c, err))
+		return
+	}
+	h.renderLotteryInterface(c, "")
+}
+
+// DrawAllRemaining draws every remaining prize unit and reports what is left undrawn.
+func (h *HTTPHandler) DrawAllRemaining(c *gin.Context) {
+	tenantID := c.GetString(tenantIDKey)
+	results, remaining, err := h.service.DrawAllRemaining(tenantID)
+	if err != nil {
+		h.renderLotteryInterface(c, h.localize(c, err))
+		return
+	}
+
+	lang := h.lang(c)
+	notice := i18n.T(lang, "draw_all_summary", len(results))
+	var left []string
+	for _, p := range h.service.GetPrizes(tenantID) {
+		if n := remaining[p.Name]; n > 0 {
+			left = append(left, i18n.T(lang, "draw_all_left_prize", p.Name, n))
+		}
+	}
+	if len(left) > 0 {
+		notice += i18n.T(lang, "draw_all_left", strings.Join(left, i18n.T(lang, "list_separator")))
+	}
+	h.renderLotteryInterface(c, notice)
+}
+
+// LockResult marks a drawn result as final and re-renders the lottery interface.
+func (h *HTTPHandler) LockResult(c *gin.Context) {
+	tenantID := c.GetString(tenantIDKey)
+	if err := h.service.LockResult(tenantID, c.PostForm("resultID")); err != nil {
+		h.renderLotteryInterface(c, h.localize(c, err))
+		return
+	}
+	h.renderLotteryInterface(c, "")
+}
+
+// ClaimResult marks a winner's prize as collected and re-renders the lottery interface.
+func (h *HTTPHandler) ClaimResult(c *gin.Context) {
+	tenantID := c.GetString(tenantIDKey)
+	if err := h.service.MarkClaimed(tenantID, c.PostForm("resultID")); err != nil {
+		h.renderLotteryInterface(c, h.localize(c, err))
+		return
+	}
+	h.renderLotteryInterface(c, "")
+}
+
+// RedrawWinner voids an absent winner's result, draws a replacement and re-renders the lottery interface.
+func (h *HTTPHandler) RedrawWinner(c *gin.Context) {
+	tenantID := c.GetString(tenantIDKey)
+	if _, err := h.service.RedrawWinner(tenantID, c.PostForm("prizeName"), c.PostForm("winnerID")); err != nil {
+		h.renderLotteryInterface(c, h.localize(c, err))
+		return
+	}
+	h.renderLotteryInterface(c, "")
+}
+
+// lotteryInterfaceData collects the data rendered by lottery_interface.html.
+func (h *HTTPHandler) lotteryInterfaceData(c *gin.Context) gin.H {
+	tenantID := c.GetString(tenantIDKey)
+	drawn, total := h.service.GetDrawProgress(tenantID)
+	progress := drawProgress{Drawn: drawn, Total: total}
+	return gin.H{
+		"Prizes":               h.service.GetPrizes(tenantID),
+		"PrizeSummary":         h.service.GetPrizeSummary(tenantID),
+		"Progress":             progress,
+		"Participants":         h.service.GetParticipants(tenantID),
+		"LotteryResults":       h.service.GetLotteryResults(tenantID),
+		"ArchivedCount":        h.service.CountArchivedResults(tenantID),
+		"MinParticipants":      h.service.GetMinParticipants(tenantID),
+		"MaxWins":              h.service.GetMaxWins(tenantID),
+		"UniqueAcrossAll":      h.service.GetUniqueAcrossAll(tenantID),
+		"AllowRepeatWins":      h.service.GetAllowRepeatWins(tenantID),
+		"NoConsecutiveRepeat":  h.service.GetNoConsecutiveRepeat(tenantID),
+		"MaskIDs":              h.service.GetMaskIDs(tenantID),
+		"AnnouncementTemplate": h.service.GetAnnouncementTemplate(tenantID),
+		"SetupWarnings":        h.service.ValidateSetupIn(h.lang(c), tenantID),
+		"Seed":                 h.service.GetSeed(tenantID),
+		"DrawToken":            newDrawToken(),
+	}
+}
+
+// newDrawToken returns a fresh idempotency key for the draw form. Every render
+// of the lottery interface gets a new one, so only resubmissions share a key.
+func newDrawToken() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "" // Without a token the draw simply isn't deduplicated
+	}
+	return hex.EncodeToString(buf)
+}
+
+// SetMinParticipants updates the minimum roster size required before drawing.
+func (h *HTTPHandler) SetMinParticipants(c *gin.Context) {
+	tenantID := c.GetString(tenantIDKey)
+	n, err := strconv.Atoi(c.PostForm("minParticipants"))
+	if err != nil {
+		c.String(http.StatusBadRequest, "Invalid minimum participant count")
+		return
 	}
+	h.service.SetMinParticipants(tenantID, n)
+	h.renderLotteryInterface(c, "")
+}
 
-	if err := h.templates.ExecuteTemplate(c.Writer, "animation.html", data); err != nil {
-		log.Printf("Error executing animation template: %v", err)
+// SetMaxWins updates the cap on how many prizes one participant can win in total.
+func (h *HTTPHandler) SetMaxWins(c *gin.Context) {
+	tenantID := c.GetString(tenantIDKey)
+	n, err := strconv.Atoi(c.PostForm("maxWins"))
+	if err != nil {
+		c.String(http.StatusBadRequest, "Invalid maximum win count")
+		return
 	}
+	h.service.SetMaxWins(tenantID, n)
+	h.renderLotteryInterface(c, "")
+}
+
+// SetUniqueAcrossAll toggles whether DrawFromAll prizes also exclude previous winners.
+func (h *HTTPHandler) SetUniqueAcrossAll(c *gin.Context) {
+	h.service.SetUniqueAcrossAll(c.GetString(tenantIDKey), c.PostForm("uniqueAcrossAll") == "true")
+	h.renderLotteryInterface(c, "")
 }
 
-// GetPrizeListPartial returns the HTML partial for the prize list body.
+// SetAllowRepeatWins toggles whether winners stay eligible for the other prizes.
+func (h *HTTPHandler) SetAllowRepeatWins(c *gin.Context) {
+	h.service.SetAllowRepeatWins(c.GetString(tenantIDKey), c.PostForm("allowRepeatWins") == "true")
+	h.renderLotteryInterface(c, "")
+}
+
+// SetNoConsecutiveRepeat toggles whether the last winner sits out the next draw.
+func (h *HTTPHandler) SetNoConsecutiveRepeat(c *gin.Context) {
+	h.service.SetNoConsecutiveRepeat(c.GetString(tenantIDKey), c.PostForm("noConsecutiveRepeat") == "true")
+	h.renderLotteryInterface(c, "")
+}
+
+// SetMaskIDs toggles masking of participant IDs in the winner displays.
+func (h *HTTPHandler) SetMaskIDs(c *gin.Context) {
+	h.service.SetMaskIDs(c.GetString(tenantIDKey), c.PostForm("maskIDs") == "true")
+	h.renderLotteryInterface(c, "")
+}
+
+// SetAnnouncementTemplate stores the tenant's winner announcement template. A
+// malformed template is reported on the re-rendered page and not saved.
+func (h *HTTPHandler) SetAnnouncementTemplate(c *gin.Context) {
+	notice := ""
+	if err := h.service.SetAnnouncementTemplate(c.GetString(tenantIDKey), c.PostForm("announcementTemplate")); err != nil {
+		notice = h.localize(c, err)
+	}
+	h.renderLotteryInterface(c, notice)
+}
+
+// SetSeed enables reproducible draws with the posted seed, or disables them when the seed is blank.
+func (h *HTTPHandler) SetSeed(c *gin.Context) {
+	tenantID := c.GetString(tenantIDKey)
+	seedStr := c.PostForm("seed")
+	if seedStr == "" {
+		h.service.ClearSeed(tenantID)
+		h.renderLotteryInterface(c, "")
+		return
+	}
+	seed, err := strconv.ParseInt(seedStr, 10, 64)
+	if err != nil {
+		c.String(http.StatusBadRequest, "Invalid seed")
+		return
+	}
+	h.service.SetSeed(tenantID, seed)
+	h.renderLotteryInterface(c, "")
+}
+
+// renderLotteryInterface renders the lottery interface partial for the current tenant.
+// A non-empty notice is shown above the draw controls, e.g. to explain a rejected action.
+func (h *HTTPHandler) renderLotteryInterface(c *gin.Context, notice string) {
+	data := h.lotteryInterfaceData(c)
+	data["Notice"] = notice
+	h.renderPartial(c, "lottery_interface.html", data)
+}
+
+// GetPrizeListPartial returns the HTML partial for the prize list body, or 304
+// to a poll whose ETag is still current; see notModified.
 func (h *HTTPHandler) GetPrizeListPartial(c *gin.Context) {
+	if h.notModified(c) {
+		return
+	}
 	tenantID := c.GetString(tenantIDKey)
-	if err := h.templates.ExecuteTemplate(c.Writer, "prize_list_table_body.html", h.service.GetPrizes(tenantID)); err != nil {
-		log.Printf("Error executing template: %v", err)
+	h.renderPartial(c, "prize_list_table_body.html", h.service.GetPrizes(tenantID))
+}
+
+// GetParticipantListPartial returns the HTML partial for the participant list container,
+// paginated and filtered like ShowParticipantsPage, or 304 like GetPrizeListPartial.
+func (h *HTTPHandler) GetParticipantListPartial(c *gin.Context) {
+	if h.notModified(c) {
+		return
 	}
+	h.renderParticipantList(c, gin.H{})
 }
 
 // ExportResultsCSV handles the request to download the lottery results as a CSV file.
+// Archived results are included, oldest first.
 func (h *HTTPHandler) ExportResultsCSV(c *gin.Context) {
 	tenantID := c.GetString(tenantIDKey)
+	lang := h.lang(c)
+
+	var rows [][]string
+	for _, result := range h.service.GetAllResults(tenantID) {
+		claimed, claimedAt := i18n.T(lang, "unclaimed"), ""
+		if result.Claimed {
+			claimed = i18n.T(lang, "claimed")
+			if result.ClaimedAt != nil {
+				claimedAt = result.ClaimedAt.Format(csvTimeLayout)
+			}
+		}
+		rows = append(rows, []string{result.PrizeName, result.WinnerID, result.WinnerName, result.PrizeItem, result.DrawnAt.Format(csvTimeLayout), claimed, claimedAt})
+	}
+	h.writeCSV(c, "lottery_results.csv", resultsExportHeader(lang), rows)
+}
+
+// resultsExportColumns are the message IDs of the header row shared by the CSV
+// and XLSX results exports.
+var resultsExportColumns = []string{"col_prize_name", "col_winner_id", "col_winner_name", "col_prize_item", "col_drawn_at", "col_claim_status", "col_claimed_at"}
+
+// resultsExportHeader returns the results export header row in lang.
+func resultsExportHeader(lang i18n.Lang) []string {
+	header := make([]string, len(resultsExportColumns))
+	for i, id := range resultsExportColumns {
+		header[i] = i18n.T(lang, id)
+	}
+	return header
+}
+
+// ExportPrizesCSV downloads the configured prizes in the format UploadPrizesCSV
+// accepts, so uploading the file again recreates them. Quantity is the
+// configured total, not what is left, so drawn prizes come back whole.
+func (h *HTTPHandler) ExportPrizesCSV(c *gin.Context) {
+	tenantID := c.GetString(tenantIDKey)
+
+	var rows [][]string
+	for _, prize := range h.service.GetPrizes(tenantID) {
+		maxWinners := ""
+		if prize.MaxWinners > 0 {
+			maxWinners = strconv.Itoa(prize.MaxWinners)
+		}
+		rows = append(rows, []string{
+			prize.Name, prize.Item, strconv.Itoa(prize.OriginalQuantity), strconv.FormatBool(prize.DrawFromAll), prize.Group, prize.ImageURL,
+			strconv.FormatBool(prize.AllRemaining), maxWinners, strings.Join(prize.Items, ";"), strings.Join(prize.ExcludeWinnersOf, ";"),
+		})
+	}
+	h.writeCSV(c, "prizes.csv", prizeCSVHeader, rows)
+}
+
+// ExportParticipantsCSV downloads the roster in the format UploadParticipantsCSV accepts.
+func (h *HTTPHandler) ExportParticipantsCSV(c *gin.Context) {
+	tenantID := c.GetString(tenantIDKey)
+
+	var rows [][]string
+	for _, p := range h.service.GetParticipants(tenantID) {
+		rows = append(rows, []string{p.ID, p.Name, strconv.Itoa(p.EffectiveWeight()), p.Group})
+	}
+	h.writeCSV(c, "participants.csv", participantCSVHeader, rows)
+}
+
+// GetAuditLog returns the tenant's audit log of draws, undos and resets as JSON.
+func (h *HTTPHandler) GetAuditLog(c *gin.Context) {
+	c.JSON(http.StatusOK, h.service.GetAuditLog(c.GetString(tenantIDKey)))
+}
+
+// GetParticipantWins returns a participant's results as JSON, or 404 if the
+// participant is not on the roster.
+func (h *HTTPHandler) GetParticipantWins(c *gin.Context) {
+	wins, err := h.service.GetParticipantWins(c.GetString(tenantIDKey), c.Param("id"))
+	if errors.Is(err, services.ErrParticipantNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": h.localize(c, err)})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": h.localize(c, err)})
+		return
+	}
+	c.JSON(http.StatusOK, wins)
+}
+
+// prizeOdds is the JSON body of GetPrizeOdds.
+type prizeOdds struct {
+	Prize    string  `json:"prize"`
+	Eligible int     `json:"eligible"`
+	Odds     float64 `json:"odds"` // Chance of one weight-1 participant winning the next draw
+}
+
+// GetPrizeOdds returns a prize's current pool size and per-participant odds as
+// JSON, with the status of drawErrorStatus when the prize cannot be drawn.
+func (h *HTTPHandler) GetPrizeOdds(c *gin.Context) {
+	prizeName := c.Param("name")
+	eligible, odds, err := h.service.DrawOdds(c.GetString(tenantIDKey), prizeName)
+	if err != nil {
+		status := drawErrorStatus(err)
+		if status == http.StatusOK {
+			status = http.StatusInternalServerError
+		}
+		c.JSON(status, gin.H{"error": h.localize(c, err)})
+		return
+	}
+	c.JSON(http.StatusOK, prizeOdds{Prize: prizeName, Eligible: eligible, Odds: odds})
+}
+
+// eligibilityReport is the JSON body of GetEligibility.
+type eligibilityReport struct {
+	Prize       string `json:"prize"`
+	Participant string `json:"participant"`
+	Eligible    bool   `json:"eligible"`
+	Reason      string `json:"reason"` // Why the participant is, or is not, in the prize's pool
+}
+
+// GetEligibility explains as JSON whether the participant named by the
+// "participant" query parameter may win the next draw of the "prize" one. An
+// unknown prize or participant answers 404.
+func (h *HTTPHandler) GetEligibility(c *gin.Context) {
+	prizeName, participantID := c.Query("prize"), c.Query("participant")
+	if prizeName == "" || participantID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "prize and participant are required"})
+		return
+	}
+	eligible, reason, err := h.service.ExplainEligibilityIn(h.lang(c), c.GetString(tenantIDKey), prizeName, participantID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrPrizeNotFound) || errors.Is(err, services.ErrParticipantNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": h.localize(c, err)})
+		return
+	}
+	c.JSON(http.StatusOK, eligibilityReport{Prize: prizeName, Participant: participantID, Eligible: eligible, Reason: reason})
+}
+
+// GetPrizeSummary returns every prize's awarded, remaining and eligible counts as JSON.
+func (h *HTTPHandler) GetPrizeSummary(c *gin.Context) {
+	c.JSON(http.StatusOK, h.service.GetPrizeSummary(c.GetString(tenantIDKey)))
+}
+
+// drawProgress is the JSON body of GetDrawProgress and the lottery page's progress bar.
+type drawProgress struct {
+	Drawn int `json:"drawn"`
+	Total int `json:"total"` // Sum of the prizes' configured quantities
+}
+
+// GetDrawProgress returns how many prize units have been drawn out of the total as JSON.
+func (h *HTTPHandler) GetDrawProgress(c *gin.Context) {
+	drawn, total := h.service.GetDrawProgress(c.GetString(tenantIDKey))
+	c.JSON(http.StatusOK, drawProgress{Drawn: drawn, Total: total})
+}
+
+// ExportSessionJSON downloads the tenant's complete session as a JSON backup.
+func (h *HTTPHandler) ExportSessionJSON(c *gin.Context) {
+	data, err := h.service.ExportSession(c.GetString(tenantIDKey))
+	if err != nil {
+		h.logFor(c).Errorf("Error exporting session: %v", err)
+		c.String(http.StatusInternalServerError, "Error exporting session: %v", err)
+		return
+	}
+	c.Header("Content-Disposition", "attachment;filename=lottery_session.json")
+	c.Data(http.StatusOK, "application/json", data)
+}
+
+// ImportSessionJSON replaces the tenant's session with an uploaded JSON backup.
+// A backup that cannot be read or fails validation answers 422 and leaves the
+// session untouched.
+func (h *HTTPHandler) ImportSessionJSON(c *gin.Context) {
+	data, ok := h.readUpload(c, "sessionJSON")
+	if !ok {
+		return
+	}
+	if err := h.service.ImportSession(c.GetString(tenantIDKey), data); err != nil {
+		c.String(http.StatusUnprocessableEntity, "<p style=\"color: #c00;\">%s</p>", template.HTMLEscapeString(i18n.T(h.lang(c), "restore_failed", h.localize(c, err))))
+		return
+	}
+	c.String(http.StatusOK, "<p>%s</p>", i18n.T(h.lang(c), "restore_done"))
+}
+
+// ExportEligibleCSV snapshots the participants currently eligible for a prize as a CSV file,
+// so operators can keep a record of the pool before a contested draw.
+func (h *HTTPHandler) ExportEligibleCSV(c *gin.Context) {
+	tenantID := c.GetString(tenantIDKey)
+	prizeName := c.Param("name")
+
+	var prize *models.Prize
+	for _, p := range h.service.GetPrizes(tenantID) {
+		if p.Name == prizeName {
+			prize = p
+			break
+		}
+	}
+	if prize == nil {
+		c.String(http.StatusNotFound, h.localize(c, i18n.NewError(i18n.WithDetail, services.ErrPrizeNotFound, prizeName)))
+		return
+	}
+	if prize.Quantity <= 0 {
+		c.String(http.StatusConflict, h.localize(c, i18n.NewError(i18n.WithDetail, services.ErrPrizeExhausted, prizeName)))
+		return
+	}
+
+	eligible, err := h.service.GetEligibleParticipants(tenantID, prizeName)
+	if err != nil {
+		c.String(http.StatusUnprocessableEntity, h.localize(c, err))
+		return
+	}
+
+	var rows [][]string
+	for _, p := range eligible {
+		rows = append(rows, []string{p.ID, p.Name})
+	}
+	h.writeCSV(c, "eligible_participants.csv", []string{"員工編號", "員工姓名"}, rows)
+}
+
+// readUpload reads an uploaded file, enforcing the handler's size limit. On
+// failure it writes the error response and returns false. A missing file, a
+// request that is not a multipart form and an oversized file each get their own
+// message instead of the raw multipart error.
+func (h *HTTPHandler) readUpload(c *gin.Context, field string) ([]byte, bool) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.limits.MaxBytes+multipartOverhead)
+	file, _, err := c.Request.FormFile(field)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.As(err, &tooLarge) || errors.Is(err, multipart.ErrMessageTooLarge):
+			h.rejectTooLarge(c)
+		case errors.Is(err, http.ErrMissingFile):
+			c.String(http.StatusBadRequest, i18n.T(h.lang(c), "upload_missing_file", uploadKind(field)))
+		case errors.Is(err, http.ErrNotMultipart):
+			c.String(http.StatusBadRequest, i18n.T(h.lang(c), "upload_not_multipart"))
+		default:
+			h.logFor(c).Infof("Rejected unreadable upload: %v", err)
+			c.String(http.StatusBadRequest, i18n.T(h.lang(c), "upload_unreadable"))
+		}
+		return nil, false
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, h.limits.MaxBytes+1))
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Error reading file: %v", err)
+		return nil, false
+	}
+	if int64(len(data)) > h.limits.MaxBytes {
+		h.rejectTooLarge(c)
+		return nil, false
+	}
+	return data, true
+}
+
+// uploadKind names the kind of file a form field expects, for messages.
+func uploadKind(field string) string {
+	if strings.HasSuffix(field, "JSON") {
+		return "JSON"
+	}
+	return "CSV"
+}
+
+func (h *HTTPHandler) rejectTooLarge(c *gin.Context) {
+	h.logFor(c).Infof("Rejected upload larger than %d bytes", h.limits.MaxBytes)
+	c.String(http.StatusRequestEntityTooLarge, i18n.T(h.lang(c), "upload_too_large", h.limits.MaxBytes>>10))
+}
+
+func (h *HTTPHandler) rejectTooManyRows(c *gin.Context) {
+	h.logFor(c).Infof("Rejected upload with more than %d rows", h.limits.MaxRows)
+	c.String(http.StatusRequestEntityTooLarge, i18n.T(h.lang(c), "upload_too_many_rows", h.limits.MaxRows))
+}
+
+// newUploadCSVReader returns a CSV reader for an uploaded file, dropping the
+// UTF-8 BOM that writeCSV and Excel put in front of the first field.
+func newUploadCSVReader(r io.Reader) *csv.Reader {
+	br := bufio.NewReader(r)
+	if bom, err := br.Peek(3); err == nil && string(bom) == "\xef\xbb\xbf" {
+		br.Discard(3)
+	}
+	return csv.NewReader(br)
+}
+
+// writeCSV streams a CSV attachment with a UTF-8 BOM so Excel detects the encoding.
+func (h *HTTPHandler) writeCSV(c *gin.Context, filename string, header []string, rows [][]string) {
 	c.Header("Content-Type", "text/csv")
-	c.Header("Content-Disposition", "attachment;filename=lottery_results.csv")
+	c.Header("Content-Disposition", "attachment;filename="+filename)
 
 	c.Writer.Write([]byte("\xef\xbb\xbf"))
 	w := csv.NewWriter(c.Writer)
 
-	if err := w.Write([]string{"獎項名稱", "員工編號", "員工姓名", "獎品名稱"}); err != nil {
-		log.Printf("Error writing CSV header: %v", err)
+	if err := w.Write(header); err != nil {
+		h.logFor(c).Errorf("Error writing CSV header: %v", err)
 		return
 	}
 
-	for _, result := range h.service.GetLotteryResults(tenantID) {
-		row := []string{result.PrizeName, result.WinnerID, result.WinnerName, result.PrizeItem}
+	for _, row := range rows {
 		if err := w.Write(row); err != nil {
-			log.Printf("Error writing CSV row: %v", err)
+			h.logFor(c).Errorf("Error writing CSV row: %v", err)
 			return
 		}
 	}
 	w.Flush()
 
 	if err := w.Error(); err != nil {
-		log.Printf("Error flushing CSV writer: %v", err)
+		h.logFor(c).Errorf("Error flushing CSV writer: %v", err)
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,1521 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"lottery/internal/i18n"
+	"lottery/internal/models"
+	"lottery/internal/services"
+)
+
+// testTenantName is the tenant cookie value sent by newTenantRequest. Combined with
+// httptest's default client IP it yields testTenantID.
+const (
+	testTenantName = "tester"
+	testTenantID   = "tester-192.0.2.1"
+)
+
+// testCookieSecret signs the tenant cookies of test requests.
+const testCookieSecret = "test-cookie-secret"
+
+// testTenantCookie returns a correctly signed tenant cookie for name.
+func testTenantCookie(name string) *http.Cookie {
+	return &http.Cookie{Name: tenantCookieName, Value: signTenantName([]byte(testCookieSecret), name)}
+}
+
+// newTestRouter builds a router wired the same way as cmd/main.go.
+func newTestRouter(t *testing.T) (*gin.Engine, *HTTPHandler) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	templates, err := template.New("").Funcs(TemplateFuncs).ParseGlob("../templates/*.html")
+	if err != nil {
+		t.Fatalf("Failed to parse templates: %v", err)
+	}
+
+	h := NewHTTPHandler(services.NewLotteryService(), templates, BuildInfo{
+		Version:   "test-build",
+		StartTime: time.Now().Add(-time.Minute),
+	})
+	h.SetCookieSecret([]byte(testCookieSecret))
+
+	r := gin.New()
+	r.SetTrustedProxies(nil)
+	r.Use(h.RecoveryMiddleware())
+	h.RegisterPublicRoutes(r)
+	h.RegisterAdminRoutes(r)
+	tenantRoutes := r.Group("/")
+	tenantRoutes.Use(h.TenantMiddleware())
+	h.RegisterTenantRoutes(tenantRoutes)
+	return r, h
+}
+
+func TestShowVersion(t *testing.T) {
+	r, _ := newTestRouter(t)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/version", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d", w.Code)
+	}
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Expected JSON body, but got error %v", err)
+	}
+
+	for _, field := range []string{"version", "goVersion", "uptime", "uptimeSeconds"} {
+		if _, ok := body[field]; !ok {
+			t.Errorf("Expected field %q in response, but it was missing", field)
+		}
+	}
+	if body["version"] != "test-build" {
+		t.Errorf("Expected version test-build, but got %v", body["version"])
+	}
+	if secs, _ := body["uptimeSeconds"].(float64); secs < 60 {
+		t.Errorf("Expected uptime of at least 60 seconds, but got %v", body["uptimeSeconds"])
+	}
+}
+
+func TestHealthz(t *testing.T) {
+	r, h := newTestRouter(t)
+	health := func() map[string]any {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, but got %d", w.Code)
+		}
+		var body map[string]any
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return body
+	}
+
+	if body := health(); body["sessions"] != float64(0) {
+		t.Errorf("Expected 0 sessions, but got %v", body["sessions"])
+	}
+	h.service.AddParticipant("tenant-a", "001", "Alice")
+	h.service.AddParticipant("tenant-a", "002", "Bob")
+	h.service.AddParticipant("tenant-b", "001", "Carol")
+
+	body := health()
+	if body["sessions"] != float64(2) || body["participants"] != float64(3) {
+		t.Errorf("Expected 2 sessions with 3 participants, but got %v", body)
+	}
+	if _, ok := body["uptimeSeconds"]; !ok {
+		t.Error("Expected an uptimeSeconds field")
+	}
+	if body := health(); body["sessions"] != float64(2) {
+		t.Errorf("Expected health checks not to create sessions, but got %v", body["sessions"])
+	}
+}
+
+func TestReadyz(t *testing.T) {
+	r, h := newTestRouter(t)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503 before the state is loaded, but got %d", w.Code)
+	}
+
+	h.SetReady(true)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200 once ready, but got %d", w.Code)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	r, h := newTestRouter(t)
+	h.service.AddPrize(testTenantID, "頭獎", "電視", 2, false)
+	h.service.AddParticipant(testTenantID, "001", "Alice")
+	h.service.AddParticipant(testTenantID, "002", "Bob")
+
+	scrape := func() string {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, but got %d", w.Code)
+		}
+		if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+			t.Errorf("Expected a text/plain content type, but got %q", ct)
+		}
+		return w.Body.String()
+	}
+
+	before := scrape()
+	for _, want := range []string{"lottery_draws_total 0\n", "lottery_participants_added_total 2\n", "lottery_active_sessions 1\n", "# TYPE lottery_active_sessions gauge\n"} {
+		if !strings.Contains(before, want) {
+			t.Errorf("Expected metrics to contain %q, but got:\n%s", want, before)
+		}
+	}
+
+	if _, err := h.service.Draw(testTenantID, "頭獎"); err != nil {
+		t.Fatalf("Draw failed: %v", err)
+	}
+	if after := scrape(); !strings.Contains(after, "lottery_draws_total 1\n") {
+		t.Errorf("Expected the draw counter to reach 1, but got:\n%s", after)
+	}
+}
+
+// newTenantRequest builds a request carrying the test tenant cookie and its CSRF token.
+func newTenantRequest(method, target string, body *bytes.Buffer) *http.Request {
+	var req *http.Request
+	if body == nil {
+		req = httptest.NewRequest(method, target, nil)
+	} else {
+		req = httptest.NewRequest(method, target, body)
+	}
+	req.AddCookie(testTenantCookie(testTenantName))
+	req.Header.Set(csrfHeaderName, csrfTokenFor([]byte(testCookieSecret), testTenantID))
+	return req
+}
+
+// newCSVUploadBody builds a multipart body with content uploaded under the given form field.
+func newCSVUploadBody(t *testing.T, field, content string) (*bytes.Buffer, string) {
+	t.Helper()
+	return newCSVUploadBodyWithFields(t, field, content, nil)
+}
+
+// newCSVUploadBodyWithFields is newCSVUploadBody with extra form fields.
+func newCSVUploadBodyWithFields(t *testing.T, field, content string, fields map[string]string) (*bytes.Buffer, string) {
+	t.Helper()
+	body := new(bytes.Buffer)
+	mw := multipart.NewWriter(body)
+	for name, value := range fields {
+		mw.WriteField(name, value)
+	}
+	fw, err := mw.CreateFormFile(field, "upload.csv")
+	if err != nil {
+		t.Fatalf("Failed to create form file: %v", err)
+	}
+	fw.Write([]byte(content))
+	mw.Close()
+	return body, mw.FormDataContentType()
+}
+
+// newCSVUploadRequest builds a tenant request uploading content as a CSV file.
+func newCSVUploadRequest(t *testing.T, target, field, content string) *http.Request {
+	t.Helper()
+	body, contentType := newCSVUploadBody(t, field, content)
+	req := newTenantRequest(http.MethodPost, target, body)
+	req.Header.Set("Content-Type", contentType)
+	return req
+}
+
+// readCSV parses a CSV response body, stripping the UTF-8 BOM.
+func readCSV(t *testing.T, body []byte) [][]string {
+	t.Helper()
+	records, err := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(body, []byte("\xef\xbb\xbf")))).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to parse CSV response: %v", err)
+	}
+	return records
+}
+
+func TestUploadCSV_HasHeader(t *testing.T) {
+	const prizeHeader = "獎項,獎品,數量,全員抽\n"
+	const prizeRows = "頭獎,電視,1,false\n二獎,手機,2,true\n"
+	const participantHeader = "編號,姓名\n"
+	const participantRows = "001,Alice\n002,Bob\n"
+
+	tests := []struct {
+		name      string
+		target    string
+		field     string
+		content   string
+		hasHeader bool
+		want      []string // Imported prize names or participant IDs, in order
+	}{
+		{"prizes with header, opted in", "/upload-prizes-csv", "prizeCSV", prizeHeader + prizeRows, true, []string{"頭獎", "二獎"}},
+		{"prizes with header, not opted in", "/upload-prizes-csv", "prizeCSV", prizeHeader + prizeRows, false, []string{"頭獎", "二獎"}},
+		{"prizes without header, opted in", "/upload-prizes-csv", "prizeCSV", prizeRows, true, []string{"二獎"}},
+		{"prizes without header, not opted in", "/upload-prizes-csv", "prizeCSV", prizeRows, false, []string{"頭獎", "二獎"}},
+		{"participants with header, opted in", "/upload-participants-csv", "participantCSV", participantHeader + participantRows, true, []string{"001", "002"}},
+		{"participants with header, not opted in", "/upload-participants-csv", "participantCSV", participantHeader + participantRows, false, []string{"編號", "001", "002"}},
+		{"participants without header, opted in", "/upload-participants-csv", "participantCSV", participantRows, true, []string{"002"}},
+		{"participants without header, not opted in", "/upload-participants-csv", "participantCSV", participantRows, false, []string{"001", "002"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, h := newTestRouter(t)
+			fields := map[string]string{}
+			if tt.hasHeader {
+				fields["hasHeader"] = "true"
+			}
+			body, contentType := newCSVUploadBodyWithFields(t, tt.field, tt.content, fields)
+			req := newTenantRequest(http.MethodPost, tt.target, body)
+			req.Header.Set("Content-Type", contentType)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, but got %d: %s", w.Code, w.Body.String())
+			}
+
+			var got []string
+			if tt.field == "prizeCSV" {
+				for _, p := range h.service.GetPrizes(testTenantID) {
+					got = append(got, p.Name)
+				}
+			} else {
+				for _, p := range h.service.GetParticipants(testTenantID) {
+					got = append(got, p.ID)
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Expected %v to be imported, but got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestUploadParticipantsCSV_Upsert(t *testing.T) {
+	tests := []struct {
+		name        string
+		upsert      bool
+		wantSummary string
+		wantNames   []string // Roster names after the upload, in order
+	}{
+		{"Test duplicates are skipped by default", false, "匯入 1 筆，略過 1 筆重複、0 筆格式錯誤", []string{"Alice", "Bob"}},
+		{"Test upsert updates existing names", true, "新增 1 筆，更新 1 筆，略過 0 筆格式錯誤", []string{"Alice Chen", "Bob"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, h := newTestRouter(t)
+			h.service.AddParticipant(testTenantID, "001", "Alice")
+
+			fields := map[string]string{}
+			if tt.upsert {
+				fields["upsert"] = "true"
+			}
+			body, contentType := newCSVUploadBodyWithFields(t, "participantCSV", "001,Alice Chen\n002,Bob\n", fields)
+			req := newTenantRequest(http.MethodPost, "/upload-participants-csv", body)
+			req.Header.Set("Content-Type", contentType)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if !strings.Contains(w.Body.String(), tt.wantSummary) {
+				t.Errorf("Expected summary %q, but got:\n%s", tt.wantSummary, w.Body.String())
+			}
+			var names []string
+			for _, p := range h.service.GetParticipants(testTenantID) {
+				names = append(names, p.Name)
+			}
+			if !slices.Equal(names, tt.wantNames) {
+				t.Errorf("Expected roster %v, but got %v", tt.wantNames, names)
+			}
+		})
+	}
+
+	t.Run("Test new IDs are inserted", func(t *testing.T) {
+		r, h := newTestRouter(t)
+		body, contentType := newCSVUploadBodyWithFields(t, "participantCSV", "001,Alice\n002,Bob\n", map[string]string{"upsert": "true"})
+		req := newTenantRequest(http.MethodPost, "/upload-participants-csv", body)
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if !strings.Contains(w.Body.String(), "新增 2 筆，更新 0 筆") || len(h.service.GetParticipants(testTenantID)) != 2 {
+			t.Errorf("Expected both participants to be inserted, but got:\n%s", w.Body.String())
+		}
+	})
+}
+
+func TestPasteParticipants(t *testing.T) {
+	tests := []struct {
+		name        string
+		text        string
+		wantSummary string
+		wantIDs     []string
+	}{
+		{"Test comma-delimited", "001,Alice\n002,Bob\n", "匯入 2 筆，略過 0 筆重複、0 筆格式錯誤", []string{"000", "001", "002"}},
+		{"Test tab-delimited", "員工編號\t員工姓名\n001\tAlice\t2\n002\tBob Lin\n", "匯入 2 筆，略過 0 筆重複、0 筆格式錯誤", []string{"000", "001", "002"}},
+		{
+			"Test mixed delimiters and blank lines",
+			"\r\n001,Alice\r\n\r\n  002\tBob  \r\n000,Existing\nno-name\n003,Carol,heavy\n",
+			"匯入 2 筆，略過 1 筆重複、2 筆格式錯誤",
+			[]string{"000", "001", "002"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, h := newTestRouter(t)
+			h.service.AddParticipant(testTenantID, "000", "Existing")
+
+			form := url.Values{"participantText": {tt.text}}
+			req := newTenantRequest(http.MethodPost, "/participants/paste", bytes.NewBufferString(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if !strings.Contains(w.Body.String(), tt.wantSummary) {
+				t.Errorf("Expected summary %q, but got:\n%s", tt.wantSummary, w.Body.String())
+			}
+			var ids []string
+			for _, p := range h.service.GetParticipants(testTenantID) {
+				ids = append(ids, p.ID)
+				if p.ID == "002" && p.Name != "Bob" && p.Name != "Bob Lin" {
+					t.Errorf("Expected the pasted name to be trimmed, but got %q", p.Name)
+				}
+			}
+			if !slices.Equal(ids, tt.wantIDs) {
+				t.Errorf("Expected roster %v, but got %v", tt.wantIDs, ids)
+			}
+		})
+	}
+}
+
+func TestExportEligibleCSV(t *testing.T) {
+	r, h := newTestRouter(t)
+	h.service.AddPrize(testTenantID, "頭獎", "電視", 1, false)
+	h.service.AddPrize(testTenantID, "二獎", "手機", 2, false)
+	h.service.AddParticipant(testTenantID, "001", "Alice")
+	h.service.AddParticipant(testTenantID, "002", "Bob")
+	h.service.AddParticipant(testTenantID, "003", "Charlie")
+
+	winner, err := h.service.Draw(testTenantID, "頭獎")
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	t.Run("Test exported set matches eligibility", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, newTenantRequest(http.MethodGet, "/prizes/二獎/eligible-csv", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, but got %d: %s", w.Code, w.Body.String())
+		}
+
+		records := readCSV(t, w.Body.Bytes())
+		eligible, _ := h.service.GetEligibleParticipants(testTenantID, "二獎")
+		if len(records) != len(eligible)+1 {
+			t.Fatalf("Expected %d rows plus header, but got %d rows", len(eligible), len(records))
+		}
+		for i, p := range eligible {
+			if records[i+1][0] != p.ID || records[i+1][1] != p.Name {
+				t.Errorf("Row %d: expected %s,%s but got %v", i+1, p.ID, p.Name, records[i+1])
+			}
+			if p.ID == winner.WinnerID {
+				t.Errorf("Expected previous winner %s to be excluded", winner.WinnerID)
+			}
+		}
+	})
+
+	t.Run("Test exhausted prize", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, newTenantRequest(http.MethodGet, "/prizes/頭獎/eligible-csv", nil))
+		if w.Code != http.StatusConflict {
+			t.Errorf("Expected status 409, but got %d", w.Code)
+		}
+	})
+
+	t.Run("Test unknown prize", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, newTenantRequest(http.MethodGet, "/prizes/不存在/eligible-csv", nil))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, but got %d", w.Code)
+		}
+	})
+}
+
+func TestUploadParticipantsCSV_Summary(t *testing.T) {
+	r, h := newTestRouter(t)
+	h.service.AddParticipant(testTenantID, "001", "Alice")
+
+	csvContent := "001,Alice\n002,Bob\n003,Charlie,extra\n004,Dave\n002,Bob again\n"
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newCSVUploadRequest(t, "/upload-participants-csv", "participantCSV", csvContent))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d: %s", w.Code, w.Body.String())
+	}
+	if want := "匯入 2 筆，略過 2 筆重複、1 筆格式錯誤"; !strings.Contains(w.Body.String(), want) {
+		t.Errorf("Expected summary %q in response, but got %s", want, w.Body.String())
+	}
+	if n := len(h.service.GetParticipants(testTenantID)); n != 3 {
+		t.Errorf("Expected 3 participants, but got %d", n)
+	}
+}
+
+func TestUploadParticipantsCSV_ImportErrorReport(t *testing.T) {
+	r, _ := newTestRouter(t)
+
+	csvContent := "員工編號,員工姓名\n001,Alice\n002\n003,Charlie,heavy\n001,Alice again\n004,Dave\n"
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newCSVUploadRequest(t, "/upload-participants-csv", "participantCSV", csvContent))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `href="/import-errors.csv"`) {
+		t.Fatalf("Expected a link to the error report, but got %d: %s", w.Code, w.Body.String())
+	}
+
+	report := func() [][]string {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, newTenantRequest(http.MethodGet, "/import-errors.csv", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, but got %d", w.Code)
+		}
+		return readCSV(t, w.Body.Bytes())
+	}
+	records := report()
+	want := [][]string{
+		{"3", "應為 2 到 4 欄，實際為 1 欄", "002", "", "", ""},
+		{"4", `權重 "heavy" 無效`, "003", "Charlie", "heavy", ""},
+		{"5", "此員工編號已存在", "001", "Alice again", "", ""},
+	}
+	if len(records) != len(want)+1 || records[0][0] != "行號" {
+		t.Fatalf("Expected a header and %d rejected rows, but got %q", len(want), records)
+	}
+	for i, row := range want {
+		if !slices.Equal(records[i+1], row) {
+			t.Errorf("Expected row %d to be %q, but got %q", i+1, row, records[i+1])
+		}
+	}
+
+	// A clean upload clears the report.
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, newCSVUploadRequest(t, "/upload-participants-csv", "participantCSV", "005,Eve\n"))
+	if strings.Contains(w.Body.String(), "import-errors.csv") {
+		t.Errorf("Expected no error report link after a clean upload, but got %s", w.Body.String())
+	}
+	if records := report(); len(records) != 1 {
+		t.Errorf("Expected only the header after a clean upload, but got %q", records)
+	}
+}
+
+func TestUploadParticipantsCSV_Weight(t *testing.T) {
+	r, h := newTestRouter(t)
+
+	csvContent := "001,Alice\n002,Bob,5\n003,Charlie,-1\n"
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newCSVUploadRequest(t, "/upload-participants-csv", "participantCSV", csvContent))
+
+	if want := "匯入 2 筆，略過 0 筆重複、1 筆格式錯誤"; !strings.Contains(w.Body.String(), want) {
+		t.Errorf("Expected summary %q in response, but got %s", want, w.Body.String())
+	}
+	participants := h.service.GetParticipants(testTenantID)
+	if len(participants) != 2 {
+		t.Fatalf("Expected 2 participants, but got %d", len(participants))
+	}
+	if participants[0].EffectiveWeight() != 1 || participants[1].Weight != 5 {
+		t.Errorf("Expected weights 1 and 5, but got %d and %d", participants[0].EffectiveWeight(), participants[1].Weight)
+	}
+}
+
+func TestUploadParticipantsCSV_Group(t *testing.T) {
+	r, h := newTestRouter(t)
+
+	csvContent := "001,Alice,,Sales\n002,Bob,3,RD\n003,Charlie\n"
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newCSVUploadRequest(t, "/upload-participants-csv", "participantCSV", csvContent))
+
+	participants := h.service.GetParticipants(testTenantID)
+	if len(participants) != 3 {
+		t.Fatalf("Expected 3 participants, but got %d: %s", len(participants), w.Body.String())
+	}
+	if participants[0].Group != "Sales" || participants[0].EffectiveWeight() != 1 {
+		t.Errorf("Expected Alice in Sales with default weight, but got %+v", participants[0])
+	}
+	if participants[1].Group != "RD" || participants[1].Weight != 3 {
+		t.Errorf("Expected Bob in RD with weight 3, but got %+v", participants[1])
+	}
+	if participants[2].Group != "" {
+		t.Errorf("Expected Charlie to have no group, but got %q", participants[2].Group)
+	}
+}
+
+func TestExportPrizesCSV(t *testing.T) {
+	r, h := newTestRouter(t)
+	h.service.InsertPrize(testTenantID, models.Prize{Name: "頭獎", Item: "電視", Quantity: 1})
+	h.service.InsertPrize(testTenantID, models.Prize{Name: "業務獎", Item: "禮券", Quantity: 3, DrawFromAll: true, Group: "Sales", ImageURL: "/assets/voucher.png"})
+	h.service.InsertPrize(testTenantID, models.Prize{Name: "禮品組", Item: "紀念品", Quantity: 4, MaxWinners: 2, Items: []string{"耳機", "手錶"}, ExcludeWinnersOf: []string{"頭獎"}})
+	h.service.InsertPrize(testTenantID, models.Prize{Name: "參加獎", Item: "紅包", Quantity: 1, AllRemaining: true})
+	h.service.AddParticipant(testTenantID, "001", "Alice")
+	h.service.AddParticipant(testTenantID, "002", "Bob")
+	// 頭獎 is drawn out and 業務獎 partly drawn; the export keeps their full quantities.
+	h.service.Draw(testTenantID, "頭獎")
+	h.service.Draw(testTenantID, "業務獎")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newTenantRequest(http.MethodGet, "/export-prizes-csv", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d: %s", w.Code, w.Body.String())
+	}
+	if !bytes.HasPrefix(w.Body.Bytes(), []byte("\xef\xbb\xbf")) {
+		t.Error("Expected the export to start with a UTF-8 BOM")
+	}
+
+	want := [][]string{
+		{"獎項名稱", "獎品名稱", "數量", "從全體抽取", "限定部門", "圖片網址", "全數頒發", "得獎人數上限", "獎品清單", "排除得主獎項"},
+		{"頭獎", "電視", "1", "false", "", "", "false", "", "", ""},
+		{"業務獎", "禮券", "3", "true", "Sales", "/assets/voucher.png", "false", "", "", ""},
+		{"禮品組", "紀念品", "4", "false", "", "", "false", "2", "耳機;手錶", "頭獎"},
+		{"參加獎", "紅包", "1", "false", "", "", "true", "", "", ""},
+	}
+	if records := readCSV(t, w.Body.Bytes()); !reflect.DeepEqual(records, want) {
+		t.Errorf("Expected %v, but got %v", want, records)
+	}
+
+	// Uploading the export into a fresh tenant reproduces the prizes as configured.
+	exported := h.service.GetPrizes(testTenantID)
+	for _, p := range exported {
+		p.Quantity = p.OriginalQuantity
+	}
+	h.service.ClearSession(testTenantID)
+	upload := httptest.NewRecorder()
+	r.ServeHTTP(upload, newCSVUploadRequest(t, "/upload-prizes-csv", "prizeCSV", w.Body.String()))
+	if got := h.service.GetPrizes(testTenantID); !reflect.DeepEqual(got, exported) {
+		t.Errorf("Expected the round trip to reproduce %+v, but got %+v (%s)", exported, got, upload.Body.String())
+	}
+}
+
+func TestUploadPrizesCSV_ItemList(t *testing.T) {
+	r, h := newTestRouter(t)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newCSVUploadRequest(t, "/upload-prizes-csv", "prizeCSV", "禮品組,耳機; 手錶;背包,3,false\n短缺獎,A;B,3,false\n"))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d: %s", w.Code, w.Body.String())
+	}
+
+	prizes := h.service.GetPrizes(testTenantID)
+	if len(prizes) != 1 {
+		t.Fatalf("Expected only the prize with enough items to be added, but got %+v", prizes)
+	}
+	if want := []string{"耳機", "手錶", "背包"}; !slices.Equal(prizes[0].Items, want) || prizes[0].Item != "" {
+		t.Errorf("Expected items %v and no single Item, but got %q and %q", want, prizes[0].Items, prizes[0].Item)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, newTenantRequest(http.MethodGet, "/export-prizes-csv", nil))
+	if records := readCSV(t, w.Body.Bytes()); len(records) != 2 || records[1][8] != "耳機;手錶;背包" {
+		t.Errorf("Expected the export to list the items with semicolons, but got %q", records)
+	}
+}
+
+func TestExportParticipantsCSV(t *testing.T) {
+	r, h := newTestRouter(t)
+	h.service.InsertParticipant(testTenantID, models.Participant{ID: "001", Name: "Alice", Weight: 1})
+	h.service.InsertParticipant(testTenantID, models.Participant{ID: "002", Name: "Bob", Weight: 5, Group: "RD"})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newTenantRequest(http.MethodGet, "/export-participants-csv", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d: %s", w.Code, w.Body.String())
+	}
+
+	want := [][]string{
+		{"員工編號", "員工姓名", "權重", "部門"},
+		{"001", "Alice", "1", ""},
+		{"002", "Bob", "5", "RD"},
+	}
+	if records := readCSV(t, w.Body.Bytes()); !reflect.DeepEqual(records, want) {
+		t.Errorf("Expected %v, but got %v", want, records)
+	}
+
+	exported := h.service.GetParticipants(testTenantID)
+	h.service.ClearSession(testTenantID)
+	up := httptest.NewRecorder()
+	r.ServeHTTP(up, newCSVUploadRequest(t, "/upload-participants-csv", "participantCSV", w.Body.String()))
+	if want := "匯入 2 筆，略過 0 筆重複、0 筆格式錯誤"; !strings.Contains(up.Body.String(), want) {
+		t.Errorf("Expected summary %q in response, but got %s", want, up.Body.String())
+	}
+	if got := h.service.GetParticipants(testTenantID); !reflect.DeepEqual(got, exported) {
+		t.Errorf("Expected the round trip to reproduce %+v, but got %+v", exported, got)
+	}
+}
+
+func TestSessionJSON_RoundTrip(t *testing.T) {
+	r, h := newTestRouter(t)
+	h.service.InsertPrize(testTenantID, models.Prize{Name: "頭獎", Item: "電視", Quantity: 2})
+	h.service.InsertParticipant(testTenantID, models.Participant{ID: "001", Name: "Alice", Weight: 1})
+	h.service.InsertParticipant(testTenantID, models.Participant{ID: "002", Name: "Bob", Weight: 2, Group: "RD"})
+	if _, err := h.service.Draw(testTenantID, "頭獎"); err != nil {
+		t.Fatalf("Draw failed: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newTenantRequest(http.MethodGet, "/export-session-json", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d: %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+		t.Errorf("Expected a JSON content type, but got %q", ct)
+	}
+
+	prizes := h.service.GetPrizes(testTenantID)
+	participants := h.service.GetParticipants(testTenantID)
+	results := h.service.GetLotteryResults(testTenantID)
+	h.service.ClearSession(testTenantID)
+
+	up := httptest.NewRecorder()
+	r.ServeHTTP(up, newCSVUploadRequest(t, "/import-session-json", "sessionJSON", w.Body.String()))
+	if !strings.Contains(up.Body.String(), "還原完成") {
+		t.Fatalf("Expected the import to succeed, but got %s", up.Body.String())
+	}
+	if got := h.service.GetPrizes(testTenantID); !reflect.DeepEqual(got, prizes) {
+		t.Errorf("Expected prizes %+v, but got %+v", prizes, got)
+	}
+	if got := h.service.GetParticipants(testTenantID); !reflect.DeepEqual(got, participants) {
+		t.Errorf("Expected participants %+v, but got %+v", participants, got)
+	}
+	got := h.service.GetLotteryResults(testTenantID)
+	if len(got) != len(results) || got[0].WinnerID != results[0].WinnerID || !got[0].DrawnAt.Equal(results[0].DrawnAt) {
+		t.Errorf("Expected results %+v, but got %+v", results, got)
+	}
+
+	// The restored winner must not be drawn again.
+	second, err := h.service.Draw(testTenantID, "頭獎")
+	if err != nil {
+		t.Fatalf("Draw after import failed: %v", err)
+	}
+	if second.WinnerID == results[0].WinnerID {
+		t.Errorf("Expected the restored winner %s to be excluded", second.WinnerID)
+	}
+}
+
+func TestImportSessionJSON_RejectsInvalidSnapshot(t *testing.T) {
+	r, h := newTestRouter(t)
+	h.service.InsertPrize(testTenantID, models.Prize{Name: "頭獎", Item: "電視", Quantity: 1})
+
+	for name, snapshot := range map[string]string{
+		"negative quantity": `{"prizes":[{"name":"頭獎","item":"電視","quantity":-1}]}`,
+		"unknown winner":    `{"participants":[{"id":"001","name":"Alice","weight":1}],"winners":{"999":{"頭獎":true}}}`,
+		"malformed":         `{"prizes":`,
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, newCSVUploadRequest(t, "/import-session-json", "sessionJSON", snapshot))
+		if w.Code != http.StatusUnprocessableEntity || !strings.Contains(w.Body.String(), "還原失敗") {
+			t.Errorf("%s: expected the import to be rejected with status 422, but got %d: %s", name, w.Code, w.Body.String())
+		}
+	}
+	if prizes := h.service.GetPrizes(testTenantID); len(prizes) != 1 || prizes[0].Quantity != 1 {
+		t.Errorf("Expected the rejected imports to leave the session untouched, but got %+v", prizes)
+	}
+}
+
+func TestUploadParticipantsCSV_RejectsOversizedFile(t *testing.T) {
+	r, h := newTestRouter(t)
+	h.limits = UploadLimits{MaxBytes: 64, MaxRows: 2}
+
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"too many bytes", "001,Alice\n002,Bob\n" + strings.Repeat("x", 64) + ",Charlie\n"},
+		{"too many rows", "001,Alice\n002,Bob\n003,Charlie\n"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, newCSVUploadRequest(t, "/upload-participants-csv", "participantCSV", tt.content))
+		if w.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("%s: expected status 413, but got %d: %s", tt.name, w.Code, w.Body.String())
+		}
+		if n := len(h.service.GetParticipants(testTenantID)); n != 0 {
+			t.Errorf("%s: expected no participants to be imported, but got %d", tt.name, n)
+		}
+	}
+
+	// A header row doesn't count towards the row limit.
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newCSVUploadRequest(t, "/upload-participants-csv", "participantCSV", "員工編號,員工姓名,權重,部門\n001,Alice\n002,Bob\n"))
+	if w.Code != http.StatusOK || len(h.service.GetParticipants(testTenantID)) != 2 {
+		t.Errorf("Expected a file within the limits to be imported, but got status %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestUploadParticipantsCSV_FriendlyErrors(t *testing.T) {
+	r, _ := newTestRouter(t)
+
+	wrongField := func() *http.Request { return newCSVUploadRequest(t, "/upload-participants-csv", "file", "001,Alice\n") }
+	notMultipart := func() *http.Request {
+		req := newTenantRequest(http.MethodPost, "/upload-participants-csv", bytes.NewBufferString("001,Alice\n"))
+		req.Header.Set("Content-Type", "text/csv")
+		return req
+	}
+	tests := []struct {
+		name    string
+		request func() *http.Request
+		want    string
+	}{
+		{"missing file field", wrongField, "請選擇 CSV 檔案"},
+		{"wrong content type", notMultipart, "請透過表單選擇要上傳的檔案"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, tt.request())
+		if w.Code != http.StatusBadRequest || w.Body.String() != tt.want {
+			t.Errorf("%s: expected 400 %q, but got %d %q", tt.name, tt.want, w.Code, w.Body.String())
+		}
+	}
+
+	// The backup upload names its own file kind.
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newCSVUploadRequest(t, "/import-session-json", "file", "{}"))
+	if !strings.Contains(w.Body.String(), "請選擇 JSON 檔案") {
+		t.Errorf("Expected the backup upload to ask for a JSON file, but got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestUploadPrizesCSV_RejectsTooManyRows(t *testing.T) {
+	r, h := newTestRouter(t)
+	h.limits.MaxRows = 1
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newCSVUploadRequest(t, "/upload-prizes-csv", "prizeCSV", "頭獎,電視,1,false\n二獎,手機,2,false\n"))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected status 413, but got %d: %s", w.Code, w.Body.String())
+	}
+	if n := len(h.service.GetPrizes(testTenantID)); n != 0 {
+		t.Errorf("Expected no prizes to be imported, but got %d", n)
+	}
+}
+
+func TestGetParticipantListPartial_Paginates(t *testing.T) {
+	r, h := newTestRouter(t)
+	for i := 1; i <= 5; i++ {
+		h.service.InsertParticipant(testTenantID, models.Participant{ID: fmt.Sprintf("%03d", i), Name: fmt.Sprintf("Person %d", i)})
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newTenantRequest(http.MethodGet, "/participants/list?offset=2&limit=2", nil))
+	body := w.Body.String()
+	for _, want := range []string{"<td>003</td>", "<td>004</td>", "顯示第 3–4 筆，共 5 筆", "上一頁", "下一頁"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected %q in the page, but got %s", want, body)
+		}
+	}
+	if strings.Contains(body, "<td>001</td>") || strings.Contains(body, "<td>005</td>") {
+		t.Errorf("Expected only the requested page, but got %s", body)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, newTenantRequest(http.MethodGet, "/participants/list?q=person+5", nil))
+	body = w.Body.String()
+	if !strings.Contains(body, "<td>005</td>") || strings.Contains(body, "<td>001</td>") || !strings.Contains(body, "共 1 筆") {
+		t.Errorf("Expected the search to match only 005, but got %s", body)
+	}
+}
+
+func TestGetAuditLog(t *testing.T) {
+	r, h := newTestRouter(t)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newTenantRequest(http.MethodGet, "/audit", nil))
+	if body := strings.TrimSpace(w.Body.String()); body != "[]" {
+		t.Errorf("Expected an empty JSON array for a fresh session, but got %s", body)
+	}
+
+	h.service.AddPrize(testTenantID, "頭獎", "電視", 1, false)
+	h.service.AddParticipant(testTenantID, "001", "Alice")
+	h.service.Draw(testTenantID, "頭獎")
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, newTenantRequest(http.MethodGet, "/audit", nil))
+	var log []services.AuditEntry
+	if err := json.Unmarshal(w.Body.Bytes(), &log); err != nil {
+		t.Fatalf("Failed to decode audit log: %v", err)
+	}
+	if len(log) != 1 || log[0].Action != services.AuditDraw || log[0].TenantID != testTenantID {
+		t.Errorf("Expected one draw entry for %s, but got %+v", testTenantID, log)
+	}
+}
+
+func TestGetParticipantWins(t *testing.T) {
+	r, h := newTestRouter(t)
+	h.service.AddPrize(testTenantID, "頭獎", "電視", 1, false)
+	h.service.AddParticipant(testTenantID, "001", "Alice")
+	winner, err := h.service.Draw(testTenantID, "頭獎")
+	if err != nil {
+		t.Fatalf("Draw failed: %v", err)
+	}
+	h.service.AddParticipant(testTenantID, "002", "Bob")
+
+	t.Run("Test participant with a win", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, newTenantRequest(http.MethodGet, "/api/participants/001/wins", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, but got %d", w.Code)
+		}
+		var wins []models.LotteryResult
+		if err := json.Unmarshal(w.Body.Bytes(), &wins); err != nil {
+			t.Fatalf("Failed to decode wins: %v", err)
+		}
+		if len(wins) != 1 || wins[0].ID != winner.ID || wins[0].PrizeName != "頭獎" {
+			t.Errorf("Expected the 頭獎 win %s, but got %+v", winner.ID, wins)
+		}
+	})
+
+	t.Run("Test participant without wins", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, newTenantRequest(http.MethodGet, "/api/participants/002/wins", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, but got %d", w.Code)
+		}
+		if body := strings.TrimSpace(w.Body.String()); body != "[]" {
+			t.Errorf("Expected an empty JSON array, but got %s", body)
+		}
+	})
+
+	t.Run("Test unknown participant", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, newTenantRequest(http.MethodGet, "/api/participants/999/wins", nil))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, but got %d", w.Code)
+		}
+	})
+}
+
+func TestAddPrize_QuantityValidation(t *testing.T) {
+	tests := []struct {
+		name     string
+		quantity string
+		wantErr  string // Empty: the prize is added
+	}{
+		{"Test zero quantity", "0", services.ErrInvalidQuantity.Error()},
+		{"Test negative quantity", "-1", services.ErrInvalidQuantity.Error()},
+		{"Test non-numeric quantity", "abc", "不是整數"},
+		{"Test positive quantity", "3", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, h := newTestRouter(t)
+			form := url.Values{"prizeName": {"頭獎"}, "itemName": {"電視"}, "quantity": {tt.quantity}}
+			req := newTenantRequest(http.MethodPost, "/prizes", bytes.NewBufferString(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected the prize list to re-render with status 200, but got %d", w.Code)
+			}
+
+			prizes := h.service.GetPrizes(testTenantID)
+			if tt.wantErr == "" {
+				if len(prizes) != 1 || prizes[0].Quantity != 3 {
+					t.Errorf("Expected the prize to be added with quantity 3, but got %+v", prizes)
+				}
+				return
+			}
+			if len(prizes) != 0 {
+				t.Errorf("Expected no prize to be added, but got %+v", prizes)
+			}
+			if !strings.Contains(w.Body.String(), tt.wantErr) {
+				t.Errorf("Expected the notice %q, but got:\n%s", tt.wantErr, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestBulkImportPrizes(t *testing.T) {
+	r, h := newTestRouter(t)
+
+	payload := `[
+		{"name": "頭獎", "item": "電視", "quantity": 1},
+		{"name": "頭獎", "item": "手機", "quantity": 1},
+		{"name": "", "item": "無名", "quantity": 1},
+		{"name": "二獎", "item": "手機", "quantity": -2},
+		{"name": "業務獎", "item": "禮券", "quantity": 3, "drawFromAll": true, "group": "Sales"}
+	]`
+	req := newTenantRequest(http.MethodPost, "/api/prizes/bulk", bytes.NewBufferString(payload))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d: %s", w.Code, w.Body.String())
+	}
+
+	var summary bulkImportSummary
+	if err := json.Unmarshal(w.Body.Bytes(), &summary); err != nil {
+		t.Fatalf("Failed to decode summary: %v", err)
+	}
+	if summary.Added != 2 || summary.Rejected != 3 {
+		t.Errorf("Expected 2 added and 3 rejected, but got %+v", summary)
+	}
+	if len(summary.Errors) != 3 || summary.Errors[0].Index != 1 || summary.Errors[0].Error != services.ErrDuplicatePrize.Error() ||
+		summary.Errors[1].Index != 2 || summary.Errors[2].Index != 3 || summary.Errors[2].Name != "二獎" {
+		t.Errorf("Expected errors for items 1 to 3, but got %+v", summary.Errors)
+	}
+
+	prizes := h.service.GetPrizes(testTenantID)
+	if len(prizes) != 2 || prizes[0].Name != "頭獎" || prizes[1].Name != "業務獎" {
+		t.Fatalf("Expected only the valid prizes to be added, but got %+v", prizes)
+	}
+	if !prizes[1].DrawFromAll || prizes[1].Group != "Sales" {
+		t.Errorf("Expected 業務獎 to keep its flag and group, but got %+v", prizes[1])
+	}
+}
+
+func TestUploadPrizesCSV_ReportsDuplicates(t *testing.T) {
+	r, h := newTestRouter(t)
+	h.service.AddPrize(testTenantID, "頭獎", "電視", 1, false)
+
+	csvContent := "頭獎,手機,2,false\n二獎,耳機,3,false\n二獎,禮券,1,true\n三獎,水壺,5,false\n"
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newCSVUploadRequest(t, "/upload-prizes-csv", "prizeCSV", csvContent))
+
+	body := w.Body.String()
+	for _, want := range []string{"第 1 列", "第 3 列", services.ErrDuplicatePrize.Error()} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected %q to be reported, but got %s", want, body)
+		}
+	}
+	prizes := h.service.GetPrizes(testTenantID)
+	if len(prizes) != 3 || prizes[0].Item != "電視" || prizes[1].Item != "耳機" || prizes[2].Name != "三獎" {
+		t.Errorf("Expected the duplicates to be skipped and the rest imported, but got %+v", prizes)
+	}
+}
+
+func TestUploadPrizesCSV_ReportsDuplicatesInChosenLanguage(t *testing.T) {
+	r, h := newTestRouter(t)
+	h.service.AddPrize(testTenantID, "頭獎", "電視", 1, false)
+
+	req := newCSVUploadRequest(t, "/upload-prizes-csv", "prizeCSV", "頭獎,手機,2,false\n")
+	req.AddCookie(&http.Cookie{Name: langCookieName, Value: "en"})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if want := i18n.Localize(i18n.En, services.ErrDuplicatePrize); !strings.Contains(w.Body.String(), want) {
+		t.Errorf("Expected the duplicate to be reported as %q, but got %s", want, w.Body.String())
+	}
+}
+
+func TestUploadPrizesCSV_ReportsInvalidRowsInChosenLanguage(t *testing.T) {
+	r, _ := newTestRouter(t)
+
+	req := newCSVUploadRequest(t, "/upload-prizes-csv", "prizeCSV", "大獎,電視,abc,false\n")
+	req.Header.Set("Accept-Language", "en-US,en;q=0.9")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	want := i18n.T(i18n.En, "row_error", 1, i18n.T(i18n.En, "prize_row_quantity", "abc"))
+	if body := w.Body.String(); !strings.Contains(body, template.HTMLEscapeString(want)) {
+		t.Errorf("Expected %q to be reported, but got %s", want, body)
+	}
+}
+
+func TestNotices_InChosenLanguage(t *testing.T) {
+	r, h := newTestRouter(t)
+	h.service.AddParticipant(testTenantID, "001", "Alice")
+
+	english := func(req *http.Request) string {
+		req.AddCookie(&http.Cookie{Name: langCookieName, Value: "en"})
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Body.String()
+	}
+	clear := newTenantRequest(http.MethodPost, "/participants/clear", bytes.NewBufferString("alsoClearResults=false"))
+	clear.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	tests := []struct {
+		name string
+		req  *http.Request
+		want string
+	}{
+		{"eligible export of a missing prize", newTenantRequest(http.MethodGet, "/prizes/"+url.PathEscape("無此獎")+"/eligible-csv", nil),
+			i18n.Localize(i18n.En, i18n.NewError(i18n.WithDetail, services.ErrPrizeNotFound, "無此獎"))},
+		{"clear participants", clear, i18n.T(i18n.En, "participants_cleared", 1)},
+		{"restore", newCSVUploadRequest(t, "/import-session-json", "sessionJSON", `{}`), i18n.T(i18n.En, "restore_done")},
+	}
+	for _, tt := range tests {
+		if body := english(tt.req); !strings.Contains(body, template.HTMLEscapeString(tt.want)) {
+			t.Errorf("%s: expected %q, but got %s", tt.name, tt.want, body)
+		}
+	}
+}
+
+func TestAddPrize_ShowsDuplicateError(t *testing.T) {
+	r, h := newTestRouter(t)
+	h.service.AddPrize(testTenantID, "頭獎", "電視", 1, false)
+
+	req := newTenantRequest(http.MethodPost, "/prizes", bytes.NewBufferString("prizeName=頭獎&itemName=手機&quantity=2"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if !strings.Contains(w.Body.String(), services.ErrDuplicatePrize.Error()) {
+		t.Errorf("Expected the duplicate error to be shown, but got %s", w.Body.String())
+	}
+	if n := len(h.service.GetPrizes(testTenantID)); n != 1 {
+		t.Errorf("Expected still 1 prize, but got %d", n)
+	}
+}
+
+func TestBulkImportPrizes_MalformedJSON(t *testing.T) {
+	r, h := newTestRouter(t)
+
+	for _, payload := range []string{`[{"name": "頭獎", "quantity": 1}`, `{"name": "頭獎"}`, `[{"name": "頭獎", "quantity": "one"}]`} {
+		req := newTenantRequest(http.MethodPost, "/api/prizes/bulk", bytes.NewBufferString(payload))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for %s, but got %d: %s", payload, w.Code, w.Body.String())
+		}
+	}
+	if n := len(h.service.GetPrizes(testTenantID)); n != 0 {
+		t.Errorf("Expected no prizes to be added, but got %d", n)
+	}
+}
+
+func TestUploadPrizesCSV_ReportsInvalidRows(t *testing.T) {
+	r, h := newTestRouter(t)
+
+	csvContent := "頭獎,電視,1,false\n大獎,電視,abc,false\n二獎,手機,2,yes\n三獎,耳機,3,true,Sales\n"
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newCSVUploadRequest(t, "/upload-prizes-csv", "prizeCSV", csvContent))
+
+	body := w.Body.String()
+	for _, want := range []string{"第 2 列", "abc", "第 3 列", "yes"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected %q to be reported, but got %s", want, body)
+		}
+	}
+
+	prizes := h.service.GetPrizes(testTenantID)
+	if len(prizes) != 2 || prizes[0].Name != "頭獎" || prizes[1].Name != "三獎" {
+		t.Fatalf("Expected only the valid rows 頭獎 and 三獎 to be imported, but got %+v", prizes)
+	}
+	if prizes[1].Quantity != 3 || !prizes[1].DrawFromAll || prizes[1].Group != "Sales" {
+		t.Errorf("Expected 三獎 to keep its quantity, flag and group, but got %+v", prizes[1])
+	}
+}
+
+func TestDrawWithReveal(t *testing.T) {
+	r, h := newTestRouter(t)
+	h.SetRevealDuration(1500 * time.Millisecond)
+	h.service.AddPrize(testTenantID, "頭獎", "電視", 1, false)
+	h.service.AddParticipant(testTenantID, "001", "Alice")
+	h.service.AddParticipant(testTenantID, "002", "Bob")
+
+	req := newTenantRequest(http.MethodPost, "/draw/reveal", bytes.NewBufferString("prizeName=頭獎"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d", w.Code)
+	}
+
+	body := w.Body.String()
+	for _, want := range []string{`data-duration-ms="1500"`, "<li>Alice</li>", "<li>Bob</li>"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected response to contain %q, but got:\n%s", want, body)
+		}
+	}
+	if n := len(h.service.GetLotteryResults(testTenantID)); n != 1 {
+		t.Errorf("Expected one winner to be recorded, but got %d", n)
+	}
+}
+
+func TestDrawGroup(t *testing.T) {
+	r, h := newTestRouter(t)
+	h.service.AddPrize(testTenantID, "參加獎", "紅包", 5, false)
+	for _, name := range []string{"Alice", "Bob", "Charlie", "Dave"} {
+		h.service.AddParticipant(testTenantID, name, name)
+	}
+
+	req := newTenantRequest(http.MethodPost, "/draw/group", bytes.NewBufferString("prizeName=參加獎&count=3"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d: %s", w.Code, w.Body.String())
+	}
+	body := w.Body.String()
+	if n := strings.Count(body, "data-winner-id="); n != 3 {
+		t.Errorf("Expected 3 winners in the response, but got %d:\n%s", n, body)
+	}
+	if !strings.Contains(body, "參加獎 (剩餘: 2)") {
+		t.Errorf("Expected the dropdown to show 2 units left, but got:\n%s", body)
+	}
+
+	req = newTenantRequest(http.MethodPost, "/draw/group", bytes.NewBufferString("prizeName=參加獎&count=0"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for a zero count, but got %d", w.Code)
+	}
+}
+
+func TestDrawWithReveal_ImageAndAnnouncement(t *testing.T) {
+	r, h := newTestRouter(t)
+	h.service.InsertPrize(testTenantID, models.Prize{Name: "頭獎", Item: "電視", Quantity: 1, ImageURL: "/assets/tv.png"})
+	h.service.AddParticipant(testTenantID, "001", "Alice")
+	if err := h.service.SetAnnouncementTemplate(testTenantID, "恭喜{{.WinnerName}}抽中{{.PrizeItem}}！<b>"); err != nil {
+		t.Fatalf("SetAnnouncementTemplate failed: %v", err)
+	}
+
+	req := newTenantRequest(http.MethodPost, "/draw/reveal", bytes.NewBufferString("prizeName=頭獎"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	body := w.Body.String()
+	for _, want := range []string{`src="/assets/tv.png"`, "恭喜Alice抽中電視！&lt;b&gt;"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected response to contain %q, but got:\n%s", want, body)
+		}
+	}
+}
+
+func TestGetEligibility(t *testing.T) {
+	r, h := newTestRouter(t)
+	h.service.InsertPrize(testTenantID, models.Prize{Name: "業務獎", Item: "禮券", Quantity: 1, Group: "業務部"})
+	h.service.AddParticipant(testTenantID, "001", "Alice")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newTenantRequest(http.MethodGet, "/api/eligibility?prize="+url.QueryEscape("業務獎")+"&participant=001", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d: %s", w.Code, w.Body.String())
+	}
+	var report eligibilityReport
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatalf("Failed to decode the report: %v", err)
+	}
+	if report.Eligible || !strings.Contains(report.Reason, "不在本獎項指定群組") {
+		t.Errorf("Expected 001 to be ineligible for being outside the group, but got %+v", report)
+	}
+
+	for target, want := range map[string]int{
+		"/api/eligibility?prize=" + url.QueryEscape("不存在") + "&participant=001": http.StatusNotFound,
+		"/api/eligibility?prize=" + url.QueryEscape("業務獎") + "&participant=999": http.StatusNotFound,
+		"/api/eligibility?participant=001":                                      http.StatusBadRequest,
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, newTenantRequest(http.MethodGet, target, nil))
+		if w.Code != want {
+			t.Errorf("%s: expected status %d, but got %d: %s", target, want, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestGetPrizeOdds(t *testing.T) {
+	r, h := newTestRouter(t)
+	h.service.AddPrize(testTenantID, "頭獎", "電視", 1, false)
+	h.service.AddParticipant(testTenantID, "001", "Alice")
+	h.service.AddParticipant(testTenantID, "002", "Bob")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newTenantRequest(http.MethodGet, "/api/prizes/頭獎/odds", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d: %s", w.Code, w.Body.String())
+	}
+	var odds prizeOdds
+	if err := json.Unmarshal(w.Body.Bytes(), &odds); err != nil {
+		t.Fatalf("Failed to decode odds: %v", err)
+	}
+	if odds.Prize != "頭獎" || odds.Eligible != 2 || odds.Odds != 0.5 {
+		t.Errorf("Expected 頭獎 with 2 eligible at 0.5, but got %+v", odds)
+	}
+
+	h.service.Draw(testTenantID, "頭獎")
+	for path, want := range map[string]int{
+		"/api/prizes/頭獎/odds":  http.StatusConflict,
+		"/api/prizes/不存在/odds": http.StatusNotFound,
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, newTenantRequest(http.MethodGet, path, nil))
+		if w.Code != want || !strings.Contains(w.Body.String(), `"error"`) {
+			t.Errorf("%s: expected status %d with an error, but got %d: %s", path, want, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestGetPrizeSummary(t *testing.T) {
+	r, h := newTestRouter(t)
+	h.service.AddPrize(testTenantID, "頭獎", "電視", 2, false)
+	h.service.AddParticipant(testTenantID, "001", "Alice")
+	h.service.AddParticipant(testTenantID, "002", "Bob")
+	h.service.Draw(testTenantID, "頭獎")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newTenantRequest(http.MethodGet, "/api/summary", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d", w.Code)
+	}
+	var summary []services.PrizeSummary
+	if err := json.Unmarshal(w.Body.Bytes(), &summary); err != nil {
+		t.Fatalf("Failed to decode summary: %v", err)
+	}
+	want := []services.PrizeSummary{{Name: "頭獎", Item: "電視", OriginalQuantity: 2, Remaining: 1, Awarded: 1, Eligible: 1}}
+	if !reflect.DeepEqual(summary, want) {
+		t.Errorf("Expected %+v, but got %+v", want, summary)
+	}
+}
+
+func TestGetDrawProgress(t *testing.T) {
+	r, h := newTestRouter(t)
+	h.service.AddPrize(testTenantID, "頭獎", "電視", 2, false)
+	h.service.AddParticipant(testTenantID, "001", "Alice")
+	h.service.AddParticipant(testTenantID, "002", "Bob")
+	h.service.Draw(testTenantID, "頭獎")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newTenantRequest(http.MethodGet, "/api/progress", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d", w.Code)
+	}
+	if want := `{"drawn":1,"total":2}`; w.Body.String() != want {
+		t.Errorf("Expected %s, but got %s", want, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, newTenantRequest(http.MethodGet, "/lottery", nil))
+	if !strings.Contains(w.Body.String(), "已抽出 1 / 2") {
+		t.Errorf("Expected the lottery page to show the progress, but got:\n%s", w.Body.String())
+	}
+}
+
+func TestRenamePrize(t *testing.T) {
+	r, h := newTestRouter(t)
+	h.service.AddPrize(testTenantID, "頭獎", "電視", 1, false)
+	h.service.AddPrize(testTenantID, "二獎", "冰箱", 1, false)
+	h.service.AddParticipant(testTenantID, "001", "Alice")
+	h.service.Draw(testTenantID, "頭獎")
+
+	rename := func(from, to string) string {
+		req := newTenantRequest(http.MethodPost, "/prizes/rename", bytes.NewBufferString(url.Values{"prizeName": {from}, "newName": {to}}.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Body.String()
+	}
+
+	if body := rename("頭獎", "特獎"); !strings.Contains(body, "特獎") {
+		t.Errorf("Expected the prize list to show the new name, but got:\n%s", body)
+	}
+	if wins, _ := h.service.GetParticipantWins(testTenantID, "001"); len(wins) != 1 || wins[0].PrizeName != "特獎" {
+		t.Errorf("Expected the win to move to 特獎, but got %v", wins)
+	}
+	if body := rename("特獎", "二獎"); !strings.Contains(body, services.ErrDuplicatePrize.Error()) {
+		t.Errorf("Expected a duplicate-name notice, but got:\n%s", body)
+	}
+}
+
+func TestExportResultsCSV_IncludesArchived(t *testing.T) {
+	r, h := newTestRouter(t)
+	h.service.SetHotResultsLimit(1)
+	h.service.AddPrize(testTenantID, "參加獎", "紅包", 3, false)
+	for _, id := range []string{"001", "002", "003"} {
+		h.service.AddParticipant(testTenantID, id, "P"+id)
+	}
+	h.service.DrawBatch(testTenantID, "參加獎", 3)
+	if n := len(h.service.GetLotteryResults(testTenantID)); n != 1 {
+		t.Fatalf("Expected 1 hot result, but got %d", n)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newTenantRequest(http.MethodGet, "/export-results-csv", nil))
+	if records := readCSV(t, w.Body.Bytes()); len(records) != 4 {
+		t.Errorf("Expected a header and all 3 results, but got %v", records)
+	}
+}
+
+func TestMaskIDs_DisplayMaskedExportFull(t *testing.T) {
+	r, h := newTestRouter(t)
+	h.service.AddPrize(testTenantID, "頭獎", "電視", 1, false)
+	h.service.AddParticipant(testTenantID, "A12345", "Alice")
+	if _, err := h.service.Draw(testTenantID, "頭獎"); err != nil {
+		t.Fatalf("Draw failed: %v", err)
+	}
+
+	req := newTenantRequest(http.MethodPost, "/settings/mask-ids", bytes.NewBufferString("maskIDs=true"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if !strings.Contains(w.Body.String(), "中獎人是Alice(員編****45)") {
+		t.Errorf("Expected the result list to show the masked ID, but got:\n%s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, newTenantRequest(http.MethodGet, "/export-results-csv", nil))
+	records := readCSV(t, w.Body.Bytes())
+	if len(records) != 2 || records[1][1] != "A12345" {
+		t.Errorf("Expected the export to keep the full ID A12345, but got %v", records)
+	}
+}
+
+func TestSetAnnouncementTemplate_RejectsMalformed(t *testing.T) {
+	r, h := newTestRouter(t)
+
+	req := newTenantRequest(http.MethodPost, "/settings/announcement", bytes.NewBufferString("announcementTemplate="+url.QueryEscape("{{.WinnerName")))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected the page to re-render with status 200, but got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "公告範本無效") {
+		t.Errorf("Expected the page to report the invalid template, but got:\n%s", w.Body.String())
+	}
+	if got := h.service.GetAnnouncementTemplate(testTenantID); got != services.DefaultAnnouncementTemplate {
+		t.Errorf("Expected the default template to stay in effect, but got %q", got)
+	}
+}
+
+func TestPerformDrawAnimation_LocalizedError(t *testing.T) {
+	r, _ := newTestRouter(t)
+
+	tests := []struct {
+		name       string
+		acceptLang string
+		cookie     string
+		want       string
+	}{
+		{"default", "", "", "指定的獎項不存在：不存在"},
+		{"zh-TW", "zh-TW,zh;q=0.9", "", "指定的獎項不存在：不存在"},
+		{"en", "en-US,en;q=0.9", "", "The prize does not exist: 不存在"},
+		{"cookie wins", "zh-TW", "en", "The prize does not exist: 不存在"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newTenantRequest(http.MethodPost, "/draw/animation", bytes.NewBufferString("prizeName=不存在"))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if tt.acceptLang != "" {
+				req.Header.Set("Accept-Language", tt.acceptLang)
+			}
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: langCookieName, Value: tt.cookie})
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != http.StatusNotFound {
+				t.Fatalf("Expected status %d, but got %d", http.StatusNotFound, w.Code)
+			}
+			if body := w.Body.String(); !strings.Contains(body, tt.want) {
+				t.Errorf("Expected the error %q, but got %q", tt.want, body)
+			}
+		})
+	}
+}
+
+func TestSetLang(t *testing.T) {
+	r, _ := newTestRouter(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/set-lang?lang=en", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusFound {
+		t.Fatalf("Expected status %d, but got %d", http.StatusFound, w.Code)
+	}
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != langCookieName || cookies[0].Value != "en" {
+		t.Errorf("Expected a %s=en cookie, but got %v", langCookieName, cookies)
+	}
+}
+
+func TestPerformDrawAnimation_ErrorStatus(t *testing.T) {
+	r, h := newTestRouter(t)
+	h.service.AddPrize(testTenantID, "頭獎", "電視", 1, false)
+	h.service.AddPrize(testTenantID, "已抽完", "手機", 1, false)
+	h.service.InsertPrize(testTenantID, models.Prize{Name: "業務獎", Item: "禮券", Quantity: 1, Group: "Sales"})
+	h.service.AddParticipant(testTenantID, "001", "Alice")
+	h.service.AddParticipant(testTenantID, "002", "Bob")
+	h.service.ReserveWinner(testTenantID, "已抽完", "002")
+	if _, err := h.service.Draw(testTenantID, "已抽完"); err != nil {
+		t.Fatalf("Draw failed: %v", err)
+	}
+
+	tests := []struct {
+		prize string
+		want  int
+	}{
+		{"不存在", http.StatusNotFound},
+		{"已抽完", http.StatusConflict},
+		{"業務獎", http.StatusUnprocessableEntity},
+		{"頭獎", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.prize, func(t *testing.T) {
+			req := newTenantRequest(http.MethodPost, "/draw/animation", bytes.NewBufferString("prizeName="+tt.prize))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("Expected status %d, but got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestPerformDrawAnimation_EmptyRoster(t *testing.T) {
+	r, h := newTestRouter(t)
+	h.service.AddPrize(testTenantID, "頭獎", "電視", 1, false)
+
+	req := newTenantRequest(http.MethodPost, "/draw/animation", bytes.NewBufferString("prizeName=頭獎"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status 422, but got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "尚未匯入參與者") {
+		t.Errorf("Expected the empty-roster message, but got %q", w.Body.String())
+	}
+}
+
+func TestPerformDrawAnimation_IdempotencyKey(t *testing.T) {
+	r, h := newTestRouter(t)
+	h.service.AddPrize(testTenantID, "頭獎", "電視", 2, false)
+	h.service.AddParticipant(testTenantID, "001", "Alice")
+	h.service.AddParticipant(testTenantID, "002", "Bob")
+
+	for i := 0; i < 2; i++ {
+		req := newTenantRequest(http.MethodPost, "/draw/animation", bytes.NewBufferString("prizeName=頭獎"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Idempotency-Key", "double-click")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Request %d: expected status 200, but got %d", i, w.Code)
+		}
+	}
+
+	if n := len(h.service.GetLotteryResults(testTenantID)); n != 1 {
+		t.Errorf("Expected one winner for a repeated key, but got %d", n)
+	}
+	if q := h.service.GetPrizes(testTenantID)[0].Quantity; q != 1 {
+		t.Errorf("Expected a single quantity decrement, but %d remain", q)
+	}
+}
+
+func TestRenderPartial_TemplateErrorSendsClean500(t *testing.T) {
+	r, h := newTestRouter(t)
+	broken := template.Must(h.templates.Clone())
+	broken.Funcs(template.FuncMap{"fail": func() (string, error) { return "", fmt.Errorf("boom") }})
+	template.Must(broken.Parse(`{{define "prize_list_container.html"}}<div id="half-rendered">{{fail}}</div>{{end}}`))
+	template.Must(broken.Parse(`{{define "layout.html"}}<html id="half-rendered">{{fail}}</html>{{end}}`))
+	h.templates = broken
+
+	tests := []struct {
+		name string
+		req  *http.Request
+	}{
+		{"partial", newTenantRequest(http.MethodPost, "/prizes", bytes.NewBufferString("prizeName=頭獎&itemName=電視&quantity=1"))},
+		{"page", newTenantRequest(http.MethodGet, "/prizes", nil)},
+	}
+	for _, tt := range tests {
+		if tt.req.Method == http.MethodPost {
+			tt.req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, tt.req)
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("%s: expected status 500, but got %d", tt.name, w.Code)
+		}
+		if body := w.Body.String(); body != "Template rendering error" {
+			t.Errorf("%s: expected only the error message, but got %q", tt.name, body)
+		}
+	}
+}
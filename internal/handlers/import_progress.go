@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"lottery/internal/i18n"
+	"lottery/internal/models"
+	"lottery/internal/services"
+)
+
+// importChunkSize is how many rows are bulk-inserted between progress reports.
+const importChunkSize = 1000
+
+// importJobRetention is how long a finished job stays queryable before it is forgotten.
+const importJobRetention = 10 * time.Minute
+
+// importProgress is a snapshot of a background participant import.
+type importProgress struct {
+	Processed int    `json:"processed"` // CSV rows read so far
+	Inserted  int    `json:"inserted"`  // Participants actually added (duplicates excluded)
+	Skipped   int    `json:"skipped"`   // Malformed rows
+	Done      bool   `json:"done"`
+	Error     string `json:"error,omitempty"`
+}
+
+// importJob tracks one background import. Watchers wait on the changed channel,
+// which is closed and replaced every time the progress is updated.
+type importJob struct {
+	tenantID string
+
+	mu       sync.Mutex
+	progress importProgress
+	changed  chan struct{}
+}
+
+// importTracker keeps the in-flight and recently finished imports.
+type importTracker struct {
+	mu   sync.Mutex
+	jobs map[string]*importJob // Key: job ID
+}
+
+func newImportTracker() *importTracker {
+	return &importTracker{jobs: make(map[string]*importJob)}
+}
+
+// start registers a new job for a tenant and returns its ID.
+func (t *importTracker) start(tenantID string) (string, *importJob, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", nil, err
+	}
+	id := hex.EncodeToString(buf)
+	job := &importJob{tenantID: tenantID, changed: make(chan struct{})}
+
+	t.mu.Lock()
+	t.jobs[id] = job
+	t.mu.Unlock()
+	return id, job, nil
+}
+
+// get returns the job with the given ID if it belongs to the tenant.
+func (t *importTracker) get(id, tenantID string) *importJob {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	job, ok := t.jobs[id]
+	if !ok || job.tenantID != tenantID {
+		return nil
+	}
+	return job
+}
+
+// forget removes a job after the retention period.
+func (t *importTracker) forget(id string) {
+	time.AfterFunc(importJobRetention, func() {
+		t.mu.Lock()
+		delete(t.jobs, id)
+		t.mu.Unlock()
+	})
+}
+
+// update applies fn to the job's progress and wakes up every watcher.
+func (j *importJob) update(fn func(p *importProgress)) {
+	j.mu.Lock()
+	fn(&j.progress)
+	close(j.changed)
+	j.changed = make(chan struct{})
+	j.mu.Unlock()
+}
+
+// snapshot returns the current progress and a channel closed on the next update.
+func (j *importJob) snapshot() (importProgress, <-chan struct{}) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.progress, j.changed
+}
+
+// StartParticipantImport accepts a participant CSV and imports it in the background,
+// returning a progress widget that follows the job via GET /import-progress/:id.
+func (h *HTTPHandler) StartParticipantImport(c *gin.Context) {
+	tenantID := c.GetString(tenantIDKey)
+	// The multipart file is gone once the request ends, so buffer it first.
+	data, ok := h.readUpload(c, "participantCSV")
+	if !ok {
+		return
+	}
+	h.csvUploads.Add(1)
+
+	jobID, job, err := h.imports.start(tenantID)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Error starting import: %v", err)
+		return
+	}
+	go h.runParticipantImport(jobID, job, data, c.PostForm("hasHeader") == "true", h.lang(c))
+
+	h.renderPartial(c, "import_progress.html", gin.H{"JobID": jobID})
+}
+
+// runParticipantImport parses the CSV and bulk-inserts it chunk by chunk,
+// publishing progress after each chunk. With hasHeader the first row is always
+// skipped; otherwise only a row matching participantCSVHeader is. Malformed
+// and duplicate rows are kept for GET /import-errors.csv, with reasons in lang.
+func (h *HTTPHandler) runParticipantImport(jobID string, job *importJob, data []byte, hasHeader bool, lang i18n.Lang) {
+	defer h.imports.forget(jobID)
+	logger := services.WithTenant(h.log, job.tenantID)
+
+	// Count the rows up front so an oversized file fails before anything is inserted.
+	counter := newUploadCSVReader(bytes.NewReader(data))
+	counter.FieldsPerRecord = -1
+	rows := 0
+	for first := true; ; first = false {
+		record, err := counter.Read()
+		if err != nil {
+			break // Read errors are reported by the import pass below
+		}
+		if !(first && (hasHeader || slices.Equal(record, participantCSVHeader))) {
+			rows++
+		}
+	}
+	if rows > h.limits.MaxRows {
+		logger.Infof("Import %s rejected: more than %d rows", jobID, h.limits.MaxRows)
+		job.update(func(p *importProgress) {
+			p.Done = true
+			p.Error = i18n.T(lang, "upload_too_many_rows", h.limits.MaxRows)
+		})
+		return
+	}
+
+	reader := newUploadCSVReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1 // Row widths are validated below so one bad row doesn't abort the import
+
+	chunk := make([]*models.Participant, 0, importChunkSize)
+	chunkRows := make([]services.ImportError, 0, importChunkSize) // Line and record of each chunk entry
+	processed, skipped := 0, 0
+	var rejected []services.ImportError
+	flush := func() {
+		inserted, duplicates := h.service.AddParticipants(job.tenantID, chunk)
+		for _, i := range duplicates {
+			row := chunkRows[i]
+			row.Reason = i18n.Localize(lang, services.ErrDuplicateParticipant)
+			rejected = append(rejected, row)
+		}
+		chunk, chunkRows = chunk[:0], chunkRows[:0]
+		job.update(func(p *importProgress) {
+			p.Processed = processed
+			p.Skipped = skipped
+			p.Inserted += inserted
+		})
+	}
+
+	for first := true; ; first = false {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			flush()
+			logger.Errorf("Import %s failed: %v", jobID, err)
+			job.update(func(p *importProgress) {
+				p.Done = true
+				p.Error = fmt.Sprintf("Error reading CSV: %v", err)
+			})
+			return
+		}
+		if first && (hasHeader || slices.Equal(record, participantCSVHeader)) {
+			continue
+		}
+		processed++
+		line, _ := reader.FieldPos(0)
+		participant, err := parseParticipantRecord(record)
+		if err != nil {
+			logger.Infof("Skipping malformed participant CSV record %v: %v", record, err)
+			rejected = append(rejected, services.ImportError{Line: line, Record: record, Reason: i18n.Localize(lang, err)})
+			skipped++
+			continue
+		}
+		chunk = append(chunk, &participant)
+		chunkRows = append(chunkRows, services.ImportError{Line: line, Record: record})
+		if len(chunk) == importChunkSize {
+			flush()
+		}
+	}
+
+	flush()
+	// Duplicates are only known once their chunk is inserted; report rows in file order.
+	slices.SortStableFunc(rejected, func(a, b services.ImportError) int { return a.Line - b.Line })
+	h.service.SetImportErrors(job.tenantID, rejected)
+	job.update(func(p *importProgress) { p.Done = true })
+	logger.Infof("Import %s finished after %d rows", jobID, processed)
+}
+
+// StreamImportProgress streams a background import's progress as Server-Sent Events.
+// It emits "progress" events until the job finishes, then a final "done" event.
+func (h *HTTPHandler) StreamImportProgress(c *gin.Context) {
+	job := h.imports.get(c.Param("id"), c.GetString(tenantIDKey))
+	if job == nil {
+		c.String(http.StatusNotFound, "Import job not found")
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+
+	for {
+		progress, changed := job.snapshot()
+		c.SSEvent("progress", progress)
+		if progress.Done {
+			c.SSEvent("done", progress)
+			c.Writer.Flush()
+			return
+		}
+		c.Writer.Flush()
+
+		select {
+		case <-changed:
+		case <-c.Request.Context().Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"lottery/internal/i18n"
+	"lottery/internal/services"
+)
+
+func TestParticipantImportProgress(t *testing.T) {
+	r, h := newTestRouter(t)
+	server := httptest.NewServer(r)
+	defer server.Close()
+	const tenantID = testTenantName + "-127.0.0.1" // Requests arrive from the loopback test server
+
+	const rows = importChunkSize*2 + 500
+	var sb strings.Builder
+	for i := 0; i < rows; i++ {
+		fmt.Fprintf(&sb, "%05d,Participant %d\n", i, i)
+	}
+
+	body, contentType := newCSVUploadBody(t, "participantCSV", sb.String())
+	req, _ := http.NewRequest(http.MethodPost, server.URL+"/upload-participants-csv/async", body)
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set(csrfHeaderName, csrfTokenFor([]byte(testCookieSecret), tenantID))
+	req.AddCookie(testTenantCookie(testTenantName))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to start import: %v", err)
+	}
+	page, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	match := regexp.MustCompile(`/import-progress/([0-9a-f]+)`).FindSubmatch(page)
+	if match == nil {
+		t.Fatalf("Expected the response to reference a progress stream, but got %s", page)
+	}
+
+	req, _ = http.NewRequest(http.MethodGet, server.URL+"/import-progress/"+string(match[1]), nil)
+	req.AddCookie(testTenantCookie(testTenantName))
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to open progress stream: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var progressEvents int
+	var final importProgress
+	var event string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimPrefix(line, "event:")
+		case strings.HasPrefix(line, "data:"):
+			var p importProgress
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data:")), &p); err != nil {
+				t.Fatalf("Failed to decode event data %q: %v", line, err)
+			}
+			if event == "progress" {
+				progressEvents++
+			} else if event == "done" {
+				final = p
+			}
+		}
+	}
+
+	if progressEvents == 0 {
+		t.Error("Expected at least one progress event, but got none")
+	}
+	if !final.Done || final.Processed != rows || final.Inserted != rows {
+		t.Errorf("Expected a final event with %d rows processed and inserted, but got %+v", rows, final)
+	}
+	if n := len(h.service.GetParticipants(tenantID)); n != rows {
+		t.Errorf("Expected %d participants after import, but got %d", rows, n)
+	}
+}
+
+func TestStreamImportProgress_UnknownJob(t *testing.T) {
+	r, _ := newTestRouter(t)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newTenantRequest(http.MethodGet, "/import-progress/missing", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, but got %d", w.Code)
+	}
+}
+
+// awaitParticipantImport starts a background import of content on server, with
+// the given language cookie, and returns the job's final progress.
+func awaitParticipantImport(t *testing.T, server *httptest.Server, content, lang string) importProgress {
+	t.Helper()
+	const tenantID = testTenantName + "-127.0.0.1"
+	body, contentType := newCSVUploadBody(t, "participantCSV", content)
+	req, _ := http.NewRequest(http.MethodPost, server.URL+"/upload-participants-csv/async", body)
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set(csrfHeaderName, csrfTokenFor([]byte(testCookieSecret), tenantID))
+	req.AddCookie(testTenantCookie(testTenantName))
+	req.AddCookie(&http.Cookie{Name: langCookieName, Value: lang})
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to start import: %v", err)
+	}
+	page, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	match := regexp.MustCompile(`/import-progress/([0-9a-f]+)`).FindSubmatch(page)
+	if match == nil {
+		t.Fatalf("Expected the response to reference a progress stream, but got %s", page)
+	}
+
+	req, _ = http.NewRequest(http.MethodGet, server.URL+"/import-progress/"+string(match[1]), nil)
+	req.AddCookie(testTenantCookie(testTenantName))
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to open progress stream: %v", err)
+	}
+	defer resp.Body.Close()
+	var final importProgress
+	var event string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimPrefix(line, "event:")
+		case strings.HasPrefix(line, "data:") && event == "done":
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data:")), &final); err != nil {
+				t.Fatalf("Failed to decode event data %q: %v", line, err)
+			}
+		}
+	}
+	return final
+}
+
+func TestParticipantImport_ReportsDuplicates(t *testing.T) {
+	r, h := newTestRouter(t)
+	server := httptest.NewServer(r)
+	defer server.Close()
+	const tenantID = testTenantName + "-127.0.0.1"
+	h.service.AddParticipant(tenantID, "001", "Alice")
+
+	final := awaitParticipantImport(t, server, "001,Alice again\n002,Bob\n003\n002,Bob again\n", "en")
+	if !final.Done || final.Inserted != 1 || final.Skipped != 1 {
+		t.Errorf("Expected one row inserted and one malformed, but got %+v", final)
+	}
+
+	errs := h.service.GetImportErrors(tenantID)
+	wantLines := []int{1, 3, 4}
+	if len(errs) != len(wantLines) {
+		t.Fatalf("Expected rows %v to be rejected, but got %+v", wantLines, errs)
+	}
+	duplicate := i18n.Localize(i18n.En, services.ErrDuplicateParticipant)
+	for i, line := range wantLines {
+		if errs[i].Line != line {
+			t.Errorf("Expected rejected row %d to be line %d, but got %+v", i, line, errs[i])
+		}
+	}
+	if errs[0].Reason != duplicate || errs[2].Reason != duplicate {
+		t.Errorf("Expected the duplicates to be rejected with %q, but got %+v", duplicate, errs)
+	}
+}
+
+func TestParticipantImport_TooManyRowsInChosenLanguage(t *testing.T) {
+	r, h := newTestRouter(t)
+	h.limits = UploadLimits{MaxBytes: 1 << 20, MaxRows: 1}
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	final := awaitParticipantImport(t, server, "001,Alice\n002,Bob\n", "en")
+	if want := i18n.T(i18n.En, "upload_too_many_rows", 1); final.Error != want {
+		t.Errorf("Expected the error %q, but got %+v", want, final)
+	}
+}
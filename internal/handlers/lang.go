@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"lottery/internal/i18n"
+)
+
+// langCookieName holds the language picked through /set-lang. Without it the
+// Accept-Language header decides.
+const langCookieName = "lottery_lang"
+
+// lang returns the language the response to c should be written in.
+func (h *HTTPHandler) lang(c *gin.Context) i18n.Lang {
+	return langFor(c)
+}
+
+// langFor is lang for middleware that has no handler at hand.
+func langFor(c *gin.Context) i18n.Lang {
+	choice, _ := c.Cookie(langCookieName)
+	return i18n.Negotiate(choice, c.GetHeader("Accept-Language"))
+}
+
+// localize returns err's message in the request's language.
+func (h *HTTPHandler) localize(c *gin.Context, err error) string {
+	return i18n.Localize(h.lang(c), err)
+}
+
+// SetLang stores the language given by the "lang" query parameter in a cookie
+// and redirects to the home page. An unsupported language clears the cookie, so
+// Accept-Language applies again.
+func (h *HTTPHandler) SetLang(c *gin.Context) {
+	if lang, ok := i18n.Parse(c.Query("lang")); ok {
+		c.SetCookie(langCookieName, string(lang), 3600*24*365, "/", "", false, true)
+	} else {
+		c.SetCookie(langCookieName, "", -1, "/", "", false, true)
+	}
+	c.Redirect(http.StatusFound, "/")
+}
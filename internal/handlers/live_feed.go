@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"lottery/internal/services"
+)
+
+// liveFeedCoalesce batches events for projection screens: a batch draw of many
+// winners reaches the browser as a few frames rather than one per winner.
+var liveFeedCoalesce = services.CoalesceConfig{Interval: 100 * time.Millisecond, MaxBatch: 100}
+
+// StreamEvents pushes the tenant's result changes as Server-Sent Events until
+// the client disconnects. Each event is named after its type ("draw", "batch",
+// "undo", "redraw", "reset" or "merge") and carries a services.LotteryEvent as JSON.
+func (h *HTTPHandler) StreamEvents(c *gin.Context) {
+	events, unsubscribe := h.service.Subscribe(c.GetString(tenantIDKey), liveFeedCoalesce)
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Writer.Flush()
+
+	for {
+		select {
+		case batch, ok := <-events:
+			if !ok {
+				return
+			}
+			for _, event := range batch {
+				c.SSEvent(event.Type, event)
+			}
+			c.Writer.Flush()
+		case <-c.Request.Context().Done():
+			return
+		}
+	}
+}
+
+// ShowProjection renders the big-screen results page, which follows /events live.
+func (h *HTTPHandler) ShowProjection(c *gin.Context) {
+	data := gin.H{
+		"title":          "得獎投影",
+		"LotteryResults": h.service.GetLotteryResults(c.GetString(tenantIDKey)),
+	}
+	h.renderPage(c, data, "projection.html")
+}
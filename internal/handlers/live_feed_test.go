@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"lottery/internal/services"
+)
+
+func TestStreamEvents(t *testing.T) {
+	r, h := newTestRouter(t)
+	server := httptest.NewServer(r)
+	defer server.Close()
+	const tenantID = testTenantName + "-127.0.0.1" // Requests arrive from the loopback test server
+
+	h.service.AddPrize(tenantID, "頭獎", "電視", 1, false)
+	h.service.AddParticipant(tenantID, "001", "Alice")
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/events", nil)
+	req.AddCookie(testTenantCookie(testTenantName))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to open event stream: %v", err)
+	}
+	defer resp.Body.Close()
+
+	// The subscription is registered before the headers are flushed, so drawing now is observed.
+	if _, err := h.service.Draw(tenantID, "頭獎"); err != nil {
+		t.Fatalf("Draw failed: %v", err)
+	}
+
+	received := make(chan services.LotteryEvent, 1)
+	go func() {
+		var event string
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case strings.HasPrefix(line, "event:"):
+				event = strings.TrimPrefix(line, "event:")
+			case strings.HasPrefix(line, "data:") && event == services.EventDraw:
+				var ev services.LotteryEvent
+				if json.Unmarshal([]byte(strings.TrimPrefix(line, "data:")), &ev) == nil {
+					received <- ev
+					return
+				}
+			}
+		}
+	}()
+
+	select {
+	case ev := <-received:
+		if len(ev.Results) != 1 || ev.Results[0].WinnerID != "001" {
+			t.Errorf("Expected a draw event for participant 001, but got %+v", ev)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for a draw event")
+	}
+}
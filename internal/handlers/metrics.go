@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// metricsContentType is the Prometheus text exposition format.
+const metricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Metrics exposes service counters in the Prometheus text format. It is public
+// and does not touch any tenant session.
+func (h *HTTPHandler) Metrics(c *gin.Context) {
+	m := h.service.Metrics()
+
+	var b strings.Builder
+	writeMetric := func(name, kind, help string, value int64) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n%s %d\n", name, help, name, kind, name, value)
+	}
+	writeMetric("lottery_draws_total", "counter", "Winners recorded by draws, batch draws and redraws.", m.Draws)
+	writeMetric("lottery_participants_added_total", "counter", "Participants added to any session.", m.ParticipantsAdded)
+	writeMetric("lottery_csv_uploads_total", "counter", "Accepted prize and participant CSV uploads.", h.csvUploads.Load())
+	writeMetric("lottery_active_sessions", "gauge", "Tenant sessions currently held in memory.", int64(m.ActiveSessions))
+	writeMetric("lottery_session_evictions_total", "counter", "Sessions evicted for inactivity.", m.Evictions)
+
+	c.Data(http.StatusOK, metricsContentType, []byte(b.String()))
+}
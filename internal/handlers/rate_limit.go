@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"lottery/internal/i18n"
+)
+
+// Default limits for the draw and upload endpoints. A human operator never
+// comes close; a scripted client or a stuck UI is stopped after the burst.
+const (
+	defaultDrawRate  = 2.0 // Sustained requests per second per tenant
+	defaultDrawBurst = 5   // Requests allowed back to back before the rate applies
+	rateLimiterSweep = 10 * time.Minute
+)
+
+// tokenBucket is one tenant's allowance: it holds up to burst tokens and refills
+// at rate tokens per second. Each request spends one token.
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// rateLimiter keeps a token bucket per tenant. Buckets that have refilled
+// completely carry no state worth keeping and are swept periodically.
+type rateLimiter struct {
+	rate  float64
+	burst int
+
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket // Key: tenantID
+	lastSweep time.Time
+	now       func() time.Time // Replaced in tests
+}
+
+func newRateLimiter(rps float64, burst int) *rateLimiter {
+	return &rateLimiter{
+		rate:      rps,
+		burst:     burst,
+		buckets:   make(map[string]*tokenBucket),
+		lastSweep: time.Now(),
+		now:       time.Now,
+	}
+}
+
+// allow spends a token for the tenant. When none is left it returns false and
+// how long until the next token is available.
+func (l *rateLimiter) allow(tenantID string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+
+	if now.Sub(l.lastSweep) > rateLimiterSweep {
+		for id, b := range l.buckets {
+			if l.refill(b, now) >= float64(l.burst) {
+				delete(l.buckets, id)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	b, ok := l.buckets[tenantID]
+	if !ok {
+		b = &tokenBucket{tokens: float64(l.burst), last: now}
+		l.buckets[tenantID] = b
+	}
+	if l.refill(b, now) < 1 {
+		wait := time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+		return false, wait
+	}
+	b.tokens--
+	return true, 0
+}
+
+// refill credits the tokens earned since the bucket was last touched.
+func (l *rateLimiter) refill(b *tokenBucket, now time.Time) float64 {
+	b.tokens = math.Min(float64(l.burst), b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+	return b.tokens
+}
+
+// DrawRateLimitMiddleware limits each tenant to rps requests per second with the
+// given burst. Requests over the limit get 429 Too Many Requests with a
+// Retry-After header. It must run after TenantMiddleware.
+func DrawRateLimitMiddleware(rps float64, burst int) gin.HandlerFunc {
+	return rateLimitMiddleware(newRateLimiter(rps, burst))
+}
+
+func rateLimitMiddleware(l *rateLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ok, wait := l.allow(c.GetString(tenantIDKey))
+		if !ok {
+			seconds := int(math.Ceil(wait.Seconds()))
+			c.Header("Retry-After", strconv.Itoa(seconds))
+			c.String(http.StatusTooManyRequests, i18n.T(langFor(c), "rate_limited", seconds))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
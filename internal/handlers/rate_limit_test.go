@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDrawRateLimit(t *testing.T) {
+	r, h := newTestRouter(t)
+	h.service.AddPrize(testTenantID, "參加獎", "紅包", 100, false)
+	for _, id := range []string{"001", "002", "003", "004", "005", "006", "007", "008", "009", "010"} {
+		h.service.AddParticipant(testTenantID, id, "Participant")
+	}
+
+	var codes []int
+	var retryAfter string
+	for i := 0; i < defaultDrawBurst+3; i++ {
+		req := newTenantRequest(http.MethodPost, "/draw/animation", bytes.NewBufferString("prizeName=參加獎"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		codes = append(codes, w.Code)
+		if w.Code == http.StatusTooManyRequests {
+			retryAfter = w.Header().Get("Retry-After")
+		}
+	}
+
+	for i, code := range codes[:defaultDrawBurst] {
+		if code != http.StatusOK {
+			t.Errorf("Request %d within the burst: expected 200, but got %d", i, code)
+		}
+	}
+	if codes[defaultDrawBurst] != http.StatusTooManyRequests {
+		t.Errorf("Expected 429 once the burst is exhausted, but got %v", codes)
+	}
+	if retryAfter == "" {
+		t.Error("Expected a Retry-After header on 429 responses")
+	}
+	if n := len(h.service.GetLotteryResults(testTenantID)); n != defaultDrawBurst {
+		t.Errorf("Expected only %d draws to go through, but got %d", defaultDrawBurst, n)
+	}
+
+	// Another tenant has its own allowance.
+	req := httptest.NewRequest(http.MethodPost, "/draw/animation", bytes.NewBufferString("prizeName=參加獎"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.AddCookie(testTenantCookie("someone-else"))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code == http.StatusTooManyRequests {
+		t.Error("Expected another tenant not to be limited")
+	}
+}
+
+func TestRateLimiter_RefillAndSweep(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := newRateLimiter(1, 2)
+	l.now = func() time.Time { return now }
+	l.lastSweep = now
+
+	for i := 0; i < 2; i++ {
+		if ok, _ := l.allow("t"); !ok {
+			t.Fatalf("Request %d: expected the burst to be allowed", i)
+		}
+	}
+	ok, wait := l.allow("t")
+	if ok || wait <= 0 || wait > time.Second {
+		t.Errorf("Expected a denial with a wait of up to 1s, but got ok=%v wait=%v", ok, wait)
+	}
+
+	now = now.Add(time.Second)
+	if ok, _ := l.allow("t"); !ok {
+		t.Error("Expected a token to be refilled after one second")
+	}
+
+	now = now.Add(rateLimiterSweep + time.Second)
+	l.allow("other")
+	if _, ok := l.buckets["t"]; ok {
+		t.Error("Expected the idle, refilled bucket to be swept")
+	}
+}
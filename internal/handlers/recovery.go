@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"net/http"
+	"runtime/debug"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RecoveryMiddleware replaces gin.Recovery: a panicking handler is logged with
+// the tenant ID and the full stack, and the client gets error.html with status
+// 500 instead of a bare error. HTMX requests receive only the error fragment so
+// it can be swapped into the page. If the handler had already started writing
+// its response, the status can no longer change and the response is just cut off.
+func (h *HTTPHandler) RecoveryMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			if rec == http.ErrAbortHandler {
+				panic(rec) // Deliberate aborts are left to net/http, which stays quiet about them
+			}
+			h.logFor(c).Errorf("panic serving %s %s: %v\n%s", c.Request.Method, c.Request.URL.Path, rec, debug.Stack())
+
+			if c.Writer.Written() {
+				c.Abort()
+				return
+			}
+			c.Status(http.StatusInternalServerError)
+			if c.GetHeader("HX-Request") == "true" {
+				h.renderPartial(c, "error.html", nil)
+			} else {
+				h.renderPage(c, gin.H{"title": "系統錯誤"}, "error.html")
+			}
+			c.Abort()
+		}()
+		c.Next()
+	}
+}
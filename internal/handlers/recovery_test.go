@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// captureLogger records formatted log lines for assertions.
+type captureLogger struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (l *captureLogger) Infof(format string, args ...any)  { l.record(format, args...) }
+func (l *captureLogger) Errorf(format string, args ...any) { l.record(format, args...) }
+
+func (l *captureLogger) record(format string, args ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, fmt.Sprintf(format, args...))
+}
+
+func TestRecoveryMiddleware(t *testing.T) {
+	r, h := newTestRouter(t)
+	capture := &captureLogger{}
+	h.SetLogger(capture)
+	r.GET("/boom", h.TenantMiddleware(), func(c *gin.Context) {
+		var m map[string]int
+		m["x"]++ // A nil-map write, like the bugs this guards against
+	})
+
+	t.Run("Test full page", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, newTenantRequest(http.MethodGet, "/boom", nil))
+		if w.Code != http.StatusInternalServerError {
+			t.Fatalf("Expected status 500, but got %d", w.Code)
+		}
+		body := w.Body.String()
+		for _, want := range []string{"<html", "系統發生錯誤", `href="/"`, "回到首頁"} {
+			if !strings.Contains(body, want) {
+				t.Errorf("Expected the error page to contain %q, but got:\n%s", want, body)
+			}
+		}
+	})
+
+	t.Run("Test HTMX fragment", func(t *testing.T) {
+		req := newTenantRequest(http.MethodGet, "/boom", nil)
+		req.Header.Set("HX-Request", "true")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusInternalServerError {
+			t.Fatalf("Expected status 500, but got %d", w.Code)
+		}
+		if body := w.Body.String(); strings.Contains(body, "<html") || !strings.Contains(body, "回到首頁") {
+			t.Errorf("Expected only the error fragment, but got:\n%s", body)
+		}
+	})
+
+	capture.mu.Lock()
+	defer capture.mu.Unlock()
+	if len(capture.lines) == 0 {
+		t.Fatal("Expected the panic to be logged")
+	}
+	line := capture.lines[0]
+	for _, want := range []string{`tenant="` + testTenantID + `"`, "assignment to entry in nil map", "recovery_test.go"} {
+		if !strings.Contains(line, want) {
+			t.Errorf("Expected the log line to contain %q, but got:\n%s", want, line)
+		}
+	}
+}
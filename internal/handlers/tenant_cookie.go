@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newCookieSecret returns a random HMAC key. Cookies signed with it stop
+// verifying once the process restarts, so production should configure a secret.
+func newCookieSecret() []byte {
+	secret := make([]byte, 32)
+	rand.Read(secret)
+	return secret
+}
+
+// signTenantName returns the cookie value for a tenant name: the name followed
+// by "." and an HMAC-SHA256 of "tenant:" plus the name. The prefix keeps these
+// MACs apart from csrfTokenFor's, which use the same key; otherwise a tenant
+// named "csrf:<id>" would be handed the CSRF token of tenant <id>.
+func signTenantName(secret []byte, name string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte("tenant:" + name))
+	return name + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// verifyTenantName checks a value produced by signTenantName and returns the name.
+func verifyTenantName(secret []byte, value string) (string, bool) {
+	i := strings.LastIndexByte(value, '.')
+	if i <= 0 {
+		return "", false
+	}
+	name := value[:i]
+	if !hmac.Equal([]byte(signTenantName(secret, name)), []byte(value)) {
+		return "", false
+	}
+	return name, true
+}
+
+// SetCookieSecret replaces the key used to sign tenant cookies. It must be
+// called before routes are served; cookies signed with the old key become invalid.
+func (h *HTTPHandler) SetCookieSecret(secret []byte) {
+	h.cookieSecret = secret
+}
+
+// CookieOptions are the attributes of the tenant name and browser token cookies.
+type CookieOptions struct {
+	MaxAge   time.Duration // Lifetime of the tenant name cookie; 0 ends it with the browser session
+	Secure   *bool         // Forces the Secure flag on or off; nil sets it only for HTTPS requests
+	SameSite http.SameSite // SameSite=None is ignored by browsers unless the cookie is Secure
+}
+
+// DefaultCookieOptions keep the tenant name for a year, mark cookies Secure on
+// HTTPS requests and send them with top-level navigations only.
+var DefaultCookieOptions = CookieOptions{
+	MaxAge:   365 * 24 * time.Hour,
+	SameSite: http.SameSiteLaxMode,
+}
+
+// SetCookieOptions replaces DefaultCookieOptions. It must be called before
+// routes are served.
+func (h *HTTPHandler) SetCookieOptions(opts CookieOptions) {
+	h.cookieOpts = opts
+}
+
+// ParseSameSite maps "lax", "strict" or "none" to the SameSite attribute.
+func ParseSameSite(v string) (http.SameSite, error) {
+	switch strings.ToLower(v) {
+	case "lax":
+		return http.SameSiteLaxMode, nil
+	case "strict":
+		return http.SameSiteStrictMode, nil
+	case "none":
+		return http.SameSiteNoneMode, nil
+	}
+	return 0, fmt.Errorf("expected lax, strict or none, got %q", v)
+}
+
+// setCookie sends an HttpOnly cookie with the configured attributes. Like
+// gin's SetCookie it escapes the value, which c.Cookie undoes. A negative
+// maxAge deletes the cookie and zero makes it last for the browser session.
+func (h *HTTPHandler) setCookie(c *gin.Context, name, value string, maxAge int) {
+	secure := c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https"
+	if h.cookieOpts.Secure != nil {
+		secure = *h.cookieOpts.Secure
+	}
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     name,
+		Value:    url.QueryEscape(value),
+		Path:     "/",
+		MaxAge:   maxAge,
+		Secure:   secure,
+		HttpOnly: true,
+		SameSite: h.cookieOpts.SameSite,
+	})
+}
+
+// tenantName returns the tenant name from a correctly signed cookie. A missing
+// or tampered cookie reports false.
+func (h *HTTPHandler) tenantName(c *gin.Context) (string, bool) {
+	value, err := c.Cookie(tenantCookieName)
+	if err != nil {
+		return "", false
+	}
+	return verifyTenantName(h.cookieSecret, value)
+}
+
+// browserTokenCookieName holds the random per-browser token that identifies the
+// tenant when SetBrowserTokens is enabled.
+const browserTokenCookieName = "lottery_browser_token"
+
+// browserTokenMaxAge is how long a browser token cookie lives, in seconds.
+const browserTokenMaxAge = 3600 * 24 * 365
+
+// SetBrowserTokens switches tenant identification from the client IP to a random
+// token kept in a signed cookie, so browsers behind the same NAT no longer share
+// a session. It must be called before routes are served.
+func (h *HTTPHandler) SetBrowserTokens(enabled bool) {
+	h.browserTokens = enabled
+}
+
+// resolveTenantID returns the tenant ID for a request. By default it is the
+// tenant name combined with the client IP, falling back to an IP-based name when
+// no valid name cookie is present. With browser tokens the IP is replaced by the
+// browser's token, which is issued on first use.
+func (h *HTTPHandler) resolveTenantID(c *gin.Context) string {
+	tenantName, named := h.tenantName(c)
+	if !h.browserTokens {
+		if !named {
+			tenantName = fmt.Sprintf("user-%s", c.ClientIP())
+		}
+		return fmt.Sprintf("%s-%s", tenantName, c.ClientIP())
+	}
+
+	if !named {
+		tenantName = "user"
+	}
+	token, ok := h.browserToken(c)
+	if !ok {
+		token = h.issueBrowserToken(c)
+		if named {
+			// Carry over the session this name had while tenants were keyed by IP.
+			h.service.MoveSession(fmt.Sprintf("%s-%s", tenantName, c.ClientIP()), fmt.Sprintf("%s-%s", tenantName, token))
+		}
+	}
+	return fmt.Sprintf("%s-%s", tenantName, token)
+}
+
+// browserToken returns the token from a correctly signed browser token cookie.
+func (h *HTTPHandler) browserToken(c *gin.Context) (string, bool) {
+	value, err := c.Cookie(browserTokenCookieName)
+	if err != nil {
+		return "", false
+	}
+	return verifyTenantName(h.cookieSecret, value)
+}
+
+// issueBrowserToken generates a new browser token and sends it as a cookie.
+func (h *HTTPHandler) issueBrowserToken(c *gin.Context) string {
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	token := hex.EncodeToString(buf)
+	h.setCookie(c, browserTokenCookieName, signTenantName(h.cookieSecret, token), browserTokenMaxAge)
+	return token
+}
@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newWhoAmIRouter serves the tenant ID resolved by TenantMiddleware at /whoami.
+func newWhoAmIRouter(t *testing.T) (*gin.Engine, *HTTPHandler) {
+	t.Helper()
+	_, h := newTestRouter(t)
+	r := gin.New()
+	h.RegisterPublicRoutes(r)
+	r.Use(h.TenantMiddleware())
+	r.GET("/whoami", func(c *gin.Context) { c.String(http.StatusOK, c.GetString(tenantIDKey)) })
+	return r, h
+}
+
+func TestTenantMiddleware_SignedCookie(t *testing.T) {
+	r, _ := newWhoAmIRouter(t)
+	const fallbackID = "user-192.0.2.1-192.0.2.1"
+
+	tests := []struct {
+		name   string
+		cookie *http.Cookie
+		want   string
+	}{
+		{"valid signature", testTenantCookie(testTenantName), testTenantID},
+		{"unsigned name", &http.Cookie{Name: tenantCookieName, Value: "victim"}, fallbackID},
+		{"tampered name", &http.Cookie{Name: tenantCookieName, Value: "victim" + strings.TrimPrefix(testTenantCookie(testTenantName).Value, testTenantName)}, fallbackID},
+		{"wrong secret", &http.Cookie{Name: tenantCookieName, Value: signTenantName([]byte("other-secret"), testTenantName)}, fallbackID},
+		{"missing cookie", nil, fallbackID},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/whoami", nil)
+		if tt.cookie != nil {
+			req.AddCookie(tt.cookie)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if got := w.Body.String(); got != tt.want {
+			t.Errorf("%s: expected tenant %q, but got %q", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestSetTenant_IssuesSignedCookie(t *testing.T) {
+	r, _ := newWhoAmIRouter(t)
+
+	// The plain form sends the CSRF token of the fallback tenant it was rendered for.
+	values := url.Values{"tenantName": {"甲公司.台北"}, csrfFormField: {csrfTokenFor([]byte(testCookieSecret), "user-192.0.2.1-192.0.2.1")}}
+	form := httptest.NewRequest(http.MethodPost, "/set-tenant", strings.NewReader(values.Encode()))
+	form.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, form)
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("Expected one cookie to be set, but got %d", len(cookies))
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/whoami", nil)
+	req.AddCookie(cookies[0])
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if want := "甲公司.台北-192.0.2.1"; w.Body.String() != want {
+		t.Errorf("Expected the issued cookie to identify tenant %q, but got %q", want, w.Body.String())
+	}
+}
+
+func TestTenantCookie_Attributes(t *testing.T) {
+	forced := false
+	tests := []struct {
+		name  string
+		opts  CookieOptions
+		https bool
+		want  []string
+		not   []string
+	}{
+		{"default over http", DefaultCookieOptions, false, []string{"Max-Age=31536000", "HttpOnly", "SameSite=Lax"}, []string{"Secure"}},
+		{"default over https", DefaultCookieOptions, true, []string{"Max-Age=31536000", "Secure", "SameSite=Lax"}, nil},
+		{"configured", CookieOptions{MaxAge: 2 * time.Hour, SameSite: http.SameSiteStrictMode}, false, []string{"Max-Age=7200", "SameSite=Strict"}, []string{"Secure"}},
+		{"secure forced off", CookieOptions{MaxAge: time.Hour, Secure: &forced, SameSite: http.SameSiteLaxMode}, true, []string{"Max-Age=3600"}, []string{"Secure"}},
+		{"browser session", CookieOptions{SameSite: http.SameSiteLaxMode}, false, nil, []string{"Max-Age"}},
+	}
+	for _, tt := range tests {
+		r, h := newWhoAmIRouter(t)
+		h.SetCookieOptions(tt.opts)
+		check := func(action, header string, want []string) {
+			for _, attr := range want {
+				if !strings.Contains(header, attr) {
+					t.Errorf("%s: expected the %s cookie %q to contain %s", tt.name, action, header, attr)
+				}
+			}
+			for _, attr := range tt.not {
+				if strings.Contains(header, attr) {
+					t.Errorf("%s: expected the %s cookie %q not to contain %s", tt.name, action, header, attr)
+				}
+			}
+		}
+
+		values := url.Values{"tenantName": {"甲公司"}, csrfFormField: {csrfTokenFor([]byte(testCookieSecret), "user-192.0.2.1-192.0.2.1")}}
+		req := httptest.NewRequest(http.MethodPost, "/set-tenant", strings.NewReader(values.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if tt.https {
+			req.TLS = &tls.ConnectionState{}
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		check("set", w.Header().Get("Set-Cookie"), tt.want)
+
+		// Clearing must use the same attributes or the browser keeps the cookie.
+		req = httptest.NewRequest(http.MethodPost, "/clear-tenant", nil)
+		req.Header.Set(csrfHeaderName, csrfTokenFor([]byte(testCookieSecret), "user-192.0.2.1-192.0.2.1"))
+		if tt.https {
+			req.TLS = &tls.ConnectionState{}
+		}
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		cleared := w.Header().Get("Set-Cookie")
+		if !strings.Contains(cleared, "Max-Age=0") {
+			t.Errorf("%s: expected the clearing cookie %q to expire immediately", tt.name, cleared)
+		}
+		var kept []string
+		for _, attr := range tt.want {
+			if strings.HasPrefix(attr, "SameSite") || attr == "Secure" {
+				kept = append(kept, attr)
+			}
+		}
+		check("clear", strings.Replace(cleared, "Max-Age=0", "", 1), kept)
+	}
+}
+
+func TestParseSameSite(t *testing.T) {
+	for v, want := range map[string]http.SameSite{"lax": http.SameSiteLaxMode, "Strict": http.SameSiteStrictMode, "none": http.SameSiteNoneMode} {
+		if got, err := ParseSameSite(v); err != nil || got != want {
+			t.Errorf("ParseSameSite(%q): expected %v, but got %v, %v", v, want, got, err)
+		}
+	}
+	if _, err := ParseSameSite("sometimes"); err == nil {
+		t.Error("Expected an unknown SameSite value to be rejected")
+	}
+}
+
+// whoAmI requests /whoami with the given cookies and returns the tenant ID and
+// any cookies the response set.
+func whoAmI(t *testing.T, r *gin.Engine, cookies ...*http.Cookie) (string, []*http.Cookie) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/whoami", nil)
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w.Body.String(), w.Result().Cookies()
+}
+
+func TestTenantMiddleware_BrowserTokens(t *testing.T) {
+	r, h := newWhoAmIRouter(t)
+	h.SetBrowserTokens(true)
+
+	// Both clients come from httptest's default address 192.0.2.1.
+	first, firstCookies := whoAmI(t, r)
+	second, secondCookies := whoAmI(t, r)
+	if first == second {
+		t.Fatalf("Expected distinct tenants for two browsers on one IP, but both got %q", first)
+	}
+	if strings.Contains(first, "192.0.2.1") {
+		t.Errorf("Expected the tenant ID not to be keyed on IP, but got %q", first)
+	}
+	if len(firstCookies) != 1 || len(secondCookies) != 1 || !firstCookies[0].HttpOnly {
+		t.Fatalf("Expected one HttpOnly token cookie per browser, but got %v and %v", firstCookies, secondCookies)
+	}
+
+	// The token cookie keeps a browser on its own tenant.
+	if again, _ := whoAmI(t, r, firstCookies[0]); again != first {
+		t.Errorf("Expected the token cookie to keep tenant %q, but got %q", first, again)
+	}
+	forged := &http.Cookie{Name: browserTokenCookieName, Value: "forged.token"}
+	if forged, _ := whoAmI(t, r, forged); forged == first || forged == second {
+		t.Errorf("Expected a forged token to get a fresh tenant, but got %q", forged)
+	}
+}
+
+func TestTenantMiddleware_BrowserTokensMigrateNamedSession(t *testing.T) {
+	r, h := newWhoAmIRouter(t)
+	h.service.AddParticipant(testTenantID, "001", "Alice")
+	h.SetBrowserTokens(true)
+
+	tenantID, cookies := whoAmI(t, r, testTenantCookie(testTenantName))
+	if !strings.HasPrefix(tenantID, testTenantName+"-") || tenantID == testTenantID {
+		t.Fatalf("Expected a token-based tenant for %s, but got %q", testTenantName, tenantID)
+	}
+	if got := h.service.GetParticipants(tenantID); len(got) != 1 || got[0].ID != "001" {
+		t.Errorf("Expected the IP-keyed session to move to %q, but got %v", tenantID, got)
+	}
+
+	// Later requests with both cookies stay on the migrated session.
+	if again, _ := whoAmI(t, r, testTenantCookie(testTenantName), cookies[0]); again != tenantID {
+		t.Errorf("Expected tenant %q on the next request, but got %q", tenantID, again)
+	}
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// tenantClient sends requests as one browser: a fixed client address and,
+// optionally, a tenant cookie.
+type tenantClient struct {
+	remoteAddr string
+	cookie     *http.Cookie
+	tenantID   string // The ID the client resolves to, for its CSRF token
+}
+
+func (tc tenantClient) do(t *testing.T, r http.Handler, method, target, form string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, target, bytes.NewBufferString(form))
+	req.RemoteAddr = tc.remoteAddr
+	if form != "" {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	if tc.cookie != nil {
+		req.AddCookie(tc.cookie)
+	}
+	req.Header.Set(csrfHeaderName, csrfTokenFor([]byte(testCookieSecret), tc.tenantID))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestTenantIsolation(t *testing.T) {
+	r, h := newTestRouter(t)
+	const idA, idB = "TenantA-198.51.100.1", "TenantB-198.51.100.2"
+	tenantA := tenantClient{remoteAddr: "198.51.100.1:1234", cookie: testTenantCookie("TenantA"), tenantID: idA}
+	tenantB := tenantClient{remoteAddr: "198.51.100.2:1234", cookie: testTenantCookie("TenantB"), tenantID: idB}
+
+	tenantA.do(t, r, http.MethodPost, "/prizes", "prizeName=A獎&itemName=電視&quantity=1")
+	tenantA.do(t, r, http.MethodPost, "/participants", "participantID=001&participantName=Alice")
+	tenantB.do(t, r, http.MethodPost, "/prizes", "prizeName=B獎&itemName=手機&quantity=1")
+	tenantB.do(t, r, http.MethodPost, "/participants", "participantID=101&participantName=Bob")
+	if w := tenantA.do(t, r, http.MethodPost, "/draw/animation", "prizeName=A獎"); w.Code != http.StatusOK {
+		t.Fatalf("Expected TenantA's draw to succeed, but got %d: %s", w.Code, w.Body.String())
+	}
+	// TenantB cannot draw TenantA's prize, and its own draw only sees its roster.
+	if w := tenantB.do(t, r, http.MethodPost, "/draw/animation", "prizeName=A獎"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected TenantB not to find TenantA's prize, but got %d", w.Code)
+	}
+	tenantB.do(t, r, http.MethodPost, "/draw/animation", "prizeName=B獎")
+
+	for id, want := range map[string]struct{ prize, participant string }{idA: {"A獎", "001"}, idB: {"B獎", "101"}} {
+		prizes := h.service.GetPrizes(id)
+		participants := h.service.GetParticipants(id)
+		results := h.service.GetLotteryResults(id)
+		if len(prizes) != 1 || prizes[0].Name != want.prize {
+			t.Errorf("%s: expected only prize %s, but got %v", id, want.prize, prizes)
+		}
+		if len(participants) != 1 || participants[0].ID != want.participant {
+			t.Errorf("%s: expected only participant %s, but got %v", id, want.participant, participants)
+		}
+		if len(results) != 1 || results[0].WinnerID != want.participant {
+			t.Errorf("%s: expected only a win for %s, but got %v", id, want.participant, results)
+		}
+	}
+	if body := tenantB.do(t, r, http.MethodGet, "/export-results-csv", "").Body.String(); strings.Contains(body, "Alice") {
+		t.Errorf("Expected TenantB's export not to contain TenantA's winner, but got:\n%s", body)
+	}
+
+	tenantA.do(t, r, http.MethodPost, "/clear-tenant", "")
+	if len(h.service.GetPrizes(idA)) != 0 {
+		t.Errorf("Expected TenantA's session to be cleared")
+	}
+	if len(h.service.GetPrizes(idB)) != 1 || len(h.service.GetLotteryResults(idB)) != 1 {
+		t.Errorf("Expected clearing TenantA to leave TenantB intact")
+	}
+}
+
+func TestTenantIsolation_ForwardedForIsNotTrusted(t *testing.T) {
+	r, h := newTestRouter(t)
+	victim := tenantClient{remoteAddr: "198.51.100.1:1234", tenantID: "user-198.51.100.1-198.51.100.1"}
+	victim.do(t, r, http.MethodPost, "/prizes", "prizeName=頭獎&itemName=電視&quantity=1")
+
+	// Without a tenant cookie the tenant is derived from the client IP; a header
+	// claiming the victim's IP must not lead to the victim's session.
+	req := httptest.NewRequest(http.MethodGet, "/export-prizes-csv", nil)
+	req.RemoteAddr = "203.0.113.9:4321"
+	req.Header.Set("X-Forwarded-For", "198.51.100.1")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if strings.Contains(w.Body.String(), "頭獎") {
+		t.Errorf("Expected a spoofed X-Forwarded-For not to expose the victim's prizes, but got:\n%s", w.Body.String())
+	}
+	if len(h.service.GetPrizes("user-198.51.100.1-198.51.100.1")) != 1 {
+		t.Errorf("Expected the victim's own session to keep its prize")
+	}
+}
@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"encoding/json"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"lottery/internal/i18n"
+	"lottery/internal/services"
+)
+
+const (
+	wsWriteWait      = 10 * time.Second    // Time allowed to write a message to the peer
+	wsPongWait       = 60 * time.Second    // Time allowed to read the next pong from the peer
+	wsPingPeriod     = wsPongWait * 9 / 10 // Must be shorter than wsPongWait
+	wsMaxMessageSize = 4096                // Largest command accepted from a client
+	wsSendBuffer     = 64                  // Per-client queue of outgoing messages
+)
+
+// wsUpgrader rejects cross-origin handshakes (its default CheckOrigin), so
+// another site cannot drive a tenant's draws through the operator's cookies.
+var wsUpgrader = websocket.Upgrader{ReadBufferSize: 1024, WriteBufferSize: 1024}
+
+// wsCommand is a control message sent by a client.
+type wsCommand struct {
+	Type      string `json:"type"` // Only "draw" is supported
+	PrizeName string `json:"prizeName"`
+}
+
+// wsError is sent back to the client whose command failed.
+type wsError struct {
+	Type  string `json:"type"` // Always "error"
+	Error string `json:"error"`
+}
+
+// wsClient is one WebSocket connection. The hub writes to send; writePump
+// drains it to the connection.
+type wsClient struct {
+	hub  *wsHub
+	conn *websocket.Conn
+	send chan []byte
+}
+
+// wsReply is a message addressed to one client of a hub.
+type wsReply struct {
+	client  *wsClient
+	message []byte
+}
+
+// wsHub relays one tenant's live events to its WebSocket clients, in the
+// register/unregister/broadcast shape of gorilla/websocket's chat example.
+type wsHub struct {
+	clients    map[*wsClient]bool
+	register   chan *wsClient
+	unregister chan *wsClient
+	reply      chan wsReply  // Messages for a single client, e.g. a failed command
+	done       chan struct{} // Closed by wsHubs.leave once the last client is gone
+
+	refs int // Clients that joined and have not left yet; guarded by wsHubs.mu
+}
+
+// run owns the client set. It forwards every service event to all clients and
+// stops once done is closed.
+func (hub *wsHub) run(events <-chan []services.LotteryEvent, unsubscribe func()) {
+	defer unsubscribe()
+	for {
+		select {
+		case client := <-hub.register:
+			hub.clients[client] = true
+		case client := <-hub.unregister:
+			if hub.clients[client] {
+				delete(hub.clients, client)
+				close(client.send)
+			}
+		case r := <-hub.reply:
+			if hub.clients[r.client] {
+				select {
+				case r.client.send <- r.message:
+				default:
+				}
+			}
+		case batch := <-events:
+			for _, event := range batch {
+				message, err := json.Marshal(event)
+				if err != nil {
+					continue
+				}
+				for client := range hub.clients {
+					select {
+					case client.send <- message:
+					default: // A client this far behind is dropped rather than stalling the rest
+						delete(hub.clients, client)
+						close(client.send)
+					}
+				}
+			}
+		case <-hub.done:
+			return
+		}
+	}
+}
+
+// wsHubs keeps one running hub per tenant with connected clients.
+type wsHubs struct {
+	mu   sync.Mutex
+	hubs map[string]*wsHub // Key: tenantID
+}
+
+func newWSHubs() *wsHubs {
+	return &wsHubs{hubs: make(map[string]*wsHub)}
+}
+
+// join adds a client to the tenant's hub, starting the hub if it is the first.
+func (r *wsHubs) join(service *services.LotteryService, tenantID string, conn *websocket.Conn) *wsClient {
+	r.mu.Lock()
+	hub, ok := r.hubs[tenantID]
+	if !ok {
+		hub = &wsHub{
+			clients:    make(map[*wsClient]bool),
+			register:   make(chan *wsClient),
+			unregister: make(chan *wsClient),
+			reply:      make(chan wsReply),
+			done:       make(chan struct{}),
+		}
+		r.hubs[tenantID] = hub
+		events, unsubscribe := service.Subscribe(tenantID, liveFeedCoalesce)
+		go hub.run(events, unsubscribe)
+	}
+	hub.refs++
+	r.mu.Unlock()
+
+	client := &wsClient{hub: hub, conn: conn, send: make(chan []byte, wsSendBuffer)}
+	hub.register <- client
+	return client
+}
+
+// leave removes a client and stops its hub once no clients remain.
+func (r *wsHubs) leave(tenantID string, client *wsClient) {
+	hub := client.hub
+	hub.unregister <- client
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if hub.refs--; hub.refs == 0 {
+		delete(r.hubs, tenantID)
+		close(hub.done)
+	}
+}
+
+// ServeWebSocket upgrades the request to the tenant's live draw control channel.
+// Clients receive every services.LotteryEvent of the tenant as JSON and may
+// send {"type":"draw","prizeName":"..."} to draw one winner; the result reaches
+// every client as a "draw" event, and a failed draw is answered with an
+// "error" message to the sender only. Draw commands spend the same per-tenant
+// allowance as the HTTP draw routes.
+func (h *HTTPHandler) ServeWebSocket(c *gin.Context) {
+	tenantID := c.GetString(tenantIDKey)
+	conn, err := wsUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		h.logFor(c).Infof("WebSocket upgrade failed: %v", err)
+		return // The upgrader has already written the error response
+	}
+
+	client := h.wsHubs.join(h.service, tenantID, conn)
+	go client.writePump()
+	h.readPump(c, tenantID, client)
+}
+
+// readPump handles commands from the client until the connection fails, then
+// unregisters it, which also ends writePump.
+func (h *HTTPHandler) readPump(c *gin.Context, tenantID string, client *wsClient) {
+	defer h.wsHubs.leave(tenantID, client)
+
+	client.conn.SetReadLimit(wsMaxMessageSize)
+	client.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	client.conn.SetPongHandler(func(string) error {
+		return client.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+
+	for {
+		var cmd wsCommand
+		if err := client.conn.ReadJSON(&cmd); err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+				h.logFor(c).Infof("WebSocket closed: %v", err)
+			}
+			return
+		}
+
+		var cmdErr error
+		switch cmd.Type {
+		case "draw":
+			if ok, wait := h.drawLimiter.allow(tenantID); !ok {
+				cmdErr = i18n.NewError("rate_limited", int(math.Ceil(wait.Seconds())))
+				break
+			}
+			_, cmdErr = h.service.Draw(tenantID, cmd.PrizeName)
+		default:
+			cmdErr = errUnknownWSCommand
+		}
+		if cmdErr != nil {
+			message, _ := json.Marshal(wsError{Type: "error", Error: h.localize(c, cmdErr)})
+			client.hub.reply <- wsReply{client, message}
+		}
+	}
+}
+
+// errUnknownWSCommand answers a command type the channel does not support.
+var errUnknownWSCommand = i18n.NewError("ws_unknown_command")
+
+// writePump writes queued messages and keep-alive pings to the connection. It
+// closes the connection once the hub closes send.
+func (client *wsClient) writePump() {
+	ticker := time.NewTicker(wsPingPeriod)
+	defer func() {
+		ticker.Stop()
+		client.conn.Close()
+	}()
+	for {
+		select {
+		case message, ok := <-client.send:
+			client.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if !ok {
+				client.conn.WriteMessage(websocket.CloseMessage, []byte{})
+				return
+			}
+			if err := client.conn.WriteMessage(websocket.TextMessage, message); err != nil {
+				return
+			}
+		case <-ticker.C:
+			client.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := client.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"lottery/internal/services"
+)
+
+// dialWS opens the test tenant's control channel on server.
+func dialWS(t *testing.T, server *httptest.Server) *websocket.Conn {
+	t.Helper()
+	header := http.Header{}
+	header.Set("Cookie", testTenantCookie(testTenantName).String())
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws", header)
+	if err != nil {
+		t.Fatalf("Failed to open WebSocket: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// readWSMessage reads messages until one of the wanted type arrives and returns it raw.
+func readWSMessage(t *testing.T, conn *websocket.Conn, wantType string) []byte {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("Expected a %q message, but reading failed: %v", wantType, err)
+		}
+		var message struct{ Type string }
+		if json.Unmarshal(data, &message) == nil && message.Type == wantType {
+			return data
+		}
+	}
+}
+
+func TestServeWebSocket_DrawCommand(t *testing.T) {
+	r, h := newTestRouter(t)
+	server := httptest.NewServer(r)
+	defer server.Close()
+	const tenantID = testTenantName + "-127.0.0.1" // Requests arrive from the loopback test server
+
+	h.service.AddPrize(tenantID, "頭獎", "電視", 1, false)
+	h.service.AddParticipant(tenantID, "001", "Alice")
+
+	operator := dialWS(t, server)
+	screen := dialWS(t, server)
+	// The screen's first command is only read once its client is registered,
+	// so an answered command proves both clients are in the hub.
+	screen.WriteJSON(wsCommand{Type: "ping"})
+	readWSMessage(t, screen, "error")
+
+	if err := operator.WriteJSON(wsCommand{Type: "draw", PrizeName: "頭獎"}); err != nil {
+		t.Fatalf("Failed to send draw command: %v", err)
+	}
+	for name, conn := range map[string]*websocket.Conn{"operator": operator, "screen": screen} {
+		var event services.LotteryEvent
+		json.Unmarshal(readWSMessage(t, conn, services.EventDraw), &event)
+		if len(event.Results) != 1 || event.Results[0].WinnerID != "001" {
+			t.Errorf("%s: expected a draw event for participant 001, but got %+v", name, event)
+		}
+	}
+
+	// A failed draw is reported to the sender.
+	operator.WriteJSON(wsCommand{Type: "draw", PrizeName: "頭獎"})
+	var reply wsError
+	json.Unmarshal(readWSMessage(t, operator, "error"), &reply)
+	if !strings.Contains(reply.Error, "該獎項已被抽完") {
+		t.Errorf("Expected an exhausted-prize error, but got %+v", reply)
+	}
+}
+
+func TestServeWebSocket_DrawCommandRateLimited(t *testing.T) {
+	r, h := newTestRouter(t)
+	server := httptest.NewServer(r)
+	defer server.Close()
+	const tenantID = testTenantName + "-127.0.0.1"
+
+	h.service.AddPrize(tenantID, "參加獎", "紅包", defaultDrawBurst+1, false)
+	for i := 0; i <= defaultDrawBurst; i++ {
+		h.service.AddParticipant(tenantID, fmt.Sprintf("%03d", i), "P")
+	}
+
+	operator := dialWS(t, server)
+	for i := 0; i <= defaultDrawBurst; i++ {
+		operator.WriteJSON(wsCommand{Type: "draw", PrizeName: "參加獎"})
+	}
+	var reply wsError
+	json.Unmarshal(readWSMessage(t, operator, "error"), &reply)
+	if !strings.Contains(reply.Error, "操作過於頻繁") {
+		t.Errorf("Expected the draw past the burst to be rate limited, but got %+v", reply)
+	}
+	if n := len(h.service.GetLotteryResults(tenantID)); n != defaultDrawBurst {
+		t.Errorf("Expected only %d draws within the burst, but got %d", defaultDrawBurst, n)
+	}
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+	"lottery/internal/i18n"
+)
+
+func TestExportResultsXLSX(t *testing.T) {
+	r, h := newTestRouter(t)
+	h.service.AddPrize(testTenantID, "頭獎", "電視", 1, false)
+	h.service.AddParticipant(testTenantID, "001", "Alice")
+
+	winner, err := h.service.Draw(testTenantID, "頭獎")
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newTenantRequest(http.MethodGet, "/export-results-xlsx", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d: %s", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("Content-Type"); got != xlsxContentType {
+		t.Errorf("Expected content type %q, but got %q", xlsxContentType, got)
+	}
+	if got := w.Header().Get("Content-Disposition"); got != "attachment;filename=lottery_results.xlsx" {
+		t.Errorf("Unexpected Content-Disposition %q", got)
+	}
+
+	f, err := excelize.OpenReader(bytes.NewReader(w.Body.Bytes()))
+	if err != nil {
+		t.Fatalf("Failed to open XLSX response: %v", err)
+	}
+	defer f.Close()
+
+	rows, err := f.GetRows(resultsSheet)
+	if err != nil {
+		t.Fatalf("Failed to read sheet %s: %v", resultsSheet, err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("Expected header and 1 winner row, but got %d rows: %v", len(rows), rows)
+	}
+	if header := resultsExportHeader(i18n.Default); !slices.Equal(rows[0], header) {
+		t.Errorf("Expected header %v, but got %v", header, rows[0])
+	}
+	if rows[1][0] != "頭獎" || rows[1][1] != winner.WinnerID || rows[1][2] != "Alice" || rows[1][3] != "電視" {
+		t.Errorf("Unexpected winner row %v", rows[1])
+	}
+	if want := winner.DrawnAt.Local().Format(csvTimeLayout); rows[1][4] != want {
+		t.Errorf("Expected draw time %q, but got %q", want, rows[1][4])
+	}
+	if rows[1][5] != "未領取" {
+		t.Errorf("Expected claim status 未領取, but got %q", rows[1][5])
+	}
+
+	styleID, err := f.GetCellStyle(resultsSheet, "A1")
+	if err != nil {
+		t.Fatalf("Failed to read header style: %v", err)
+	}
+	style, err := f.GetStyle(styleID)
+	if err != nil || style.Font == nil || !style.Font.Bold {
+		t.Errorf("Expected a bold header row, but got style %+v (err %v)", style, err)
+	}
+}
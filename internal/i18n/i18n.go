@@ -0,0 +1,116 @@
+// Package i18n holds the message catalog used for the user-facing texts of the
+// service and the handlers, in Traditional Chinese and English.
+package i18n
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Lang identifies a message bundle.
+type Lang string
+
+const (
+	ZhTW Lang = "zh-TW" // The default, and the language every message exists in
+	En   Lang = "en"
+)
+
+// Default is the language used when none is requested, and the one Error.Error uses.
+const Default = ZhTW
+
+// T formats the message id in lang with fmt.Sprintf. Messages missing from
+// lang fall back to Default; unknown IDs are returned as they are.
+func T(lang Lang, id string, args ...any) string {
+	format, ok := catalog[lang][id]
+	if !ok {
+		if format, ok = catalog[Default][id]; !ok {
+			return id
+		}
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// Error is an error whose text is a catalog message, so it can be shown in the
+// reader's language with Localize. Args that are errors are localized too, and
+// the first of them is what Unwrap returns, so errors.Is sees through wrapping.
+type Error struct {
+	ID   string
+	Args []any
+}
+
+// NewError returns an *Error for the message id with the given arguments.
+func NewError(id string, args ...any) *Error {
+	return &Error{ID: id, Args: args}
+}
+
+// Error returns the message in Default, so logs and existing callers read as before.
+func (e *Error) Error() string {
+	return e.localize(Default)
+}
+
+// Unwrap returns the first argument that is an error, if any.
+func (e *Error) Unwrap() error {
+	for _, arg := range e.Args {
+		if err, ok := arg.(error); ok {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *Error) localize(lang Lang) string {
+	args := make([]any, len(e.Args))
+	for i, arg := range e.Args {
+		if err, ok := arg.(error); ok {
+			arg = Localize(lang, err)
+		}
+		args[i] = arg
+	}
+	return T(lang, e.ID, args...)
+}
+
+// Localize returns err's message in lang if it is an *Error, and err.Error()
+// otherwise. Only the outermost error is inspected: an error that wraps an
+// *Error with fmt.Errorf keeps its own, untranslated text.
+func Localize(lang Lang, err error) string {
+	if e, ok := err.(*Error); ok {
+		return e.localize(lang)
+	}
+	return err.Error()
+}
+
+// Parse returns the supported language matching a language tag such as
+// "en-US" or "zh-Hant-TW", and false if there is none.
+func Parse(tag string) (Lang, bool) {
+	primary, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+	switch primary {
+	case "zh":
+		return ZhTW, true
+	case "en":
+		return En, true
+	}
+	return "", false
+}
+
+// Negotiate picks the language for a request: an explicit choice such as the
+// "lang" cookie wins, then the first supported language in the Accept-Language
+// header, in the client's order of preference, then Default.
+func Negotiate(choice, acceptLanguage string) Lang {
+	if lang, ok := Parse(choice); ok {
+		return lang
+	}
+	best, bestQ := Default, 0.0
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if _, err := fmt.Sscanf(v, "%g", &q); err != nil {
+				continue
+			}
+		}
+		if lang, ok := Parse(tag); ok && q > bestQ {
+			best, bestQ = lang, q
+		}
+	}
+	return best
+}
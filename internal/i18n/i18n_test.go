@@ -0,0 +1,56 @@
+package i18n
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestT(t *testing.T) {
+	if got, want := T(En, "prize_option", "頭獎", 2), "頭獎 (left: 2)"; got != want {
+		t.Errorf("Expected %q, but got %q", want, got)
+	}
+	if got, want := T(Lang("fr"), "claimed"), "已領取"; got != want {
+		t.Errorf("Expected an unknown language to fall back to %q, but got %q", want, got)
+	}
+	if got := T(En, "no_such_message"); got != "no_such_message" {
+		t.Errorf("Expected an unknown ID to be returned as it is, but got %q", got)
+	}
+}
+
+func TestLocalize(t *testing.T) {
+	notFound := NewError("prize_not_found")
+	err := NewError(WithDetail, notFound, "頭獎")
+
+	if !errors.Is(err, notFound) {
+		t.Errorf("Expected the detailed error to wrap %v", notFound)
+	}
+	if got, want := err.Error(), "指定的獎項不存在：頭獎"; got != want {
+		t.Errorf("Expected Error() in the default language %q, but got %q", want, got)
+	}
+	if got, want := Localize(En, err), "The prize does not exist: 頭獎"; got != want {
+		t.Errorf("Expected %q, but got %q", want, got)
+	}
+	if got, want := Localize(En, errors.New("plain")), "plain"; got != want {
+		t.Errorf("Expected a plain error to keep its text %q, but got %q", want, got)
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	for _, tc := range []struct {
+		choice, accept string
+		want           Lang
+	}{
+		{"", "", Default},
+		{"", "en-US,en;q=0.9", En},
+		{"", "fr-FR, zh-TW;q=0.8, en;q=0.5", ZhTW},
+		{"", "zh;q=0.3, en;q=0.7", En},
+		{"", "fr, de", Default},
+		{"zh-TW", "en", ZhTW},
+		{"en", "zh-TW", En},
+		{"xx", "en", En},
+	} {
+		if got := Negotiate(tc.choice, tc.accept); got != tc.want {
+			t.Errorf("Negotiate(%q, %q): expected %q, but got %q", tc.choice, tc.accept, tc.want, got)
+		}
+	}
+}
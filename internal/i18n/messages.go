@@ -0,0 +1,249 @@
+package i18n
+
+// Message IDs shared by several packages.
+const (
+	// WithDetail appends a detail, such as the prize name, to a wrapped error:
+	// NewError(WithDetail, ErrPrizeNotFound, name).
+	WithDetail = "with_detail"
+)
+
+// catalog maps each language to its messages, keyed by message ID. Formats
+// are fmt verbs; an error argument is passed already localized, as a string.
+var catalog = map[Lang]map[string]string{
+	ZhTW: {
+		WithDetail: "%s：%v",
+
+		// Prizes
+		"prize_not_found":        "指定的獎項不存在",
+		"prize_exhausted":        "該獎項已被抽完",
+		"prize_duplicate":        "此獎項名稱已存在",
+		"prize_invalid_quantity": "獎項數量必須大於 0",
+		"prize_quantity_input":   "%s（輸入為 %d）",
+		"prize_name_empty":       "獎項名稱不可為空",
+		"prize_image_url":        "圖片網址 %q 必須是 http(s) 連結或站內路徑",
+		"prize_items_short":      "獎品清單只有 %d 項，少於數量 %d",
+		"prize_max_winners":      "得獎人數上限不可為負數",
+		"prize_below_awarded":    "數量不可少於已抽出的 %d 份",
+		"prize_order_unknown":    "獎項 %q 不存在",
+		"prize_order_repeated":   "獎項 %q 重複出現",
+		"prize_all_remaining":    "此獎項會一次頒給所有合格者，請使用批次抽獎",
+		"setup_over_pool":        "獎項%s數量(%d)超過合格人數(%d)",
+
+		// Participants
+		"participant_duplicate":     "此員工編號已存在",
+		"participant_not_found":     "指定的參與者不存在",
+		"participant_id_empty":      "員工編號不可為空",
+		"participant_id_invalid":    "員工編號 %q 不可包含逗號或換行",
+		"participant_merge_self":    "無法將參與者與自己合併",
+		"participants_clear_locked": "已有鎖定的抽獎結果，無法連同結果一併清除",
+		"participants_cleared_all":  "已清除 %d 位參與者及所有抽獎結果",
+		"participants_cleared":      "已清除 %d 位參與者，已中獎者保留",
+		"import_summary":            "匯入 %d 筆，略過 %d 筆重複、%d 筆格式錯誤",
+		"import_summary_upsert":     "新增 %d 筆，更新 %d 筆，略過 %d 筆格式錯誤",
+
+		// Draws
+		"no_participants":          "尚未匯入參與者",
+		"no_eligible_participants": "沒有符合資格的參與者可供抽獎",
+		"min_participants":         "參與者人數不足：目前 %d 人，至少需要 %d 人才能抽獎",
+		"draw_count_invalid":       "抽獎數量必須大於 0",
+		"draw_shortfall":           "僅抽出 %d 位中獎者（要求 %d 位）：獎項剩餘數量或合格人數不足",
+		"draw_no_prizes":           "請至少選擇一個獎項",
+		"draw_group_too_large":     "無法同時抽出 %d 位中獎者：獎項剩餘 %d 份，合格人數 %d 位",
+		"draw_transaction_failed":  "獎項「%s」無法抽出，本次抽獎已全部取消：%s",
+		"reserve_all_remaining":    "此獎項會一次頒給所有合格者，無法預留",
+		"reserve_duplicate":        "此參與者已預留該獎項",
+		"reserve_full":             "預留人數已達獎項剩餘數量",
+		"draw_all_summary":         "共抽出 %d 位中獎者",
+		"draw_all_left":            "；合格人數不足，尚未抽出：%s",
+		"draw_all_left_prize":      "%s %d 份",
+		"list_separator":           "、",
+
+		// Results
+		"result_locked":        "該抽獎結果已鎖定，無法變更",
+		"result_not_found":     "指定的抽獎結果不存在",
+		"win_not_found":        "找不到該中獎紀錄",
+		"nothing_to_undo":      "沒有可撤銷的抽獎結果",
+		"batch_not_found":      "指定的批次不存在",
+		"ws_unknown_command":   "不支援的指令",
+		"rate_limited":         "操作過於頻繁，請於 %d 秒後再試",
+		"stream_all_drawn":     "獎項已全部抽出",
+		"claimed":              "已領取",
+		"unclaimed":            "未領取",
+		"col_prize_name":       "獎項名稱",
+		"col_winner_id":        "員工編號",
+		"col_winner_name":      "員工姓名",
+		"col_prize_item":       "獎品名稱",
+		"col_drawn_at":         "抽出時間",
+		"col_claim_status":     "領取狀態",
+		"col_claimed_at":       "領取時間",
+		"select_prize":         "-- 請選擇 --",
+		"prize_option":         "%s (剩餘: %d)",
+		"announcement":         "{{.PrizeItem}}({{.PrizeName}})獎項的中獎人是{{.WinnerName}}(員編{{.WinnerID}})",
+		"announcement_long":    "公告範本不可超過 %d 個字元",
+		"announcement_bad":     "公告範本無效：%v",
+		"announcement_large":   "公告內容過長",
+		"announcement_control": "公告範本只能使用欄位與 if，不可使用 range、with 或 template",
+
+		"csrf_invalid": "請求已失效，請重新整理頁面後再試一次",
+
+		// Uploads
+		"upload_missing_file":     "請選擇 %s 檔案",
+		"upload_not_multipart":    "請透過表單選擇要上傳的檔案",
+		"upload_unreadable":       "無法讀取上傳的檔案",
+		"upload_too_large":        "檔案過大，上限為 %d KB",
+		"upload_too_many_rows":    "資料列過多，上限為 %d 列",
+		"row_columns":             "應為 2 到 4 欄，實際為 %d 欄",
+		"row_weight":              "權重 %q 無效",
+		"row_error":               "第 %d 列：%s",
+		"row_error_named":         "第 %d 列：%s（%s）",
+		"prize_row_columns":       "欄位數應為 4 至 %d，實際為 %d",
+		"prize_row_quantity":      "數量 %q 不是整數",
+		"prize_row_draw_from_all": "從全體抽取 %q 應為 true 或 false",
+		"prize_row_all_remaining": "全數頒發 %q 應為 true 或 false",
+		"prize_row_max_winners":   "得獎人數上限 %q 不是整數",
+
+		// Session snapshots
+		"snapshot_unreadable":            "無法解析備份檔: %v",
+		"snapshot_negative":              "獎項 %q 的數量不可為負數",
+		"snapshot_prize_repeated":        "獎項名稱 %q 重複",
+		"snapshot_participant_dup":       "員工編號 %q 重複",
+		"snapshot_unknown_winner":        "中獎紀錄參照了不存在的參與者 %q",
+		"snapshot_result_unknown_winner": "抽獎結果 %q 的得獎者 %q 不在參與者名單中",
+		"snapshot_result_unknown_prize":  "抽獎結果 %q 的獎項 %q 不存在",
+		"restore_failed":                 "還原失敗：%s",
+		"restore_done":                   "還原完成。",
+
+		// Eligibility explanations
+		"eligibility_ok":                "符合抽獎資格",
+		"eligibility_absent":            "未出席",
+		"eligibility_voided":            "曾因缺席被取消中獎，不再參與抽獎",
+		"eligibility_group":             "不在本獎項指定群組 %q",
+		"eligibility_max_wins":          "已達每人得獎上限 %d 次",
+		"eligibility_won_prize":         "已抽中本獎項",
+		"eligibility_won_any":           "已中過獎，本獎項只從未中獎者中抽出",
+		"eligibility_unique_across_all": "已中過獎，且已開啟全場不重複得獎",
+		"eligibility_excluded_prize":    "已抽中 %q，該獎項得主不可再抽本獎項",
+		"eligibility_max_winners":       "本獎項得獎人數已達上限 %d 人",
+		"eligibility_max_winners_more":  "已持有較多份本獎項，需等其他得主抽到相同份數",
+		"eligibility_consecutive":       "剛抽中上一次抽獎，本次暫停",
+
+		// Cloning
+		"clone_source_missing":   "來源租戶不存在",
+		"clone_target_not_empty": "目標租戶已有資料，請先清除",
+	},
+	En: {
+		WithDetail: "%s: %v",
+
+		"prize_not_found":        "The prize does not exist",
+		"prize_exhausted":        "The prize has been fully drawn",
+		"prize_duplicate":        "A prize with this name already exists",
+		"prize_invalid_quantity": "The prize quantity must be greater than 0",
+		"prize_quantity_input":   "%s (got %d)",
+		"prize_name_empty":       "The prize name must not be empty",
+		"prize_image_url":        "Image URL %q must be an http(s) link or a path on this site",
+		"prize_items_short":      "The item list has only %d entries, fewer than the quantity %d",
+		"prize_max_winners":      "The winner limit cannot be negative",
+		"prize_below_awarded":    "The quantity cannot be less than the %d already drawn",
+		"prize_order_unknown":    "Prize %q does not exist",
+		"prize_order_repeated":   "Prize %q is listed more than once",
+		"prize_all_remaining":    "This prize goes to everyone eligible at once; use a batch draw",
+		"setup_over_pool":        "Prize %s has more units (%d) than eligible participants (%d)",
+
+		"participant_duplicate":     "This employee ID already exists",
+		"participant_not_found":     "The participant does not exist",
+		"participant_id_empty":      "The employee ID must not be empty",
+		"participant_id_invalid":    "Employee ID %q must not contain commas or line breaks",
+		"participant_merge_self":    "A participant cannot be merged with themselves",
+		"participants_clear_locked": "Some results are locked, so they cannot be cleared along with the participants",
+		"participants_cleared_all":  "Cleared %d participants and all results",
+		"participants_cleared":      "Cleared %d participants; winners were kept",
+		"import_summary":            "Imported %d; skipped %d duplicates and %d malformed rows",
+		"import_summary_upsert":     "Added %d, updated %d, skipped %d malformed rows",
+
+		"no_participants":          "No participants have been imported yet",
+		"no_eligible_participants": "No participants are eligible for this draw",
+		"min_participants":         "Not enough participants: %d now, at least %d needed to draw",
+		"draw_count_invalid":       "The number of winners must be greater than 0",
+		"draw_shortfall":           "Only %d winners drawn (%d requested): not enough units left or eligible participants",
+		"draw_no_prizes":           "Select at least one prize",
+		"draw_group_too_large":     "Cannot draw %d winners together: %d units left, %d participants eligible",
+		"draw_transaction_failed":  "Prize %q could not be drawn, so the whole draw was cancelled: %s",
+		"reserve_all_remaining":    "This prize goes to everyone eligible at once and cannot be reserved",
+		"reserve_duplicate":        "This participant already has a reservation for the prize",
+		"reserve_full":             "The prize has as many reservations as units left",
+		"draw_all_summary":         "Drew %d winners",
+		"draw_all_left":            "; not enough eligible participants for: %s",
+		"draw_all_left_prize":      "%s (%d left)",
+		"list_separator":           ", ",
+
+		"result_locked":        "The result is locked and cannot be changed",
+		"result_not_found":     "The result does not exist",
+		"win_not_found":        "No such win was found",
+		"nothing_to_undo":      "There is no draw to undo",
+		"batch_not_found":      "The batch does not exist",
+		"ws_unknown_command":   "Unsupported command",
+		"rate_limited":         "Too many requests; try again in %d seconds",
+		"stream_all_drawn":     "All of the prize has been drawn",
+		"claimed":              "Claimed",
+		"unclaimed":            "Unclaimed",
+		"col_prize_name":       "Prize",
+		"col_winner_id":        "Employee ID",
+		"col_winner_name":      "Employee name",
+		"col_prize_item":       "Item",
+		"col_drawn_at":         "Drawn at",
+		"col_claim_status":     "Claim status",
+		"col_claimed_at":       "Claimed at",
+		"select_prize":         "-- Select --",
+		"prize_option":         "%s (left: %d)",
+		"announcement":         "The winner of {{.PrizeItem}} ({{.PrizeName}}) is {{.WinnerName}} (ID {{.WinnerID}})",
+		"announcement_long":    "The announcement template must not exceed %d characters",
+		"announcement_bad":     "Invalid announcement template: %v",
+		"announcement_large":   "The announcement is too long",
+		"announcement_control": "Announcement templates may only use fields and if, not range, with or template",
+
+		"csrf_invalid": "The request has expired; reload the page and try again",
+
+		"upload_missing_file":     "Please choose a %s file",
+		"upload_not_multipart":    "Please choose the file to upload through the form",
+		"upload_unreadable":       "The uploaded file could not be read",
+		"upload_too_large":        "The file is too large; the limit is %d KB",
+		"upload_too_many_rows":    "Too many rows; the limit is %d",
+		"row_columns":             "Expected 2 to 4 columns, got %d",
+		"row_weight":              "Invalid weight %q",
+		"row_error":               "Row %d: %s",
+		"row_error_named":         "Row %d: %s (%s)",
+		"prize_row_columns":       "Expected 4 to %d columns, got %d",
+		"prize_row_quantity":      "Quantity %q is not a whole number",
+		"prize_row_draw_from_all": "Draw from all %q should be true or false",
+		"prize_row_all_remaining": "Award to all %q should be true or false",
+		"prize_row_max_winners":   "Winner limit %q is not a whole number",
+
+		"snapshot_unreadable":            "The backup file cannot be read: %v",
+		"snapshot_negative":              "Prize %q has a negative quantity",
+		"snapshot_prize_repeated":        "Prize name %q appears more than once",
+		"snapshot_participant_dup":       "Employee ID %q appears more than once",
+		"snapshot_unknown_winner":        "A win refers to participant %q, who does not exist",
+		"snapshot_result_unknown_winner": "Result %q names winner %q, who is not on the roster",
+		"snapshot_result_unknown_prize":  "Result %q names prize %q, which does not exist",
+		"restore_failed":                 "Restore failed: %s",
+		"restore_done":                   "Restore complete.",
+
+		// Eligibility explanations
+		"eligibility_ok":                "Eligible",
+		"eligibility_absent":            "Marked absent",
+		"eligibility_voided":            "A win was voided for absence, so they are no longer drawn",
+		"eligibility_group":             "Not in the prize's group %q",
+		"eligibility_max_wins":          "Has reached the limit of %d wins per person",
+		"eligibility_won_prize":         "Has already won this prize",
+		"eligibility_won_any":           "Has already won, and this prize only draws from non-winners",
+		"eligibility_unique_across_all": "Has already won, and wins are unique across all prizes",
+		"eligibility_excluded_prize":    "Won %q, whose winners may not win this prize",
+		"eligibility_max_winners":       "The prize has reached its limit of %d winners",
+		"eligibility_max_winners_more":  "Holds more units of this prize than its other winners",
+		"eligibility_consecutive":       "Won the previous draw, so sits this one out",
+
+		// Cloning
+		"clone_source_missing":   "The source tenant does not exist",
+		"clone_target_not_empty": "The target tenant already has data; clear it first",
+	},
+}
@@ -1,27 +1,85 @@
 package models
 
+import (
+	"encoding/json"
+	"time"
+)
+
 // Prize represents a single prize category in the lottery.
 // It includes the name of the prize, the specific item, the total quantity,
 // and a flag to determine the pool of participants for this prize.
 type Prize struct {
-	Name        string `json:"name"`
-	Item        string `json:"item"`
-	Quantity    int    `json:"quantity"`
-	DrawFromAll bool   `json:"drawFromAll"` // true: draw from all participants; false: draw from non-winners only
+	Name             string   `json:"name"`
+	Item             string   `json:"item"`
+	Items            []string `json:"items,omitempty"`            // Distinct items handed out one per draw, in order; Item labels draws once all are taken
+	Quantity         int      `json:"quantity"`                   // Remaining units; decremented on every draw
+	OriginalQuantity int      `json:"originalQuantity"`           // Configured total, restored by ResetResults
+	DrawFromAll      bool     `json:"drawFromAll"`                // true: draw from all participants (each may win this prize once); false: draw from non-winners only
+	AllRemaining     bool     `json:"allRemaining"`               // true: a batch draw awards every eligible participant, regardless of Quantity
+	Group            string   `json:"group,omitempty"`            // Non-empty: only participants of this group may win
+	Order            int      `json:"order"`                      // Display position; GetPrizes sorts by it
+	ExcludeWinnersOf []string `json:"excludeWinnersOf,omitempty"` // Names of prizes whose winners may not win this one
+	ImageURL         string   `json:"imageUrl,omitempty"`         // Photo shown when a winner is announced; http(s) URL or path on this server
+	MaxWinners       int      `json:"maxWinners,omitempty"`       // Cap on distinct winners; once reached, further units go again to those winners. 0 = unbounded
 }
 
 // Participant represents a person entering the lottery.
-
+// Weight scales their chance of being drawn; zero is treated as 1.
 type Participant struct {
-	ID   string `json:"id"`
-	Name string `json:"name"`
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Weight  int    `json:"weight,omitempty"`
+	Group   string `json:"group,omitempty"` // Department or team, matched against Prize.Group
+	Present bool   `json:"present"`         // false: absent, skipped by draws until marked present again
+}
+
+// UnmarshalJSON decodes a participant, treating a missing "present" field as
+// present so snapshots saved before attendance tracking keep everyone eligible.
+func (p *Participant) UnmarshalJSON(data []byte) error {
+	type plain Participant // Drops the method to avoid recursing
+	decoded := plain{Present: true}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*p = Participant(decoded)
+	return nil
+}
+
+// EffectiveWeight returns the participant's draw weight, defaulting to 1.
+func (p *Participant) EffectiveWeight() int {
+	if p.Weight <= 0 {
+		return 1
+	}
+	return p.Weight
 }
 
 // LotteryResult stores the outcome of a single draw,
 // linking a winner to a specific prize.
 type LotteryResult struct {
-	PrizeName  string `json:"prizeName"`
-	PrizeItem  string `json:"prizeItem"`
-	WinnerID   string `json:"winnerId"`
-	WinnerName string `json:"winnerName"`
+	ID         string     `json:"id"`
+	PrizeName  string     `json:"prizeName"`
+	PrizeItem  string     `json:"prizeItem"`
+	WinnerID   string     `json:"winnerId"`
+	WinnerName string     `json:"winnerName"`
+	DrawnAt    time.Time  `json:"drawnAt"`             // Serialized as RFC3339
+	BatchID    string     `json:"batchId,omitempty"`   // Shared by all results of one batch draw
+	Locked     bool       `json:"locked"`              // true: result is final and can no longer be undone or redrawn
+	Claimed    bool       `json:"claimed"`             // true: the winner has physically collected the prize
+	ClaimedAt  *time.Time `json:"claimedAt,omitempty"` // When the prize was collected; nil until claimed
+}
+
+// Setup steps reported by SetupStatus.Step, in the order an operator completes them.
+const (
+	SetupStepPrizes       = 1 // Waiting for at least one prize
+	SetupStepParticipants = 2 // Waiting for at least one participant
+	SetupStepReady        = 3 // Prizes and participants configured; ready to draw
+)
+
+// SetupStatus describes how far a tenant has progressed through the initial setup.
+// It is derived from the session state rather than stored.
+type SetupStatus struct {
+	Step            int  `json:"step"`
+	HasPrizes       bool `json:"hasPrizes"`
+	HasParticipants bool `json:"hasParticipants"`
+	ReadyToDraw     bool `json:"readyToDraw"`
 }
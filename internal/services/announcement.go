@@ -0,0 +1,160 @@
+package services
+
+import (
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"lottery/internal/i18n"
+	"lottery/internal/models"
+)
+
+// DefaultAnnouncementTemplate is the winner announcement used until a tenant
+// sets its own with SetAnnouncementTemplate. It is the i18n.Default text of the
+// "announcement" message; AnnounceIn uses that message's other translations.
+const DefaultAnnouncementTemplate = "{{.PrizeItem}}({{.PrizeName}})獎項的中獎人是{{.WinnerName}}(員編{{.WinnerID}})"
+
+const (
+	maxAnnouncementTemplateLen = 1000 // Longest template SetAnnouncementTemplate accepts, in bytes
+	maxAnnouncementLen         = 4096 // Longest rendered announcement, in bytes
+)
+
+// AnnouncementData is everything a custom announcement template can reference.
+// It holds plain strings only, so a template has nothing to call.
+type AnnouncementData struct {
+	PrizeName  string
+	PrizeItem  string
+	WinnerName string
+	WinnerID   string
+}
+
+// errAnnouncementTooLong stops a template whose output outgrows maxAnnouncementLen.
+var errAnnouncementTooLong = i18n.NewError("announcement_large")
+
+// errAnnouncementControl rejects templates with actions that repeat or call
+// other templates; see checkAnnouncementTree.
+var errAnnouncementControl = i18n.NewError("announcement_control")
+
+// limitedBuilder is a strings.Builder that refuses to grow past maxAnnouncementLen,
+// so a template cannot exhaust memory with its output.
+type limitedBuilder struct{ strings.Builder }
+
+func (b *limitedBuilder) Write(p []byte) (int, error) {
+	if b.Len()+len(p) > maxAnnouncementLen {
+		return 0, errAnnouncementTooLong
+	}
+	return b.Builder.Write(p)
+}
+
+// renderAnnouncement parses and executes an announcement template with
+// text/template. The output is plain text; callers escape it when rendering HTML.
+func renderAnnouncement(tmpl string, data AnnouncementData) (string, error) {
+	t, err := template.New("announcement").Parse(tmpl)
+	if err != nil {
+		return "", err
+	}
+	for _, defined := range t.Templates() {
+		if err := checkAnnouncementTree(defined.Root); err != nil {
+			return "", err
+		}
+	}
+	var out limitedBuilder
+	if err := t.Execute(&out, data); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// checkAnnouncementTree allows text, comments, actions and if/else only. A
+// range can spin without writing anything, e.g. {{range 1000000000}}{{end}},
+// which the output cap never sees; with and template calls are refused too so
+// that rendering stays one pass over the template.
+func checkAnnouncementTree(node parse.Node) error {
+	switch n := node.(type) {
+	case nil, *parse.TextNode, *parse.CommentNode, *parse.ActionNode:
+		return nil
+	case *parse.ListNode:
+		if n == nil {
+			return nil
+		}
+		for _, child := range n.Nodes {
+			if err := checkAnnouncementTree(child); err != nil {
+				return err
+			}
+		}
+		return nil
+	case *parse.IfNode:
+		if err := checkAnnouncementTree(n.List); err != nil {
+			return err
+		}
+		return checkAnnouncementTree(n.ElseList)
+	}
+	return errAnnouncementControl
+}
+
+// SetAnnouncementTemplate replaces the tenant's winner announcement. The template
+// uses text/template syntax over AnnouncementData, e.g. "恭喜{{.WinnerName}}". It is
+// test-rendered before being stored, so a malformed template is rejected with an
+// error and the previous one stays in effect. An empty template restores the default.
+func (s *LotteryService) SetAnnouncementTemplate(tenantID, tmpl string) error {
+	if len(tmpl) > maxAnnouncementTemplateLen {
+		return i18n.NewError("announcement_long", maxAnnouncementTemplateLen)
+	}
+	if tmpl != "" {
+		sample := AnnouncementData{PrizeName: "頭獎", PrizeItem: "電視", WinnerName: "王小明", WinnerID: "001"}
+		if _, err := renderAnnouncement(tmpl, sample); err != nil {
+			return i18n.NewError("announcement_bad", err)
+		}
+	}
+
+	session := s.lockSession(tenantID)
+	defer session.mu.Unlock()
+	session.AnnouncementTemplate = tmpl
+	s.logFor(tenantID).Infof("set announcement template (%d bytes)", len(tmpl))
+	return nil
+}
+
+// GetAnnouncementTemplate returns the tenant's announcement template, or
+// DefaultAnnouncementTemplate if none is set.
+func (s *LotteryService) GetAnnouncementTemplate(tenantID string) string {
+	session := s.lockSession(tenantID)
+	defer session.mu.Unlock()
+	if tmpl := session.AnnouncementTemplate; tmpl != "" {
+		return tmpl
+	}
+	return DefaultAnnouncementTemplate
+}
+
+// Announce renders the tenant's announcement for a result in i18n.Default; see AnnounceIn.
+func (s *LotteryService) Announce(tenantID string, result *models.LotteryResult) string {
+	return s.AnnounceIn(i18n.Default, tenantID, result)
+}
+
+// AnnounceIn renders the tenant's announcement for a result. A custom template
+// is used as it is; without one, the default announcement is given in lang.
+// Should the stored template fail anyway (e.g. its output is too long for this
+// winner), the default announcement is used instead. The winner's ID is masked
+// with MaskID when the tenant enabled SetMaskIDs.
+func (s *LotteryService) AnnounceIn(lang i18n.Lang, tenantID string, result *models.LotteryResult) string {
+	data := AnnouncementData{
+		PrizeName:  result.PrizeName,
+		PrizeItem:  result.PrizeItem,
+		WinnerName: result.WinnerName,
+		WinnerID:   result.WinnerID,
+	}
+	if s.GetMaskIDs(tenantID) {
+		data.WinnerID = MaskID(result.WinnerID)
+	}
+	fallback := i18n.T(lang, "announcement")
+	tmpl := s.GetAnnouncementTemplate(tenantID)
+	if tmpl == DefaultAnnouncementTemplate {
+		tmpl = fallback
+	}
+	text, err := renderAnnouncement(tmpl, data)
+	if err == nil {
+		return text
+	}
+	s.logFor(tenantID).Errorf("announcement template failed, using the default: %v", err)
+	text, _ = renderAnnouncement(fallback, data)
+	return text
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"lottery/internal/models"
+)
+
+func TestLotteryService_AnnouncementTemplate(t *testing.T) {
+	service := NewLotteryService()
+	const testTenantID = "announce-tenant"
+	result := &models.LotteryResult{PrizeName: "頭獎", PrizeItem: "電視", WinnerName: "Alice", WinnerID: "001"}
+
+	if got, want := service.Announce(testTenantID, result), "電視(頭獎)獎項的中獎人是Alice(員編001)"; got != want {
+		t.Errorf("Expected the default announcement %q, but got %q", want, got)
+	}
+
+	if err := service.SetAnnouncementTemplate(testTenantID, "恭喜{{.WinnerName}}抽中{{.PrizeItem}}！"); err != nil {
+		t.Fatalf("SetAnnouncementTemplate failed: %v", err)
+	}
+	if got, want := service.Announce(testTenantID, result), "恭喜Alice抽中電視！"; got != want {
+		t.Errorf("Expected the custom announcement %q, but got %q", want, got)
+	}
+
+	// A zero-output loop must be refused up front rather than spin.
+	start := time.Now()
+	for _, bad := range []string{
+		"{{.WinnerName",               // Unterminated action
+		"{{.Salary}}",                 // Unknown field
+		"{{range 100000000}}x{{end}}", // Repeats
+		"{{range 1000000000}}{{end}}", // Repeats without writing, so the output cap never stops it
+		"{{with .WinnerName}}{{.}}{{end}}",
+		`{{define "x"}}{{.WinnerName}}{{end}}{{template "x" .}}`,
+		`{{printf "%5000s" .WinnerName}}`, // Output too large
+		strings.Repeat("x", maxAnnouncementTemplateLen+1),
+	} {
+		if err := service.SetAnnouncementTemplate(testTenantID, bad); err == nil {
+			t.Errorf("Expected template %.40q to be rejected, but got nil", bad)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected rejecting the templates to be quick, but it took %v", elapsed)
+	}
+	if got := service.GetAnnouncementTemplate(testTenantID); got != "恭喜{{.WinnerName}}抽中{{.PrizeItem}}！" {
+		t.Errorf("Expected a rejected template to keep the previous one, but got %q", got)
+	}
+
+	if err := service.SetAnnouncementTemplate(testTenantID, "{{if .PrizeItem}}{{.PrizeItem}}{{else}}{{.PrizeName}}{{end}}"); err != nil {
+		t.Errorf("Expected if/else to stay allowed, but got %v", err)
+	}
+
+	if err := service.SetAnnouncementTemplate(testTenantID, ""); err != nil || service.GetAnnouncementTemplate(testTenantID) != DefaultAnnouncementTemplate {
+		t.Errorf("Expected an empty template to restore the default, but got %v", err)
+	}
+}
+
+func TestLotteryService_PrizeImageURL(t *testing.T) {
+	service := NewLotteryService()
+	const testTenantID = "image-tenant"
+
+	for _, ok := range []string{"", "https://example.com/tv.png", "/assets/tv.png"} {
+		if err := service.InsertPrize(testTenantID, models.Prize{Name: "獎" + ok, Item: "電視", Quantity: 1, ImageURL: ok}); err != nil {
+			t.Errorf("Expected image URL %q to be accepted, but got %v", ok, err)
+		}
+	}
+	for _, bad := range []string{"javascript:alert(1)", "data:image/png;base64,AAAA", "//evil.example/tv.png", "tv.png"} {
+		if err := service.InsertPrize(testTenantID, models.Prize{Name: "壞" + bad, Item: "電視", Quantity: 1, ImageURL: bad}); err == nil {
+			t.Errorf("Expected image URL %q to be rejected, but got nil", bad)
+		}
+	}
+}
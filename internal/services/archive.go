@@ -0,0 +1,59 @@
+package services
+
+import (
+	"slices"
+
+	"lottery/internal/models"
+)
+
+// SetHotResultsLimit caps how many results each session keeps in
+// LotteryResults. Whenever a draw takes a session past the cap, its oldest
+// results are moved to the session's archive, which only GetArchivedResults and
+// GetAllResults read, so the lottery page and the undo operations stay cheap on
+// long events. Archived results are final: they cannot be undone or redrawn,
+// but can still be claimed and are still exported. A limit <= 0, the default,
+// keeps every result hot. It must be called before the service is shared.
+func (s *LotteryService) SetHotResultsLimit(n int) {
+	s.hotResults = max(n, 0)
+}
+
+// archiveLocked moves the session's oldest results to its archive until no
+// more than the hot limit remain. The caller must hold the session's mu.
+func (s *LotteryService) archiveLocked(tenantID string, session *LotterySession) {
+	excess := len(session.LotteryResults) - s.hotResults
+	if s.hotResults == 0 || excess <= 0 {
+		return
+	}
+	session.ArchivedResults = append(session.ArchivedResults, session.LotteryResults[:excess]...)
+	// Copy the hot tail so the archived pointers are not kept alive by its backing array.
+	session.LotteryResults = slices.Clone(session.LotteryResults[excess:])
+	s.logFor(tenantID).Infof("archived %d results (%d archived in total)", excess, len(session.ArchivedResults))
+}
+
+// allResults returns the archived and hot results in draw order. The slice is
+// new but the results are the session's own.
+func allResults(session *LotterySession) []*models.LotteryResult {
+	return slices.Concat(session.ArchivedResults, session.LotteryResults)
+}
+
+// GetArchivedResults returns copies of the results moved out of
+// GetLotteryResults by SetHotResultsLimit, oldest first.
+func (s *LotteryService) GetArchivedResults(tenantID string) []*models.LotteryResult {
+	session := s.lockSession(tenantID)
+	defer session.mu.Unlock()
+	return copyResults(session.ArchivedResults)
+}
+
+// CountArchivedResults returns how many results a tenant has archived.
+func (s *LotteryService) CountArchivedResults(tenantID string) int {
+	session := s.lockSession(tenantID)
+	defer session.mu.Unlock()
+	return len(session.ArchivedResults)
+}
+
+// GetAllResults returns copies of every result, archived ones first, in draw order.
+func (s *LotteryService) GetAllResults(tenantID string) []*models.LotteryResult {
+	session := s.lockSession(tenantID)
+	defer session.mu.Unlock()
+	return copyResults(allResults(session))
+}
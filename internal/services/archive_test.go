@@ -0,0 +1,76 @@
+package services
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+)
+
+func TestLotteryService_HotResultsLimit(t *testing.T) {
+	service := NewLotteryService()
+	service.SetHotResultsLimit(3)
+	const testTenantID = "archive-tenant"
+	service.AddPrize(testTenantID, "參加獎", "紅包", 10, false)
+	for i := 1; i <= 10; i++ {
+		service.AddParticipant(testTenantID, strconv.Itoa(i), "P"+strconv.Itoa(i))
+	}
+
+	for i := 0; i < 4; i++ {
+		if _, err := service.Draw(testTenantID, "參加獎"); err != nil {
+			t.Fatalf("Draw failed: %v", err)
+		}
+	}
+	if _, err := service.DrawBatch(testTenantID, "參加獎", 2); err != nil {
+		t.Fatalf("DrawBatch failed: %v", err)
+	}
+
+	hot := service.GetLotteryResults(testTenantID)
+	archived := service.GetArchivedResults(testTenantID)
+	if len(hot) != 3 || len(archived) != 3 {
+		t.Fatalf("Expected 3 hot and 3 archived results, but got %d and %d", len(hot), len(archived))
+	}
+	all := service.GetAllResults(testTenantID)
+	for i, r := range all {
+		if r.ID != strconv.Itoa(i+1) {
+			t.Errorf("Expected all results in draw order, but position %d holds result %s", i, r.ID)
+		}
+	}
+	if archived[2].ID != "3" || hot[0].ID != "4" {
+		t.Errorf("Expected results 1-3 archived and 4-6 hot, but got archived %s.. hot %s..", archived[2].ID, hot[0].ID)
+	}
+
+	// Archived results still count as awarded and can be claimed, but not undone.
+	if summary := service.GetPrizeSummary(testTenantID); summary[0].Awarded != 6 || summary[0].Remaining != 4 {
+		t.Errorf("Expected 6 awarded and 4 remaining, but got %+v", summary[0])
+	}
+	if err := service.MarkClaimed(testTenantID, archived[0].ID); err != nil {
+		t.Errorf("Expected an archived result to be claimable, but got %v", err)
+	}
+	if _, err := service.RedrawWinner(testTenantID, "參加獎", archived[0].WinnerID); err == nil {
+		t.Error("Expected redrawing an archived result to fail, but got nil")
+	}
+	for i := 0; i < 3; i++ {
+		service.UndoLastDraw(testTenantID)
+	}
+	if _, err := service.UndoLastDraw(testTenantID); err == nil {
+		t.Error("Expected undo to stop at the archived results, but got nil")
+	}
+	if n := len(service.GetAllResults(testTenantID)); n != 3 {
+		t.Errorf("Expected the 3 archived results to remain, but got %d", n)
+	}
+
+	// A batch that was partly archived can no longer be undone as a unit.
+	service.DrawBatch(testTenantID, "參加獎", 3)
+	service.Draw(testTenantID, "參加獎")
+	hot = service.GetLotteryResults(testTenantID)
+	if err := service.UndoBatch(testTenantID, hot[0].BatchID); !errors.Is(err, ErrResultLocked) {
+		t.Errorf("Expected ErrResultLocked for a partly archived batch, but got %v", err)
+	}
+
+	if err := service.ResetResults(testTenantID); err != nil {
+		t.Fatalf("ResetResults failed: %v", err)
+	}
+	if n := len(service.GetAllResults(testTenantID)); n != 0 {
+		t.Errorf("Expected a reset to clear the archive too, but %d results remain", n)
+	}
+}
@@ -0,0 +1,51 @@
+package services
+
+import (
+	"time"
+
+	"lottery/internal/models"
+)
+
+// Audit actions recorded in a session's audit log.
+const (
+	AuditDraw   = "draw"   // One or more winners were drawn
+	AuditUndo   = "undo"   // Results were reversed
+	AuditRedraw = "redraw" // An absent winner's result was voided and redrawn
+	AuditReset  = "reset"  // Every result was cleared
+	AuditMerge  = "merge"  // Results were reassigned from a merged-away participant
+)
+
+// AuditEntry records one change to a session's results: what happened, when,
+// and which tenant session performed it.
+type AuditEntry struct {
+	At        time.Time `json:"at"`
+	TenantID  string    `json:"tenantId"`
+	Action    string    `json:"action"`
+	PrizeName string    `json:"prizeName,omitempty"` // Set when every result is for the same prize
+	ResultIDs []string  `json:"resultIds,omitempty"` // Results drawn or reversed, in order
+	WinnerIDs []string  `json:"winnerIds,omitempty"` // Participant of each entry in ResultIDs
+}
+
+// GetAuditLog returns the tenant's audit log, oldest entry first. The log
+// survives ResetResults; only clearing the session discards it.
+func (s *LotteryService) GetAuditLog(tenantID string) []AuditEntry {
+	session := s.lockSession(tenantID)
+	defer session.mu.Unlock()
+	return append([]AuditEntry{}, session.AuditLog...)
+}
+
+// audit appends an entry for results to the session's audit log. The caller
+// must hold the session's mu.
+func audit(tenantID string, session *LotterySession, action string, results ...*models.LotteryResult) {
+	entry := AuditEntry{At: time.Now(), TenantID: tenantID, Action: action}
+	for i, r := range results {
+		if i == 0 {
+			entry.PrizeName = r.PrizeName
+		} else if r.PrizeName != entry.PrizeName {
+			entry.PrizeName = ""
+		}
+		entry.ResultIDs = append(entry.ResultIDs, r.ID)
+		entry.WinnerIDs = append(entry.WinnerIDs, r.WinnerID)
+	}
+	session.AuditLog = append(session.AuditLog, entry)
+}
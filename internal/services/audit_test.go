@@ -0,0 +1,59 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLotteryService_AuditLog(t *testing.T) {
+	service := NewLotteryService()
+	const testTenantID = "audit-tenant"
+	service.AddPrize(testTenantID, "頭獎", "電視", 1, false)
+	service.AddPrize(testTenantID, "二獎", "手機", 2, false)
+	service.AddParticipant(testTenantID, "1", "Alice")
+	service.AddParticipant(testTenantID, "2", "Bob")
+	service.AddParticipant(testTenantID, "3", "Charlie")
+
+	drawn, err := service.Draw(testTenantID, "頭獎")
+	if err != nil {
+		t.Fatalf("Draw failed: %v", err)
+	}
+	if _, err := service.UndoLastDraw(testTenantID); err != nil {
+		t.Fatalf("UndoLastDraw failed: %v", err)
+	}
+	batch, err := service.DrawBatch(testTenantID, "二獎", 2)
+	if err != nil {
+		t.Fatalf("DrawBatch failed: %v", err)
+	}
+	if err := service.ResetResults(testTenantID); err != nil {
+		t.Fatalf("ResetResults failed: %v", err)
+	}
+
+	log := service.GetAuditLog(testTenantID)
+	if len(log) != 4 {
+		t.Fatalf("Expected 4 audit entries, but got %d: %+v", len(log), log)
+	}
+
+	draw, undo := log[0], log[1]
+	if draw.Action != AuditDraw || undo.Action != AuditUndo {
+		t.Fatalf("Expected a draw entry followed by an undo entry, but got %q and %q", draw.Action, undo.Action)
+	}
+	for _, e := range []AuditEntry{draw, undo} {
+		if e.TenantID != testTenantID || e.PrizeName != "頭獎" {
+			t.Errorf("Expected entry for tenant %q and prize 頭獎, but got %+v", testTenantID, e)
+		}
+		if !reflect.DeepEqual(e.ResultIDs, []string{drawn.ID}) || !reflect.DeepEqual(e.WinnerIDs, []string{drawn.WinnerID}) {
+			t.Errorf("Expected entry to reference result %s won by %s, but got %+v", drawn.ID, drawn.WinnerID, e)
+		}
+	}
+	if undo.At.Before(draw.At) {
+		t.Error("Expected the undo to be recorded after the draw")
+	}
+
+	if log[2].Action != AuditDraw || len(log[2].ResultIDs) != len(batch) {
+		t.Errorf("Expected one draw entry covering the batch of %d, but got %+v", len(batch), log[2])
+	}
+	if log[3].Action != AuditReset || len(log[3].ResultIDs) != len(batch) {
+		t.Errorf("Expected a reset entry listing the %d cleared results, but got %+v", len(batch), log[3])
+	}
+}
@@ -0,0 +1,74 @@
+package services
+
+import "time"
+
+// CoalesceConfig controls how a live-feed subscriber's events are batched.
+// With a zero Interval and MaxBatch every event is delivered on its own.
+type CoalesceConfig struct {
+	Interval time.Duration // Flush at most this long after the first buffered event
+	MaxBatch int           // Flush early once this many events are buffered (0 = no limit)
+}
+
+// coalescer sits between a publisher and one slow or fast subscriber. Events
+// sent to In are grouped into batches on Out according to the config; while the
+// subscriber is not reading, events keep accumulating into the pending batch so
+// a burst arrives as a few frames instead of one frame per event.
+type coalescer[T any] struct {
+	In  chan<- T
+	Out <-chan []T
+
+	in     chan T
+	out    chan []T
+	config CoalesceConfig
+}
+
+// newCoalescer starts a coalescer. Closing In flushes the pending batch and closes Out.
+func newCoalescer[T any](config CoalesceConfig, buffer int) *coalescer[T] {
+	c := &coalescer[T]{
+		in:     make(chan T, buffer),
+		out:    make(chan []T),
+		config: config,
+	}
+	c.In, c.Out = c.in, c.out
+	go c.run()
+	return c
+}
+
+func (c *coalescer[T]) run() {
+	defer close(c.out)
+
+	var batch []T
+	var deadline <-chan time.Time
+	due := false // The interval since the first buffered event has elapsed
+
+	for {
+		// Only offer the batch to the subscriber once it is due or full.
+		var out chan<- []T
+		if len(batch) > 0 && (due || (c.config.MaxBatch > 0 && len(batch) >= c.config.MaxBatch)) {
+			out = c.out
+		}
+
+		select {
+		case ev, ok := <-c.in:
+			if !ok {
+				if len(batch) > 0 {
+					c.out <- batch
+				}
+				return
+			}
+			batch = append(batch, ev)
+			if len(batch) == 1 {
+				if c.config.Interval <= 0 {
+					due = true
+				} else {
+					deadline = time.After(c.config.Interval)
+				}
+			}
+		case <-deadline:
+			deadline = nil
+			due = true
+		case out <- batch:
+			batch, deadline, due = nil, nil, false
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+// drain collects batches from a coalescer until it is closed.
+func drain[T any](c *coalescer[T]) [][]T {
+	var batches [][]T
+	for batch := range c.Out {
+		batches = append(batches, batch)
+	}
+	return batches
+}
+
+func TestCoalescer(t *testing.T) {
+	t.Run("Test rapid events are coalesced per interval", func(t *testing.T) {
+		c := newCoalescer[int](CoalesceConfig{Interval: 50 * time.Millisecond}, 16)
+		done := make(chan [][]int)
+		go func() { done <- drain(c) }()
+
+		for i := 0; i < 100; i++ {
+			c.In <- i
+		}
+		close(c.In)
+
+		batches := <-done
+		total := 0
+		for _, b := range batches {
+			total += len(b)
+		}
+		if total != 100 {
+			t.Errorf("Expected 100 events in total, but got %d", total)
+		}
+		if len(batches) > 3 {
+			t.Errorf("Expected the burst to arrive in a few frames, but got %d", len(batches))
+		}
+		if batches[0][0] != 0 || batches[len(batches)-1][len(batches[len(batches)-1])-1] != 99 {
+			t.Error("Expected events to keep their original order")
+		}
+	})
+
+	t.Run("Test batch size triggers an early flush", func(t *testing.T) {
+		c := newCoalescer[int](CoalesceConfig{Interval: time.Hour, MaxBatch: 5}, 16)
+		for i := 0; i < 5; i++ {
+			c.In <- i
+		}
+		select {
+		case batch := <-c.Out:
+			if len(batch) != 5 {
+				t.Errorf("Expected a batch of 5, but got %d", len(batch))
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Expected a flush once MaxBatch was reached")
+		}
+		close(c.In)
+	})
+
+	t.Run("Test zero config delivers immediately", func(t *testing.T) {
+		c := newCoalescer[int](CoalesceConfig{}, 1)
+		c.In <- 42
+		select {
+		case batch := <-c.Out:
+			if len(batch) != 1 || batch[0] != 42 {
+				t.Errorf("Expected [42], but got %v", batch)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Expected the event to be delivered without delay")
+		}
+		close(c.In)
+	})
+}
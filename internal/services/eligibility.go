@@ -0,0 +1,112 @@
+package services
+
+import (
+	"slices"
+
+	"lottery/internal/i18n"
+	"lottery/internal/models"
+)
+
+// exclusionReason explains why p may not win targetPrize under the rules
+// applied to every participant on their own, or returns nil if they may. It
+// is the single source of those rules for firstTimeEligible, repeatWinners and
+// ExplainEligibility. totals holds each participant's units won, from
+// winTotals. With repeat, p is being considered for another unit of a prize
+// that has reached MaxWinners, so only the rules about earlier wins are
+// skipped. The caller must hold the session's mu.
+func exclusionReason(session *LotterySession, targetPrize *models.Prize, p *models.Participant, totals map[string]int, repeat bool) *i18n.Error {
+	if !p.Present {
+		return i18n.NewError("eligibility_absent")
+	}
+	if session.Excluded[p.ID] {
+		return i18n.NewError("eligibility_voided")
+	}
+	if targetPrize.Group != "" && p.Group != targetPrize.Group {
+		return i18n.NewError("eligibility_group", targetPrize.Group)
+	}
+	wins := session.Winners[p.ID]
+	if session.MaxWinsPerParticipant > 0 && max(len(wins), totals[p.ID]) >= session.MaxWinsPerParticipant {
+		return i18n.NewError("eligibility_max_wins", session.MaxWinsPerParticipant)
+	}
+	switch {
+	case repeat:
+		// p already holds a unit of targetPrize, which the cases below would hold against them.
+	case (targetPrize.DrawFromAll || session.AllowRepeatWins) && !session.UniqueAcrossAll:
+		if wins[targetPrize.Name] {
+			return i18n.NewError("eligibility_won_prize")
+		}
+	case len(wins) > 0:
+		if session.UniqueAcrossAll && (targetPrize.DrawFromAll || session.AllowRepeatWins) {
+			return i18n.NewError("eligibility_unique_across_all")
+		}
+		return i18n.NewError("eligibility_won_any")
+	}
+	if i := slices.IndexFunc(targetPrize.ExcludeWinnersOf, func(name string) bool { return wins[name] }); i >= 0 {
+		return i18n.NewError("eligibility_excluded_prize", targetPrize.ExcludeWinnersOf[i])
+	}
+	return nil
+}
+
+// winTotals returns how many units each participant has won across all
+// prizes, counting every unit of a prize with MaxWinners. The caller must hold
+// the session's mu.
+func winTotals(session *LotterySession) map[string]int {
+	totals := make(map[string]int)
+	for _, r := range allResults(session) {
+		totals[r.WinnerID]++
+	}
+	return totals
+}
+
+// ExplainEligibility reports whether a participant may win the next draw of a
+// prize, with the reason in i18n.Default; see ExplainEligibilityIn.
+func (s *LotteryService) ExplainEligibility(tenantID, prizeName, participantID string) (bool, string, error) {
+	return s.ExplainEligibilityIn(i18n.Default, tenantID, prizeName, participantID)
+}
+
+// ExplainEligibilityIn reports whether a participant is in the pool
+// GetEligibleParticipants returns for a prize and, in lang, why or why not, so
+// staff can answer "why can't I win?". An unknown prize or participant is
+// reported as ErrPrizeNotFound or ErrParticipantNotFound.
+func (s *LotteryService) ExplainEligibilityIn(lang i18n.Lang, tenantID, prizeName, participantID string) (bool, string, error) {
+	session := s.lockSession(tenantID)
+	defer session.mu.Unlock()
+
+	prize := findPrize(session, prizeName)
+	if prize == nil {
+		return false, "", i18n.NewError(i18n.WithDetail, ErrPrizeNotFound, prizeName)
+	}
+	i := slices.IndexFunc(session.Participants, func(p *models.Participant) bool { return p.ID == participantID })
+	if i < 0 {
+		return false, "", i18n.NewError(i18n.WithDetail, ErrParticipantNotFound, participantID)
+	}
+	p := session.Participants[i]
+
+	pool, _ := eligibleLocked(session, prize)
+	if slices.Contains(pool, p) {
+		return true, i18n.T(lang, "eligibility_ok"), nil
+	}
+	return false, i18n.Localize(lang, poolExclusionReason(session, prize, p)), nil
+}
+
+// poolExclusionReason explains why p is missing from computeEligible's pool
+// for prize, adding the rules that depend on the rest of the session to those
+// of exclusionReason. The caller must hold the session's mu.
+func poolExclusionReason(session *LotterySession, prize *models.Prize, p *models.Participant) *i18n.Error {
+	totals := winTotals(session)
+	if counts := prizeWinCounts(session, prize); len(counts) > 0 && len(counts) >= prize.MaxWinners {
+		if reason := exclusionReason(session, prize, p, totals, true); reason != nil {
+			return reason
+		}
+		switch {
+		case counts[p.ID] == 0:
+			return i18n.NewError("eligibility_max_winners", prize.MaxWinners)
+		case !slices.Contains(repeatWinners(session, prize, counts), p):
+			return i18n.NewError("eligibility_max_winners_more")
+		}
+	} else if reason := exclusionReason(session, prize, p, totals, false); reason != nil {
+		return reason
+	}
+	// Everything else held, so NoConsecutiveRepeat took p out.
+	return i18n.NewError("eligibility_consecutive")
+}
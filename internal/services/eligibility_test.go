@@ -0,0 +1,118 @@
+package services
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"lottery/internal/i18n"
+	"lottery/internal/models"
+)
+
+func TestLotteryService_ExplainEligibility(t *testing.T) {
+	const tenant = "eligibility-tenant"
+	// Each case sets up a fresh service, then asks about participant 001 and 目標獎.
+	tests := []struct {
+		name   string
+		setup  func(s *LotteryService)
+		want   bool
+		reason string
+		args   []any
+	}{
+		{"eligible", func(s *LotteryService) {
+			s.InsertPrize(tenant, models.Prize{Name: "目標獎", Quantity: 1})
+		}, true, "eligibility_ok", nil},
+		{"absent", func(s *LotteryService) {
+			s.InsertPrize(tenant, models.Prize{Name: "目標獎", Quantity: 1})
+			s.SetPresence(tenant, "001", false)
+		}, false, "eligibility_absent", nil},
+		{"voided", func(s *LotteryService) {
+			s.InsertPrize(tenant, models.Prize{Name: "目標獎", Quantity: 2})
+			s.SetPresence(tenant, "002", false)
+			s.Draw(tenant, "目標獎")
+			s.SetPresence(tenant, "002", true)
+			s.RedrawWinner(tenant, "目標獎", "001")
+		}, false, "eligibility_voided", nil},
+		{"other group", func(s *LotteryService) {
+			s.InsertPrize(tenant, models.Prize{Name: "目標獎", Quantity: 1, Group: "業務部"})
+		}, false, "eligibility_group", []any{"業務部"}},
+		{"max wins per person", func(s *LotteryService) {
+			s.SetMaxWins(tenant, 1)
+			s.InsertPrize(tenant, models.Prize{Name: "先抽獎", Quantity: 1, DrawFromAll: true})
+			s.InsertPrize(tenant, models.Prize{Name: "目標獎", Quantity: 1, DrawFromAll: true})
+			drawAs001(s, tenant, "先抽獎")
+		}, false, "eligibility_max_wins", []any{1}},
+		{"won this prize", func(s *LotteryService) {
+			s.InsertPrize(tenant, models.Prize{Name: "目標獎", Quantity: 2, DrawFromAll: true})
+			drawAs001(s, tenant, "目標獎")
+		}, false, "eligibility_won_prize", nil},
+		{"won another prize", func(s *LotteryService) {
+			s.InsertPrize(tenant, models.Prize{Name: "先抽獎", Quantity: 1})
+			s.InsertPrize(tenant, models.Prize{Name: "目標獎", Quantity: 1})
+			drawAs001(s, tenant, "先抽獎")
+		}, false, "eligibility_won_any", nil},
+		{"unique across all", func(s *LotteryService) {
+			s.SetUniqueAcrossAll(tenant, true)
+			s.InsertPrize(tenant, models.Prize{Name: "先抽獎", Quantity: 1})
+			s.InsertPrize(tenant, models.Prize{Name: "目標獎", Quantity: 1, DrawFromAll: true})
+			drawAs001(s, tenant, "先抽獎")
+		}, false, "eligibility_unique_across_all", nil},
+		{"excluded prize", func(s *LotteryService) {
+			s.InsertPrize(tenant, models.Prize{Name: "先抽獎", Quantity: 1, DrawFromAll: true})
+			s.InsertPrize(tenant, models.Prize{Name: "目標獎", Quantity: 1, DrawFromAll: true, ExcludeWinnersOf: []string{"先抽獎"}})
+			drawAs001(s, tenant, "先抽獎")
+		}, false, "eligibility_excluded_prize", []any{"先抽獎"}},
+		{"winner limit reached", func(s *LotteryService) {
+			s.InsertPrize(tenant, models.Prize{Name: "目標獎", Quantity: 3, MaxWinners: 1})
+			s.SetPresence(tenant, "001", false)
+			s.Draw(tenant, "目標獎")
+			s.SetPresence(tenant, "001", true)
+		}, false, "eligibility_max_winners", []any{1}},
+		{"holds more units", func(s *LotteryService) {
+			s.InsertPrize(tenant, models.Prize{Name: "目標獎", Quantity: 4, MaxWinners: 2})
+			s.DrawBatch(tenant, "目標獎", 2)
+			drawAs001(s, tenant, "目標獎")
+		}, false, "eligibility_max_winners_more", nil},
+		{"won the previous draw", func(s *LotteryService) {
+			s.SetNoConsecutiveRepeat(tenant, true)
+			s.InsertPrize(tenant, models.Prize{Name: "先抽獎", Quantity: 1, DrawFromAll: true})
+			s.InsertPrize(tenant, models.Prize{Name: "目標獎", Quantity: 1, DrawFromAll: true})
+			drawAs001(s, tenant, "先抽獎")
+		}, false, "eligibility_consecutive", nil},
+	}
+	for _, tt := range tests {
+		service := NewLotteryService()
+		service.AddParticipant(tenant, "001", "Alice")
+		service.AddParticipant(tenant, "002", "Bob")
+		tt.setup(service)
+
+		eligible, reason, err := service.ExplainEligibility(tenant, "目標獎", "001")
+		if err != nil {
+			t.Errorf("%s: ExplainEligibility failed: %v", tt.name, err)
+			continue
+		}
+		if want := i18n.T(i18n.Default, tt.reason, tt.args...); eligible != tt.want || reason != want {
+			t.Errorf("%s: expected %t %q, but got %t %q", tt.name, tt.want, want, eligible, reason)
+		}
+		pool, _ := service.GetEligibleParticipants(tenant, "目標獎")
+		if inPool := slices.ContainsFunc(pool, func(p *models.Participant) bool { return p.ID == "001" }); inPool != eligible {
+			t.Errorf("%s: expected the explanation to agree with GetEligibleParticipants (%t), but got %t", tt.name, inPool, eligible)
+		}
+	}
+
+	service := NewLotteryService()
+	service.AddPrize(tenant, "目標獎", "電視", 1, false)
+	if _, _, err := service.ExplainEligibility(tenant, "不存在", "001"); !errors.Is(err, ErrPrizeNotFound) {
+		t.Errorf("Expected ErrPrizeNotFound, but got %v", err)
+	}
+	if _, _, err := service.ExplainEligibility(tenant, "目標獎", "999"); !errors.Is(err, ErrParticipantNotFound) {
+		t.Errorf("Expected ErrParticipantNotFound, but got %v", err)
+	}
+}
+
+// drawAs001 draws prizeName with participant 002 marked absent, so 001 wins.
+func drawAs001(s *LotteryService, tenantID, prizeName string) {
+	s.SetPresence(tenantID, "002", false)
+	s.Draw(tenantID, prizeName)
+	s.SetPresence(tenantID, "002", true)
+}
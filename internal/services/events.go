@@ -0,0 +1,97 @@
+package services
+
+import (
+	"sync"
+
+	"lottery/internal/models"
+)
+
+// Event types published to live-feed subscribers.
+const (
+	EventDraw      = "draw"   // One winner was drawn
+	EventBatchDraw = "batch"  // Several winners were drawn in one batch
+	EventUndo      = "undo"   // Results were reversed; Results lists the removed ones
+	EventRedraw    = "redraw" // An absent winner was replaced; Results holds the voided and the new result
+	EventReset     = "reset"  // All results were wiped; Results lists the removed ones
+	EventMerge     = "merge"  // A participant was merged into another; Results lists the reassigned ones
+)
+
+// eventBuffer is the per-subscriber queue in front of its coalescer.
+const eventBuffer = 64
+
+// LotteryEvent is pushed to every live-feed subscriber of a tenant when its
+// results change.
+type LotteryEvent struct {
+	Type    string                  `json:"type"`
+	Results []*models.LotteryResult `json:"results"`
+}
+
+// eventBroker fans events out to the subscribers of each tenant. Every
+// subscriber gets its own coalescer so a slow screen receives bursts as a few
+// batches instead of holding up the publisher.
+type eventBroker struct {
+	mu          sync.Mutex
+	subscribers map[string]map[*coalescer[LotteryEvent]]struct{} // Key: tenantID
+}
+
+func newEventBroker() *eventBroker {
+	return &eventBroker{subscribers: make(map[string]map[*coalescer[LotteryEvent]]struct{})}
+}
+
+// subscribe registers a subscriber for a tenant. The returned function removes
+// it again and must be called once the subscriber stops reading.
+func (b *eventBroker) subscribe(tenantID string, config CoalesceConfig) (<-chan []LotteryEvent, func()) {
+	c := newCoalescer[LotteryEvent](config, eventBuffer)
+
+	b.mu.Lock()
+	if b.subscribers[tenantID] == nil {
+		b.subscribers[tenantID] = make(map[*coalescer[LotteryEvent]]struct{})
+	}
+	b.subscribers[tenantID][c] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subscribers[tenantID], c)
+			if len(b.subscribers[tenantID]) == 0 {
+				delete(b.subscribers, tenantID)
+			}
+			close(c.in)
+			b.mu.Unlock()
+
+			// Drain the final flush so the coalescer goroutine can exit.
+			go func() {
+				for range c.Out {
+				}
+			}()
+		})
+	}
+	return c.Out, unsubscribe
+}
+
+// publish delivers an event to every subscriber of a tenant without blocking.
+// If a subscriber's queue is full the event is dropped for that subscriber.
+func (b *eventBroker) publish(tenantID string, event LotteryEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for c := range b.subscribers[tenantID] {
+		select {
+		case c.in <- event:
+		default:
+		}
+	}
+}
+
+// Subscribe registers a live-feed subscriber for a tenant's result changes.
+// Events are batched according to config. Call the returned function to
+// unsubscribe; the channel is closed afterwards.
+func (s *LotteryService) Subscribe(tenantID string, config CoalesceConfig) (<-chan []LotteryEvent, func()) {
+	return s.events.subscribe(tenantID, config)
+}
+
+// publish sends a copy of results to the tenant's subscribers.
+func (s *LotteryService) publish(tenantID, eventType string, results ...*models.LotteryResult) {
+	s.events.publish(tenantID, LotteryEvent{Type: eventType, Results: copyResults(results)})
+}
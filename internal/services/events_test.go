@@ -0,0 +1,91 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLotteryService_Subscribe(t *testing.T) {
+	const testTenantID = "events-tenant"
+	service := NewLotteryService()
+	service.AddPrize(testTenantID, "頭獎", "電視", 3, true)
+	service.AddParticipant(testTenantID, "001", "Alice")
+	service.AddParticipant(testTenantID, "002", "Bob")
+
+	events, unsubscribe := service.Subscribe(testTenantID, CoalesceConfig{})
+	defer unsubscribe()
+	other, unsubscribeOther := service.Subscribe("other-tenant", CoalesceConfig{})
+	defer unsubscribeOther()
+
+	receive := func() LotteryEvent {
+		t.Helper()
+		select {
+		case batch := <-events:
+			if len(batch) != 1 {
+				t.Fatalf("Expected one event per batch, but got %d", len(batch))
+			}
+			return batch[0]
+		case <-time.After(time.Second):
+			t.Fatal("Timed out waiting for an event")
+		}
+		return LotteryEvent{}
+	}
+
+	result, err := service.Draw(testTenantID, "頭獎")
+	if err != nil {
+		t.Fatalf("Draw failed: %v", err)
+	}
+	if ev := receive(); ev.Type != EventDraw || len(ev.Results) != 1 || ev.Results[0].ID != result.ID {
+		t.Errorf("Expected a draw event for result %s, but got %+v", result.ID, ev)
+	}
+
+	if _, err := service.UndoLastDraw(testTenantID); err != nil {
+		t.Fatalf("UndoLastDraw failed: %v", err)
+	}
+	if ev := receive(); ev.Type != EventUndo || ev.Results[0].ID != result.ID {
+		t.Errorf("Expected an undo event for result %s, but got %+v", result.ID, ev)
+	}
+
+	if _, err := service.DrawBatch(testTenantID, "頭獎", 2); err != nil {
+		t.Fatalf("DrawBatch failed: %v", err)
+	}
+	if ev := receive(); ev.Type != EventBatchDraw || len(ev.Results) != 2 {
+		t.Errorf("Expected a batch event with 2 results, but got %+v", ev)
+	}
+
+	if err := service.ResetResults(testTenantID); err != nil {
+		t.Fatalf("ResetResults failed: %v", err)
+	}
+	if ev := receive(); ev.Type != EventReset || len(ev.Results) != 2 {
+		t.Errorf("Expected a reset event with 2 results, but got %+v", ev)
+	}
+
+	select {
+	case batch := <-other:
+		t.Errorf("Expected no events for another tenant, but got %+v", batch)
+	default:
+	}
+}
+
+func TestLotteryService_Unsubscribe(t *testing.T) {
+	const testTenantID = "unsubscribe-tenant"
+	service := NewLotteryService()
+	events, unsubscribe := service.Subscribe(testTenantID, CoalesceConfig{})
+	unsubscribe()
+	unsubscribe() // Calling it twice is harmless
+
+	select {
+	case _, ok := <-events:
+		if ok {
+			t.Error("Expected the channel to be closed after unsubscribing")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for the channel to close")
+	}
+
+	service.events.mu.Lock()
+	defer service.events.mu.Unlock()
+	if n := len(service.events.subscribers); n != 0 {
+		t.Errorf("Expected no registered subscribers, but got %d tenants", n)
+	}
+}
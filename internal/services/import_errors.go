@@ -0,0 +1,33 @@
+package services
+
+import "slices"
+
+// ImportError is a row a participant import rejected, kept so the operator can
+// download the rows, fix them and upload them again.
+type ImportError struct {
+	Line   int      // 1-based line of the row in the uploaded file or pasted text
+	Record []string // The row's fields as read
+	Reason string   // Why the row was rejected, in the uploader's language
+}
+
+// SetImportErrors replaces the tenant's rejected rows with those of the import
+// that just finished. An import without rejected rows clears them.
+func (s *LotteryService) SetImportErrors(tenantID string, errs []ImportError) {
+	session := s.lockSession(tenantID)
+	defer session.mu.Unlock()
+	session.importErrors = slices.Clone(errs)
+	if len(errs) > 0 {
+		s.logFor(tenantID).Infof("kept %d rejected import rows", len(errs))
+	}
+}
+
+// GetImportErrors returns the rows rejected by the tenant's last participant import.
+func (s *LotteryService) GetImportErrors(tenantID string) []ImportError {
+	session := s.lockSession(tenantID)
+	defer session.mu.Unlock()
+	errs := make([]ImportError, len(session.importErrors))
+	for i, e := range session.importErrors {
+		errs[i] = ImportError{Line: e.Line, Record: slices.Clone(e.Record), Reason: e.Reason}
+	}
+	return errs
+}
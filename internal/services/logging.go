@@ -0,0 +1,48 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/google/logger"
+)
+
+// Logger is the logging interface used by LotteryService and the HTTP handlers.
+// Implementations must be safe for concurrent use.
+type Logger interface {
+	Infof(format string, args ...any)
+	Errorf(format string, args ...any)
+}
+
+// DefaultLogger returns a Logger backed by github.com/google/logger.
+func DefaultLogger() Logger {
+	return googleLogger{}
+}
+
+type googleLogger struct{}
+
+func (googleLogger) Infof(format string, args ...any) {
+	logger.InfoDepth(1, fmt.Sprintf(format, args...))
+}
+
+func (googleLogger) Errorf(format string, args ...any) {
+	logger.ErrorDepth(1, fmt.Sprintf(format, args...))
+}
+
+// WithTenant returns a Logger that prefixes every message with a tenant=<id>
+// field, so all lines of a tenant can be found with a single search.
+func WithTenant(l Logger, tenantID string) Logger {
+	return tenantLogger{base: l, tenantID: tenantID}
+}
+
+type tenantLogger struct {
+	base     Logger
+	tenantID string
+}
+
+func (t tenantLogger) Infof(format string, args ...any) {
+	t.base.Infof("tenant=%q "+format, append([]any{t.tenantID}, args...)...)
+}
+
+func (t tenantLogger) Errorf(format string, args ...any) {
+	t.base.Errorf("tenant=%q "+format, append([]any{t.tenantID}, args...)...)
+}
@@ -1,180 +1,2325 @@
 package services
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"lottery/internal/i18n"
 	"lottery/internal/models"
+	"maps"
 	"math/rand"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
-
-	"github.com/google/logger"
 )
 
 // LotterySession holds the data for a single user/tenant.
 type LotterySession struct {
-	Prizes         []*models.Prize
-	Participants   []*models.Participant
-	Winners        map[string]bool // Key: Participant.ID
-	LotteryResults []*models.LotteryResult
-	LastActivity   time.Time
+	Prizes                []*models.Prize            `json:"prizes"`
+	Participants          []*models.Participant      `json:"participants"`
+	Winners               map[string]map[string]bool `json:"winners"` // Key: Participant.ID, then Prize.Name
+	LotteryResults        []*models.LotteryResult    `json:"lotteryResults"`
+	LastActivity          time.Time                  `json:"lastActivity"`
+	ResultSeq             int                        `json:"resultSeq"`                      // Last sequence number handed out as a LotteryResult.ID
+	MinParticipants       int                        `json:"minParticipants"`                // Draws are rejected until the roster reaches this size
+	Seed                  *int64                     `json:"seed,omitempty"`                 // Announced seed for reproducible draws; nil uses crypto/rand
+	MaxWinsPerParticipant int                        `json:"maxWinsPerParticipant"`          // Cap on total wins across all prizes; 0 = unlimited
+	Excluded              map[string]bool            `json:"excluded"`                       // Participant IDs voided as absent; never drawn again
+	UniqueAcrossAll       bool                       `json:"uniqueAcrossAll"`                // true: DrawFromAll prizes also exclude anyone who has won any prize
+	AllowRepeatWins       bool                       `json:"allowRepeatWins,omitempty"`      // true: other prizes only exclude their own winners, like DrawFromAll ones
+	NoConsecutiveRepeat   bool                       `json:"noConsecutiveRepeat,omitempty"`  // true: the previous draw's winner sits out the next draw unless nobody else is eligible
+	AuditLog              []AuditEntry               `json:"auditLog,omitempty"`             // Every draw, undo, redraw, reset and merge, oldest first
+	Reservations          map[string][]string        `json:"reservations,omitempty"`         // Key: Prize.Name; participant IDs the next draws must pick, in order
+	AnnouncementTemplate  string                     `json:"announcementTemplate,omitempty"` // text/template for the winner announcement; empty uses DefaultAnnouncementTemplate
+	MaskIDs               bool                       `json:"maskIds,omitempty"`              // true: winner displays show IDs through MaskID; exports keep them in full
+	ArchivedResults       []*models.LotteryResult    `json:"archivedResults,omitempty"`      // Oldest results moved out of LotteryResults by the hot limit; final
+
+	// mu serializes every operation on the session; see lockSession.
+	mu sync.Mutex
+
+	// version is read without mu by Version; see markChanged.
+	version atomic.Uint64
+
+	// rng is the seeded source built from Seed. It is not persisted; loading a
+	// session rebuilds it from Seed, which restarts the sequence.
+	rng *rand.Rand
+
+	// eligibleCache memoizes GetEligibleParticipants per prize name. Any change to
+	// prizes, participants or winners must call invalidateEligible.
+	eligibleCache map[string][]*models.Participant
+
+	// processedDraws remembers recent DrawBatchOnce calls by idempotency key so a
+	// repeated request is answered without drawing again. Entries older than
+	// idempotencyWindow are pruned on the next keyed draw.
+	processedDraws map[string]processedDraw
+
+	// importErrors are the rows the last participant import rejected; see
+	// SetImportErrors. They are not persisted.
+	importErrors []ImportError
+}
+
+// idempotencyWindow is how long DrawBatchOnce remembers an idempotency key.
+const idempotencyWindow = 10 * time.Minute
+
+// processedDraw is the outcome of a keyed draw, replayed for repeated keys.
+type processedDraw struct {
+	at      time.Time
+	results []*models.LotteryResult
+	err     error
+}
+
+// intn returns a random integer in [0, n) from the session's seeded source if
+// one is set, otherwise from crypto/rand.
+func (session *LotterySession) intn(n int) (int, error) {
+	if session.Seed == nil {
+		return secureIntn(n)
+	}
+	if n <= 0 {
+		return 0, errors.New("intn: n must be positive")
+	}
+	if session.rng == nil {
+		session.rng = rand.New(rand.NewSource(*session.Seed))
+	}
+	return session.rng.Intn(n), nil
+}
+
+// invalidateEligible drops every cached eligibility list for the session. It
+// also calls markChanged, as whatever changes eligibility changes the lists.
+func (session *LotterySession) invalidateEligible() {
+	session.eligibleCache = nil
+	session.markChanged()
+}
+
+// listVersions hands out the values of LotterySession.version. It is shared by
+// every session so a version never repeats, not even across tenants or after a
+// session is cleared and created again.
+var listVersions atomic.Uint64
+
+// markChanged gives the session a new version, so a client holding the ETag of
+// an older prize or participant list gets it rendered again. Any change to the
+// prizes or participants must call it, directly or through invalidateEligible.
+func (session *LotterySession) markChanged() {
+	session.version.Store(listVersions.Add(1))
 }
 
+// Version identifies the current state of a tenant's prizes and participants:
+// it changes with every change to them and never takes a value it had before.
+// It is an atomic read that does not wait for operations on the session, and
+// reports 0 for a tenant without one.
+func (s *LotteryService) Version(tenantID string) uint64 {
+	s.mu.RLock()
+	session, ok := s.sessions[tenantID]
+	s.mu.RUnlock()
+	if !ok {
+		return 0
+	}
+	return session.version.Load()
+}
+
+// ErrResultLocked is returned when an operation would alter a locked (final) result.
+var ErrResultLocked = i18n.NewError("result_locked")
+
+// Draw and eligibility errors. They are returned wrapped with the prize name,
+// so match them with errors.Is. ErrNoParticipants means the roster is empty,
+// while ErrNoEligibleParticipants means nobody on it may win the prize.
+var (
+	ErrPrizeNotFound          = i18n.NewError("prize_not_found")
+	ErrPrizeExhausted         = i18n.NewError("prize_exhausted")
+	ErrNoParticipants         = i18n.NewError("no_participants")
+	ErrNoEligibleParticipants = i18n.NewError("no_eligible_participants")
+)
+
 // LotteryService manages multiple lottery sessions.
 type LotteryService struct {
-	mu       sync.RWMutex
-	sessions map[string]*LotterySession // Key: tenantID
+	mu           sync.RWMutex               // Guards the sessions map only; each session has its own mu
+	sessions     map[string]*LotterySession // Key: tenantID
+	sessionTTL   time.Duration              // Idle time after which CleanUpInactiveSessions drops a session
+	hotResults   int                        // Results kept in LotteryResults before older ones are archived; 0 = all
+	cleanupDebug bool                       // Whether evictions log the evicted session's contents
+	log          Logger
+	events       *eventBroker
+	metrics      serviceMetrics
 }
 
+// DefaultSessionTTL is the inactivity timeout used by NewLotteryService.
+const DefaultSessionTTL = time.Hour
+
 // NewLotteryService creates and initializes a new LotteryService.
 func NewLotteryService() *LotteryService {
+	return NewLotteryServiceWithTTL(DefaultSessionTTL)
+}
+
+// NewLotteryServiceWithTTL creates a LotteryService whose sessions expire after
+// ttl of inactivity. A non-positive ttl falls back to DefaultSessionTTL.
+func NewLotteryServiceWithTTL(ttl time.Duration) *LotteryService {
+	if ttl <= 0 {
+		ttl = DefaultSessionTTL
+	}
 	return &LotteryService{
-		sessions: make(map[string]*LotterySession),
+		sessions:   make(map[string]*LotterySession),
+		sessionTTL: ttl,
+		log:        DefaultLogger(),
+		events:     newEventBroker(),
 	}
 }
 
+// SetLogger replaces the service's logger. It must be called before the service is shared.
+func (s *LotteryService) SetLogger(l Logger) {
+	s.log = l
+}
+
+// logFor returns the service logger tagged with a tenant ID.
+func (s *LotteryService) logFor(tenantID string) Logger {
+	return WithTenant(s.log, tenantID)
+}
+
+// SessionTTL returns the inactivity timeout after which sessions are dropped.
+func (s *LotteryService) SessionTTL() time.Duration {
+	return s.sessionTTL
+}
+
 // getSession returns a session for a tenant, creating one if it doesn't exist.
+// The returned session must not be accessed concurrently with other service calls.
 func (s *LotteryService) getSession(tenantID string) *LotterySession {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	session := s.lockSession(tenantID)
+	session.mu.Unlock()
+	return session
+}
 
+// lockSession returns a tenant's session, creating one if it doesn't exist,
+// with its mu held; the caller must unlock it. Public methods hold it for their
+// whole read-modify-write so that concurrent requests for the same tenant
+// cannot interleave, while requests for other tenants proceed in parallel.
+//
+// s.mu only guards the sessions map and is never held while waiting for a
+// session's mu, so the two cannot deadlock. A session removed or replaced while
+// we waited for its mu is no longer the tenant's, so we start over.
+func (s *LotteryService) lockSession(tenantID string) *LotterySession {
+	for {
+		s.mu.Lock()
+		session := s.sessionLocked(tenantID)
+		s.mu.Unlock()
+
+		session.mu.Lock()
+		s.mu.RLock()
+		current := s.sessions[tenantID] == session
+		s.mu.RUnlock()
+		if current {
+			session.LastActivity = time.Now()
+			return session
+		}
+		session.mu.Unlock()
+	}
+}
+
+// sessionLocked returns a tenant's session, creating one if it doesn't exist.
+// The caller must hold s.mu for writing; it does not lock the session.
+func (s *LotteryService) sessionLocked(tenantID string) *LotterySession {
 	session, exists := s.sessions[tenantID]
 	if !exists {
 		session = &LotterySession{
-			Prizes:         make([]*models.Prize, 0),
-			Participants:   make([]*models.Participant, 0),
-			Winners:        make(map[string]bool),
-			LotteryResults: make([]*models.LotteryResult, 0),
+			Prizes:          make([]*models.Prize, 0),
+			Participants:    make([]*models.Participant, 0),
+			Winners:         make(map[string]map[string]bool),
+			LotteryResults:  make([]*models.LotteryResult, 0),
+			Excluded:        make(map[string]bool),
+			MinParticipants: 1,
+			LastActivity:    time.Now(),
 		}
+		session.markChanged()
 		s.sessions[tenantID] = session
 	}
-	session.LastActivity = time.Now()
 	return session
 }
 
-// GetPrizes returns the prizes for a specific tenant.
+// snapshotSessions returns every session with the ID it is kept under. The
+// sessions are not locked; lock each one with its mu before reading it.
+func (s *LotteryService) snapshotSessions() map[string]*LotterySession {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return maps.Clone(s.sessions)
+}
+
+// GetPrizes returns a snapshot of the prizes for a specific tenant, sorted by Order.
+// Prizes with the same Order keep their insertion order.
 func (s *LotteryService) GetPrizes(tenantID string) []*models.Prize {
-	return s.getSession(tenantID).Prizes
+	session := s.lockSession(tenantID)
+	defer session.mu.Unlock()
+	prizes := copyPrizes(session.Prizes)
+	slices.SortStableFunc(prizes, func(a, b *models.Prize) int { return a.Order - b.Order })
+	return prizes
 }
 
-// GetParticipants returns the participants for a specific tenant.
+// GetParticipants returns a snapshot of the participants for a specific tenant.
 func (s *LotteryService) GetParticipants(tenantID string) []*models.Participant {
-	return s.getSession(tenantID).Participants
+	session := s.lockSession(tenantID)
+	defer session.mu.Unlock()
+	return copyParticipants(session.Participants)
+}
+
+// GetParticipantsPage returns one page of the participants whose ID or Name
+// contains query (case-insensitive; an empty query matches everyone), together
+// with the total number of matches. An offset past the end yields an empty page;
+// a limit <= 0 returns every match from offset on.
+func (s *LotteryService) GetParticipantsPage(tenantID string, query string, offset, limit int) ([]*models.Participant, int) {
+	session := s.lockSession(tenantID)
+	defer session.mu.Unlock()
+
+	query = strings.ToLower(strings.TrimSpace(query))
+	var matches []*models.Participant
+	for _, p := range session.Participants {
+		if query == "" || strings.Contains(strings.ToLower(p.ID), query) || strings.Contains(strings.ToLower(p.Name), query) {
+			matches = append(matches, p)
+		}
+	}
+
+	total := len(matches)
+	offset = min(max(offset, 0), total)
+	end := total
+	if limit > 0 {
+		end = min(offset+limit, total)
+	}
+	return copyParticipants(matches[offset:end]), total
 }
 
-// GetLotteryResults returns the lottery results for a specific tenant.
+// GetLotteryResults returns a snapshot of the lottery results for a specific tenant.
 func (s *LotteryService) GetLotteryResults(tenantID string) []*models.LotteryResult {
-	return s.getSession(tenantID).LotteryResults
+	session := s.lockSession(tenantID)
+	defer session.mu.Unlock()
+	return copyResults(session.LotteryResults)
 }
 
-// AddPrize adds a new prize for a specific tenant.
-func (s *LotteryService) AddPrize(tenantID, name, item string, quantity int, drawFromAll bool) {
-	session := s.getSession(tenantID)
-	session.Prizes = append(session.Prizes, &models.Prize{Name: name, Item: item, Quantity: quantity, DrawFromAll: drawFromAll})
+// GetResultsGroupedByPrize returns copies of the results keyed by prize name.
+// Within each prize the results keep their draw order.
+func (s *LotteryService) GetResultsGroupedByPrize(tenantID string) map[string][]*models.LotteryResult {
+	session := s.lockSession(tenantID)
+	defer session.mu.Unlock()
+	grouped := make(map[string][]*models.LotteryResult)
+	for _, r := range allResults(session) {
+		grouped[r.PrizeName] = append(grouped[r.PrizeName], copyResult(r))
+	}
+	return grouped
 }
 
-// AddParticipant adds a new participant for a specific tenant.
-func (s *LotteryService) AddParticipant(tenantID, id, name string) {
-	session := s.getSession(tenantID)
-	for _, p := range session.Participants {
-		if p.ID == id {
-			return
+// GetParticipantWins returns copies of the results won by a participant, in draw
+// order. A participant without wins gets an empty slice; an ID that is not on
+// the roster returns ErrParticipantNotFound.
+func (s *LotteryService) GetParticipantWins(tenantID, participantID string) ([]*models.LotteryResult, error) {
+	session := s.lockSession(tenantID)
+	defer session.mu.Unlock()
+
+	if !slices.ContainsFunc(session.Participants, func(p *models.Participant) bool { return p.ID == participantID }) {
+		return nil, ErrParticipantNotFound
+	}
+	wins := []*models.LotteryResult{}
+	for _, r := range allResults(session) {
+		if r.WinnerID == participantID {
+			wins = append(wins, copyResult(r))
 		}
 	}
-	session.Participants = append(session.Participants, &models.Participant{ID: id, Name: name})
+	return wins, nil
 }
 
-// Draw performs the lottery draw for a specific tenant and prize.
-func (s *LotteryService) Draw(tenantID, prizeName string) (*models.LotteryResult, error) {
-	session := s.getSession(tenantID)
+// GetSetupStatus derives the onboarding progress for a specific tenant.
+func (s *LotteryService) GetSetupStatus(tenantID string) models.SetupStatus {
+	session := s.lockSession(tenantID)
+	defer session.mu.Unlock()
 
-	var targetPrize *models.Prize
+	status := models.SetupStatus{
+		HasPrizes:       len(session.Prizes) > 0,
+		HasParticipants: len(session.Participants) > 0,
+	}
 	for _, p := range session.Prizes {
-		if p.Name == prizeName {
-			targetPrize = p
+		if p.Quantity > 0 {
+			status.ReadyToDraw = status.HasParticipants
 			break
 		}
 	}
 
-	if targetPrize == nil {
-		return nil, errors.New("指定的獎項不存在")
+	switch {
+	case !status.HasPrizes:
+		status.Step = models.SetupStepPrizes
+	case !status.HasParticipants:
+		status.Step = models.SetupStepParticipants
+	default:
+		status.Step = models.SetupStepReady
 	}
+	return status
+}
 
-	if targetPrize.Quantity <= 0 {
-		return nil, errors.New("該獎項已被抽完")
+// ErrDuplicatePrize is returned when a prize with the same name already exists.
+var ErrDuplicatePrize = i18n.NewError("prize_duplicate")
+
+// ErrInvalidQuantity is returned, wrapped with the quantity, when a new prize
+// would start with nothing to draw.
+var ErrInvalidQuantity = i18n.NewError("prize_invalid_quantity")
+
+// AddPrize adds a new prize for a specific tenant. Prize names identify prizes
+// for drawing, undo and deletion, so ErrDuplicatePrize is returned if the name is
+// taken. The name must not be blank and the quantity must be positive.
+func (s *LotteryService) AddPrize(tenantID, name, item string, quantity int, drawFromAll bool) error {
+	return s.InsertPrize(tenantID, models.Prize{Name: name, Item: item, Quantity: quantity, DrawFromAll: drawFromAll})
+}
+
+// InsertPrize adds a fully specified prize for a specific tenant, for callers that
+// need options beyond AddPrize's arguments. It validates the prize like
+// AddPrize and also rejects an ImageURL that is not http(s) or a local path.
+// Every way of creating a prize, including the CSV and JSON imports, goes through here.
+func (s *LotteryService) InsertPrize(tenantID string, prize models.Prize) error {
+	if err := validatePrize(prize); err != nil {
+		return err
 	}
 
-	eligibleParticipants, err := s.GetEligibleParticipants(tenantID, prizeName)
-	if err != nil {
-		return nil, err
+	session := s.lockSession(tenantID)
+	defer session.mu.Unlock()
+	if findPrize(session, prize.Name) != nil {
+		return ErrDuplicatePrize
+	}
+	prize.OriginalQuantity = prize.Quantity
+	prize.ExcludeWinnersOf = slices.Clone(prize.ExcludeWinnersOf)
+	prize.Items = slices.Clone(prize.Items)
+	prize.Order = 0
+	for _, p := range session.Prizes {
+		prize.Order = max(prize.Order, p.Order+1)
+	}
+	session.Prizes = append(session.Prizes, &prize)
+	session.invalidateEligible()
+	s.logFor(tenantID).Infof("added prize %q (quantity %d)", prize.Name, prize.Quantity)
+	return nil
+}
+
+// validatePrize checks the fields of a prize about to be created.
+func validatePrize(prize models.Prize) error {
+	if strings.TrimSpace(prize.Name) == "" {
+		return i18n.NewError("prize_name_empty")
+	}
+	if prize.Quantity <= 0 {
+		return i18n.NewError("prize_quantity_input", ErrInvalidQuantity, prize.Quantity)
+	}
+	if len(prize.Items) > 0 && prize.Item == "" && prize.Quantity > len(prize.Items) {
+		return i18n.NewError("prize_items_short", len(prize.Items), prize.Quantity)
+	}
+	if prize.MaxWinners < 0 {
+		return i18n.NewError("prize_max_winners")
 	}
+	return validateImageURL(prize.ImageURL)
+}
 
-	winnerIndex := rand.Intn(len(eligibleParticipants))
-	winner := eligibleParticipants[winnerIndex]
+// validateImageURL accepts an empty URL, an absolute http(s) URL or a path on
+// this server, so a prize photo can never smuggle in a javascript: link.
+func validateImageURL(raw string) error {
+	if raw == "" {
+		return nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https" && !(u.Scheme == "" && u.Host == "" && strings.HasPrefix(u.Path, "/"))) {
+		return i18n.NewError("prize_image_url", raw)
+	}
+	return nil
+}
 
-	targetPrize.Quantity--
-	session.Winners[winner.ID] = true
+// UpdatePrize edits a prize in place. newQuantity is the prize's total quantity,
+// including units already awarded, so it cannot be lower than the number of
+// results drawn for the prize; the remaining quantity is adjusted accordingly.
+func (s *LotteryService) UpdatePrize(tenantID, prizeName string, newItem string, newQuantity int, newDrawFromAll bool) error {
+	session := s.lockSession(tenantID)
+	defer session.mu.Unlock()
 
-	result := &models.LotteryResult{
-		PrizeName:  targetPrize.Name,
-		PrizeItem:  targetPrize.Item,
-		WinnerID:   winner.ID,
-		WinnerName: winner.Name,
+	prize := findPrize(session, prizeName)
+	if prize == nil {
+		return i18n.NewError(i18n.WithDetail, ErrPrizeNotFound, prizeName)
 	}
-	session.LotteryResults = append(session.LotteryResults, result)
 
-	return result, nil
+	awarded := 0
+	for _, r := range allResults(session) {
+		if r.PrizeName == prizeName {
+			awarded++
+		}
+	}
+	if newQuantity < awarded {
+		return i18n.NewError("prize_below_awarded", awarded)
+	}
+	if len(prize.Items) > 0 && newItem == "" && newQuantity > len(prize.Items) {
+		return i18n.NewError("prize_items_short", len(prize.Items), newQuantity)
+	}
+
+	prize.Item = newItem
+	prize.Quantity = newQuantity - awarded
+	prize.OriginalQuantity = newQuantity
+	prize.DrawFromAll = newDrawFromAll
+	session.invalidateEligible()
+	s.logFor(tenantID).Infof("updated prize %q (quantity %d, %d awarded)", prizeName, newQuantity, awarded)
+	return nil
 }
 
-// GetEligibleParticipants returns a slice of participants eligible for a specific prize draw.
-func (s *LotteryService) GetEligibleParticipants(tenantID, prizeName string) ([]*models.Participant, error) {
-	session := s.getSession(tenantID)
+// RenamePrize changes a prize's name, which is also the key of its wins: the
+// winners, results (archived ones included), reservations and other prizes'
+// ExcludeWinnersOf lists are rewritten to the new name, so eligibility is the
+// same as before. The audit log keeps the name that was current at the time.
+func (s *LotteryService) RenamePrize(tenantID, oldName, newName string) error {
+	newName = strings.TrimSpace(newName)
+	if newName == "" {
+		return i18n.NewError("prize_name_empty")
+	}
 
-	var targetPrize *models.Prize
+	session := s.lockSession(tenantID)
+	defer session.mu.Unlock()
+
+	prize := findPrize(session, oldName)
+	if prize == nil {
+		return i18n.NewError(i18n.WithDetail, ErrPrizeNotFound, oldName)
+	}
+	if newName == oldName {
+		return nil
+	}
+	if findPrize(session, newName) != nil {
+		return ErrDuplicatePrize
+	}
+
+	prize.Name = newName
+	for _, wins := range session.Winners {
+		if wins[oldName] {
+			delete(wins, oldName)
+			wins[newName] = true
+		}
+	}
+	for _, r := range allResults(session) {
+		if r.PrizeName == oldName {
+			r.PrizeName = newName
+		}
+	}
+	if ids, ok := session.Reservations[oldName]; ok {
+		delete(session.Reservations, oldName)
+		session.Reservations[newName] = ids
+	}
 	for _, p := range session.Prizes {
+		for i, name := range p.ExcludeWinnersOf {
+			if name == oldName {
+				p.ExcludeWinnersOf[i] = newName
+			}
+		}
+	}
+	session.invalidateEligible()
+	s.logFor(tenantID).Infof("renamed prize %q to %q", oldName, newName)
+	return nil
+}
+
+// ReorderPrizes sets the display order of a tenant's prizes: the named prizes come
+// first, in the given order, followed by any unnamed prizes in their current order.
+// Unknown or repeated names are rejected without changing anything.
+func (s *LotteryService) ReorderPrizes(tenantID string, orderedNames []string) error {
+	session := s.lockSession(tenantID)
+	defer session.mu.Unlock()
+
+	position := make(map[string]int, len(orderedNames))
+	for i, name := range orderedNames {
+		if findPrize(session, name) == nil {
+			return i18n.NewError("prize_order_unknown", name)
+		}
+		if _, dup := position[name]; dup {
+			return i18n.NewError("prize_order_repeated", name)
+		}
+		position[name] = i
+	}
+
+	current := slices.Clone(session.Prizes)
+	slices.SortStableFunc(current, func(a, b *models.Prize) int { return a.Order - b.Order })
+	next := len(orderedNames)
+	for _, p := range current {
+		if i, ok := position[p.Name]; ok {
+			p.Order = i
+		} else {
+			p.Order = next
+			next++
+		}
+	}
+	session.markChanged()
+	s.logFor(tenantID).Infof("reordered prizes: %q", orderedNames)
+	return nil
+}
+
+// DeletePrize removes a prize so it can no longer be drawn. Results already drawn
+// for it are kept (including in the CSV export), and its winners still count as
+// having won for the non-DrawFromAll rule.
+func (s *LotteryService) DeletePrize(tenantID, prizeName string) error {
+	session := s.lockSession(tenantID)
+	defer session.mu.Unlock()
+
+	for i, p := range session.Prizes {
 		if p.Name == prizeName {
-			targetPrize = p
-			break
+			session.Prizes = append(session.Prizes[:i], session.Prizes[i+1:]...)
+			delete(session.Reservations, prizeName)
+			session.invalidateEligible()
+			s.logFor(tenantID).Infof("deleted prize %q", prizeName)
+			return nil
 		}
 	}
-	if targetPrize == nil {
-		return nil, errors.New("指定的獎項不存在")
+	return i18n.NewError(i18n.WithDetail, ErrPrizeNotFound, prizeName)
+}
+
+// ErrDuplicateParticipant is returned when a participant ID is already on the roster.
+var ErrDuplicateParticipant = i18n.NewError("participant_duplicate")
+
+// ErrParticipantNotFound is returned when a participant ID is not on the roster.
+var ErrParticipantNotFound = i18n.NewError("participant_not_found")
+
+// normalizeParticipantID trims surrounding whitespace from a participant ID and
+// rejects IDs that are empty or contain commas or line breaks, which would corrupt
+// the CSV export.
+func normalizeParticipantID(id string) (string, error) {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return "", i18n.NewError("participant_id_empty")
+	}
+	if strings.ContainsAny(id, ",\r\n") {
+		return "", i18n.NewError("participant_id_invalid", id)
 	}
+	return id, nil
+}
 
-	var eligibleParticipants []*models.Participant
-	if targetPrize.DrawFromAll {
-		eligibleParticipants = session.Participants
-	} else {
-		for _, p := range session.Participants {
-			if !session.Winners[p.ID] {
-				eligibleParticipants = append(eligibleParticipants, p)
+// AddParticipant adds a new participant for a specific tenant. The ID is
+// normalized first; ErrDuplicateParticipant is returned if it is already taken.
+func (s *LotteryService) AddParticipant(tenantID, id, name string) error {
+	return s.InsertParticipant(tenantID, models.Participant{ID: id, Name: name})
+}
+
+// InsertParticipant adds a fully specified participant (e.g. with a Weight) for a
+// specific tenant, with the same ID normalization and duplicate check as AddParticipant.
+// New participants are always added as present; see SetPresence.
+func (s *LotteryService) InsertParticipant(tenantID string, participant models.Participant) error {
+	id, err := normalizeParticipantID(participant.ID)
+	if err != nil {
+		return err
+	}
+	participant.ID = id
+	participant.Present = true
+
+	session := s.lockSession(tenantID)
+	defer session.mu.Unlock()
+	for _, p := range session.Participants {
+		if p.ID == participant.ID {
+			return ErrDuplicateParticipant
+		}
+	}
+	session.Participants = append(session.Participants, &participant)
+	session.invalidateEligible()
+	s.metrics.participantsAdded.Add(1)
+	return nil
+}
+
+// UpsertParticipant adds the participant like InsertParticipant, or, when the
+// ID already exists, replaces that participant's Name and reports updated. The
+// rest of the existing participant, including past results, is left as it is.
+func (s *LotteryService) UpsertParticipant(tenantID string, participant models.Participant) (updated bool, err error) {
+	id, err := normalizeParticipantID(participant.ID)
+	if err != nil {
+		return false, err
+	}
+	participant.ID = id
+	participant.Present = true
+
+	session := s.lockSession(tenantID)
+	defer session.mu.Unlock()
+	for _, p := range session.Participants {
+		if p.ID == participant.ID {
+			if p.Name != participant.Name {
+				s.logFor(tenantID).Infof("renamed participant %q", p.ID)
 			}
+			p.Name = participant.Name
+			session.invalidateEligible()
+			return true, nil
 		}
 	}
+	session.Participants = append(session.Participants, &participant)
+	session.invalidateEligible()
+	s.metrics.participantsAdded.Add(1)
+	return false, nil
+}
 
-	if len(eligibleParticipants) == 0 {
-		return nil, errors.New("沒有符合資格的參與者可供抽獎")
+// SetPresence marks a participant as present or absent. Absent participants
+// stay on the roster but are skipped by every draw.
+func (s *LotteryService) SetPresence(tenantID, participantID string, present bool) error {
+	session := s.lockSession(tenantID)
+	defer session.mu.Unlock()
+
+	for _, p := range session.Participants {
+		if p.ID == participantID {
+			if p.Present != present {
+				p.Present = present
+				session.invalidateEligible()
+				s.logFor(tenantID).Infof("marked participant %q present=%t", participantID, present)
+			}
+			return nil
+		}
 	}
+	return ErrParticipantNotFound
+}
 
-	return eligibleParticipants, nil
+// RemoveParticipant deletes a participant from the roster and purges their win
+// records, absent-winner exclusion and reservations, so the ID starts fresh if
+// it is added again. Recorded results are kept.
+func (s *LotteryService) RemoveParticipant(tenantID, participantID string) error {
+	session := s.lockSession(tenantID)
+	defer session.mu.Unlock()
+
+	for i, p := range session.Participants {
+		if p.ID == participantID {
+			session.Participants = append(session.Participants[:i], session.Participants[i+1:]...)
+			delete(session.Winners, participantID)
+			delete(session.Excluded, participantID)
+			for prizeName, ids := range session.Reservations {
+				if ids = slices.DeleteFunc(ids, func(id string) bool { return id == participantID }); len(ids) > 0 {
+					session.Reservations[prizeName] = ids
+				} else {
+					delete(session.Reservations, prizeName)
+				}
+			}
+			session.invalidateEligible()
+			s.logFor(tenantID).Infof("removed participant %q", participantID)
+			return nil
+		}
+	}
+	return ErrParticipantNotFound
 }
 
-// CleanUpInactiveSessions removes sessions that have been inactive for over an hour.
-func (s *LotteryService) CleanUpInactiveSessions() {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+// AddParticipants bulk-inserts participants for a specific tenant, skipping invalid
+// IDs and IDs that already exist (including duplicates within the batch after
+// normalization). It returns how many were added and the indexes into
+// participants of those skipped as duplicates.
+func (s *LotteryService) AddParticipants(tenantID string, participants []*models.Participant) (added int, duplicates []int) {
+	session := s.lockSession(tenantID)
+	defer session.mu.Unlock()
 
-	for tenantID, session := range s.sessions {
-		if time.Since(session.LastActivity) > time.Hour {
-			logger.Infof("sessions: %+v, tenantID: %+v", s.sessions, tenantID)
-			delete(s.sessions, tenantID)
+	existing := make(map[string]bool, len(session.Participants)+len(participants))
+	for _, p := range session.Participants {
+		existing[p.ID] = true
+	}
+
+	for i, p := range participants {
+		id, err := normalizeParticipantID(p.ID)
+		if err != nil {
+			continue
 		}
+		if existing[id] {
+			duplicates = append(duplicates, i)
+			continue
+		}
+		existing[id] = true
+		c := *p
+		c.ID = id
+		c.Present = true
+		session.Participants = append(session.Participants, &c)
+		added++
+	}
+	if added > 0 {
+		session.invalidateEligible()
 	}
+	s.metrics.participantsAdded.Add(int64(added))
+	s.logFor(tenantID).Infof("bulk-added %d of %d participants", added, len(participants))
+	return added, duplicates
 }
 
-// ClearSession removes all data associated with a specific tenant.
-func (s *LotteryService) ClearSession(tenantID string) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	delete(s.sessions, tenantID)
-	logger.Infof("Cleared session for tenant: %s", tenantID)
+// MergeParticipants folds the participant mergeID into keepID, for when the same
+// person was imported under two IDs. Results won by mergeID are reassigned to keepID
+// and mergeID is removed from the roster. If both IDs won the same prize, the
+// duplicate result of mergeID is dropped and its prize unit returned to the pool;
+// the merge is refused with ErrResultLocked if that duplicate result is locked.
+// keepID inherits mergeID's exclusion, absence and reservations: if either record
+// was excluded or marked absent, the merged participant is too.
+func (s *LotteryService) MergeParticipants(tenantID, keepID, mergeID string) error {
+	if keepID == mergeID {
+		return i18n.NewError("participant_merge_self")
+	}
+
+	session := s.lockSession(tenantID)
+	defer session.mu.Unlock()
+
+	var keep, merge *models.Participant
+	mergeIndex := -1
+	for i, p := range session.Participants {
+		switch p.ID {
+		case keepID:
+			keep = p
+		case mergeID:
+			merge, mergeIndex = p, i
+		}
+	}
+	if keep == nil || mergeIndex < 0 {
+		return ErrParticipantNotFound
+	}
+
+	// A result of mergeID is a duplicate if keepID already won the same prize.
+	keepWins := session.Winners[keepID]
+	for _, r := range session.LotteryResults {
+		if r.WinnerID == mergeID && keepWins[r.PrizeName] && r.Locked {
+			return ErrResultLocked
+		}
+	}
+	for _, r := range session.ArchivedResults { // Archived results are final and cannot be dropped
+		if r.WinnerID == mergeID && keepWins[r.PrizeName] {
+			return ErrResultLocked
+		}
+	}
+	var reassigned, dropped []*models.LotteryResult
+	for _, r := range session.ArchivedResults {
+		if r.WinnerID == mergeID {
+			r.WinnerID = keep.ID
+			r.WinnerName = keep.Name
+			reassigned = append(reassigned, r)
+		}
+	}
+
+	results := session.LotteryResults[:0]
+	for _, r := range session.LotteryResults {
+		if r.WinnerID == mergeID {
+			if keepWins[r.PrizeName] {
+				if prize := findPrize(session, r.PrizeName); prize != nil {
+					prize.Quantity++
+				}
+				dropped = append(dropped, r)
+				continue
+			}
+			r.WinnerID = keep.ID
+			r.WinnerName = keep.Name
+			reassigned = append(reassigned, r)
+		}
+		results = append(results, r)
+	}
+	session.LotteryResults = results
+
+	if mergeWins := session.Winners[mergeID]; len(mergeWins) > 0 {
+		if session.Winners[keepID] == nil {
+			session.Winners[keepID] = make(map[string]bool)
+		}
+		for prizeName := range mergeWins {
+			session.Winners[keepID][prizeName] = true
+		}
+	}
+	delete(session.Winners, mergeID)
+
+	if session.Excluded[mergeID] {
+		session.Excluded[keepID] = true
+	}
+	delete(session.Excluded, mergeID)
+	keep.Present = keep.Present && merge.Present
+	for prizeName, ids := range session.Reservations {
+		if i := slices.Index(ids, mergeID); i >= 0 {
+			if slices.Contains(ids, keepID) {
+				session.Reservations[prizeName] = slices.Delete(ids, i, i+1)
+			} else {
+				ids[i] = keepID
+			}
+		}
+	}
+
+	session.Participants = append(session.Participants[:mergeIndex], session.Participants[mergeIndex+1:]...)
+	session.invalidateEligible()
+	s.logFor(tenantID).Infof("merged participant %q into %q", mergeID, keepID)
+	if len(dropped) > 0 {
+		audit(tenantID, session, AuditUndo, dropped...)
+		s.publish(tenantID, EventUndo, dropped...)
+	}
+	if len(reassigned) > 0 {
+		audit(tenantID, session, AuditMerge, reassigned...)
+		s.publish(tenantID, EventMerge, reassigned...)
+	}
+	return nil
+}
+
+// SetMinParticipants sets how many participants must be registered before any
+// draw is allowed for a tenant. Values below 1 are treated as 1.
+func (s *LotteryService) SetMinParticipants(tenantID string, n int) {
+	if n < 1 {
+		n = 1
+	}
+	session := s.lockSession(tenantID)
+	defer session.mu.Unlock()
+	session.MinParticipants = n
+	s.logFor(tenantID).Infof("set minimum participants to %d", n)
+}
+
+// GetMinParticipants returns the minimum roster size required to draw for a tenant.
+func (s *LotteryService) GetMinParticipants(tenantID string) int {
+	session := s.lockSession(tenantID)
+	defer session.mu.Unlock()
+	return session.MinParticipants
+}
+
+// SetMaxWins caps how many prizes one participant can win in total, across all
+// prizes and regardless of DrawFromAll. Zero or a negative value removes the cap.
+func (s *LotteryService) SetMaxWins(tenantID string, max int) {
+	if max < 0 {
+		max = 0
+	}
+	session := s.lockSession(tenantID)
+	defer session.mu.Unlock()
+	session.MaxWinsPerParticipant = max
+	session.invalidateEligible()
+	s.logFor(tenantID).Infof("set maximum wins per participant to %d", max)
+}
+
+// GetMaxWins returns the per-participant win cap for a tenant; 0 means unlimited.
+func (s *LotteryService) GetMaxWins(tenantID string) int {
+	session := s.lockSession(tenantID)
+	defer session.mu.Unlock()
+	return session.MaxWinsPerParticipant
+}
+
+// SetUniqueAcrossAll controls whether one person can win several DrawFromAll
+// prizes. When set, every prize, DrawFromAll or not, excludes anyone who has
+// already won a prize; when cleared, DrawFromAll prizes only exclude their own winners.
+func (s *LotteryService) SetUniqueAcrossAll(tenantID string, v bool) {
+	session := s.lockSession(tenantID)
+	defer session.mu.Unlock()
+	session.UniqueAcrossAll = v
+	session.invalidateEligible()
+	s.logFor(tenantID).Infof("set unique winners across all prizes to %t", v)
+}
+
+// GetUniqueAcrossAll reports whether a tenant limits everyone to a single win.
+func (s *LotteryService) GetUniqueAcrossAll(tenantID string) bool {
+	session := s.lockSession(tenantID)
+	defer session.mu.Unlock()
+	return session.UniqueAcrossAll
+}
+
+// SetAllowRepeatWins controls whether someone who has won a prize can still
+// win the other prizes. By default only DrawFromAll prizes allow that; when
+// set, every prize behaves like one and only excludes its own winners.
+// SetUniqueAcrossAll takes precedence over it.
+func (s *LotteryService) SetAllowRepeatWins(tenantID string, v bool) {
+	session := s.lockSession(tenantID)
+	defer session.mu.Unlock()
+	session.AllowRepeatWins = v
+	session.invalidateEligible()
+	s.logFor(tenantID).Infof("set repeat wins across prizes to %t", v)
+}
+
+// GetAllowRepeatWins reports whether a tenant lets winners win other prizes.
+func (s *LotteryService) GetAllowRepeatWins(tenantID string) bool {
+	session := s.lockSession(tenantID)
+	defer session.mu.Unlock()
+	return session.AllowRepeatWins
+}
+
+// SetNoConsecutiveRepeat controls whether the winner of the most recent draw
+// is left out of the next one, so nobody wins two prizes back to back even
+// where the other rules would allow it. If that winner is the only eligible
+// participant, they stay eligible.
+func (s *LotteryService) SetNoConsecutiveRepeat(tenantID string, v bool) {
+	session := s.lockSession(tenantID)
+	defer session.mu.Unlock()
+	session.NoConsecutiveRepeat = v
+	session.invalidateEligible()
+	s.logFor(tenantID).Infof("set no consecutive repeat wins to %t", v)
+}
+
+// GetNoConsecutiveRepeat reports whether a tenant keeps the last winner out of the next draw.
+func (s *LotteryService) GetNoConsecutiveRepeat(tenantID string) bool {
+	session := s.lockSession(tenantID)
+	defer session.mu.Unlock()
+	return session.NoConsecutiveRepeat
+}
+
+// SetMaskIDs controls whether winner displays hide all but the last two
+// characters of participant IDs. Exports are never masked.
+func (s *LotteryService) SetMaskIDs(tenantID string, v bool) {
+	session := s.lockSession(tenantID)
+	defer session.mu.Unlock()
+	session.MaskIDs = v
+	s.logFor(tenantID).Infof("set ID masking to %t", v)
+}
+
+// GetMaskIDs reports whether a tenant's winner displays mask participant IDs.
+func (s *LotteryService) GetMaskIDs(tenantID string) bool {
+	session := s.lockSession(tenantID)
+	defer session.mu.Unlock()
+	return session.MaskIDs
+}
+
+// MaskID replaces all but the last two characters of id with asterisks, e.g.
+// "123445" becomes "****45". IDs of two characters or fewer are masked entirely,
+// since showing their last two would show all of them.
+func MaskID(id string) string {
+	runes := []rune(id)
+	if len(runes) <= 2 {
+		return strings.Repeat("*", len(runes))
+	}
+	return strings.Repeat("*", len(runes)-2) + string(runes[len(runes)-2:])
+}
+
+// SetSeed switches a tenant to reproducible draws: from now on winners are picked
+// from a math/rand source seeded with seed, so the same seed, roster order and
+// draw sequence always yield the same winners. The seed is stored in the session.
+func (s *LotteryService) SetSeed(tenantID string, seed int64) {
+	session := s.lockSession(tenantID)
+	defer session.mu.Unlock()
+	session.Seed = &seed
+	session.rng = rand.New(rand.NewSource(seed))
+	s.logFor(tenantID).Infof("set draw seed to %d", seed)
+}
+
+// ClearSeed returns a tenant to the secure, non-reproducible default.
+func (s *LotteryService) ClearSeed(tenantID string) {
+	session := s.lockSession(tenantID)
+	defer session.mu.Unlock()
+	session.Seed = nil
+	session.rng = nil
+	s.logFor(tenantID).Infof("cleared draw seed")
+}
+
+// GetSeed returns the tenant's draw seed, or nil if draws are not seeded.
+func (s *LotteryService) GetSeed(tenantID string) *int64 {
+	session := s.lockSession(tenantID)
+	defer session.mu.Unlock()
+	if seed := session.Seed; seed != nil {
+		v := *seed
+		return &v
+	}
+	return nil
+}
+
+// checkMinParticipants rejects a draw while the roster is below the configured
+// minimum. An empty roster is reported as ErrNoParticipants.
+func checkMinParticipants(session *LotterySession) error {
+	if len(session.Participants) == 0 {
+		return ErrNoParticipants
+	}
+	if len(session.Participants) < session.MinParticipants {
+		return i18n.NewError("min_participants", len(session.Participants), session.MinParticipants)
+	}
+	return nil
+}
+
+// ReserveWinner pre-assigns a prize to a participant, e.g. for a sponsored award.
+// Reservations for a prize are queued and honored in order by the next draws of
+// that prize, each consuming one unit like a normal win. A reserved participant
+// who is no longer eligible when the draw happens is skipped and the draw falls
+// back to random selection. A prize cannot hold more reservations than it has units left.
+func (s *LotteryService) ReserveWinner(tenantID, prizeName, participantID string) error {
+	session := s.lockSession(tenantID)
+	defer session.mu.Unlock()
+
+	prize := findPrize(session, prizeName)
+	if prize == nil {
+		return i18n.NewError(i18n.WithDetail, ErrPrizeNotFound, prizeName)
+	}
+	if prize.AllRemaining {
+		return i18n.NewError("reserve_all_remaining")
+	}
+	if !slices.ContainsFunc(session.Participants, func(p *models.Participant) bool { return p.ID == participantID }) {
+		return ErrParticipantNotFound
+	}
+	reserved := session.Reservations[prizeName]
+	if slices.Contains(reserved, participantID) {
+		return i18n.NewError("reserve_duplicate")
+	}
+	if len(reserved) >= prize.Quantity {
+		return i18n.NewError("reserve_full")
+	}
+
+	if session.Reservations == nil {
+		session.Reservations = make(map[string][]string)
+	}
+	session.Reservations[prizeName] = append(reserved, participantID)
+	s.logFor(tenantID).Infof("reserved prize %q for participant %q", prizeName, participantID)
+	return nil
+}
+
+// takeReservation pops the prize's queued reservations until one names a
+// participant in pool, and returns that participant's index in pool. It returns
+// -1 when no reservation can be honored. The caller must hold the session's mu.
+func (s *LotteryService) takeReservation(tenantID string, session *LotterySession, prizeName string, pool []*models.Participant) int {
+	for len(session.Reservations[prizeName]) > 0 {
+		id := session.Reservations[prizeName][0]
+		if rest := session.Reservations[prizeName][1:]; len(rest) > 0 {
+			session.Reservations[prizeName] = rest
+		} else {
+			delete(session.Reservations, prizeName)
+		}
+		if i := slices.IndexFunc(pool, func(p *models.Participant) bool { return p.ID == id }); i >= 0 {
+			return i
+		}
+		s.logFor(tenantID).Infof("dropped reservation of prize %q for ineligible participant %q", prizeName, id)
+	}
+	return -1
+}
+
+// Draw performs the lottery draw for a specific tenant and prize. The eligibility
+// check and the recording of the win happen under one hold of the service lock,
+// so concurrent draws can never hand out the same remaining unit twice.
+func (s *LotteryService) Draw(tenantID, prizeName string) (*models.LotteryResult, error) {
+	session := s.lockSession(tenantID)
+	defer session.mu.Unlock()
+	return s.drawLocked(tenantID, session, prizeName)
+}
+
+// drawLocked implements Draw. The caller must hold the session's mu.
+func (s *LotteryService) drawLocked(tenantID string, session *LotterySession, prizeName string) (*models.LotteryResult, error) {
+	result, err := s.drawWinnerLocked(tenantID, session, prizeName)
+	if err != nil {
+		return nil, err
+	}
+	s.metrics.draws.Add(1)
+	s.logFor(tenantID).Infof("drew participant %q for prize %q (result %s)", result.WinnerID, prizeName, result.ID)
+	audit(tenantID, session, AuditDraw, result)
+	s.publish(tenantID, EventDraw, result)
+	s.archiveLocked(tenantID, session)
+	return copyResult(result), nil
+}
+
+// drawWinnerLocked picks and records one winner for the prize without auditing,
+// publishing or counting the draw, and returns the session's own result. The
+// caller must hold the session's mu.
+func (s *LotteryService) drawWinnerLocked(tenantID string, session *LotterySession, prizeName string) (*models.LotteryResult, error) {
+	targetPrize := findPrize(session, prizeName)
+	if targetPrize == nil {
+		return nil, i18n.NewError(i18n.WithDetail, ErrPrizeNotFound, prizeName)
+	}
+
+	if targetPrize.Quantity <= 0 {
+		return nil, i18n.NewError(i18n.WithDetail, ErrPrizeExhausted, prizeName)
+	}
+
+	if targetPrize.AllRemaining {
+		return nil, i18n.NewError("prize_all_remaining")
+	}
+
+	if err := checkMinParticipants(session); err != nil {
+		return nil, err
+	}
+
+	eligibleParticipants, err := eligibleLocked(session, targetPrize)
+	if err != nil {
+		return nil, err
+	}
+
+	winnerIndex := s.takeReservation(tenantID, session, prizeName, eligibleParticipants)
+	if winnerIndex < 0 {
+		winnerIndex, err = weightedIndex(eligibleParticipants, session.intn)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return recordWin(session, targetPrize, eligibleParticipants[winnerIndex]), nil
+}
+
+// maxRevealDecoys caps how many names DrawWithReveal returns for the reveal animation.
+const maxRevealDecoys = 30
+
+// DrawReveal is the outcome of DrawWithReveal.
+type DrawReveal struct {
+	Result *models.LotteryResult
+	// Decoys is a shuffled sample of the names that were eligible for the draw,
+	// for the front end to cycle through before revealing. It always contains the winner.
+	Decoys []string
+}
+
+// DrawWithReveal performs a single draw like Draw and also returns up to
+// maxRevealDecoys names sampled from the pool the winner was drawn from, so
+// the reveal animation only ever shows people who could actually have won.
+func (s *LotteryService) DrawWithReveal(tenantID, prizeName string) (*DrawReveal, error) {
+	session := s.lockSession(tenantID)
+	defer session.mu.Unlock()
+
+	// Snapshot the pool before the draw removes the winner from it. Any error is
+	// reported by drawLocked below.
+	var pool []*models.Participant
+	if prize := findPrize(session, prizeName); prize != nil && prize.Quantity > 0 {
+		eligible, _ := eligibleLocked(session, prize)
+		pool = slices.Clone(eligible)
+	}
+
+	result, err := s.drawLocked(tenantID, session, prizeName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Pick the other names with a partial Fisher-Yates shuffle, then shuffle the
+	// winner in so its position says nothing about the outcome.
+	others := slices.DeleteFunc(pool, func(p *models.Participant) bool { return p.ID == result.WinnerID })
+	n := min(len(others), maxRevealDecoys-1)
+	for i := 0; i < n; i++ {
+		j, err := session.intn(len(others) - i)
+		if err != nil {
+			return nil, err
+		}
+		others[i], others[i+j] = others[i+j], others[i]
+	}
+	decoys := make([]string, 0, n+1)
+	for _, p := range others[:n] {
+		decoys = append(decoys, p.Name)
+	}
+	decoys = append(decoys, result.WinnerName)
+	for i := len(decoys) - 1; i > 0; i-- {
+		j, err := session.intn(i + 1)
+		if err != nil {
+			return nil, err
+		}
+		decoys[i], decoys[j] = decoys[j], decoys[i]
+	}
+	return &DrawReveal{Result: result, Decoys: decoys}, nil
+}
+
+// DrawBatch draws up to count distinct winners for a prize in one operation.
+// Winners are picked with a partial Fisher-Yates shuffle over the eligible pool,
+// weighted by Participant.Weight, so nobody is chosen twice within a batch. The batch never exceeds the prize's
+// remaining quantity; if fewer than count winners could be drawn, the winners
+// that were drawn are returned together with an error explaining the shortfall.
+// For AllRemaining prizes count is ignored and every eligible participant wins.
+func (s *LotteryService) DrawBatch(tenantID, prizeName string, count int) ([]*models.LotteryResult, error) {
+	session := s.lockSession(tenantID)
+	defer session.mu.Unlock()
+	return s.drawBatchLocked(tenantID, session, prizeName, count)
+}
+
+// DrawGroup draws n winners of a prize to be revealed together, e.g. on one
+// screen. It is DrawBatch without partial results: if the prize has fewer than
+// n units left or fewer than n eligible participants, nothing is drawn. Each
+// winner is still recorded as its own result, sharing the batch's BatchID.
+func (s *LotteryService) DrawGroup(tenantID, prizeName string, n int) ([]*models.LotteryResult, error) {
+	if n <= 0 {
+		return nil, i18n.NewError("draw_count_invalid")
+	}
+
+	session := s.lockSession(tenantID)
+	defer session.mu.Unlock()
+
+	targetPrize := findPrize(session, prizeName)
+	if targetPrize == nil {
+		return nil, i18n.NewError(i18n.WithDetail, ErrPrizeNotFound, prizeName)
+	}
+	if targetPrize.Quantity <= 0 {
+		return nil, i18n.NewError(i18n.WithDetail, ErrPrizeExhausted, prizeName)
+	}
+	if err := checkMinParticipants(session); err != nil {
+		return nil, err
+	}
+	eligible, err := eligibleLocked(session, targetPrize)
+	if err != nil {
+		return nil, err
+	}
+	if !targetPrize.AllRemaining && (n > targetPrize.Quantity || n > len(eligible)) {
+		return nil, i18n.NewError("draw_group_too_large", n, targetPrize.Quantity, len(eligible))
+	}
+	return s.drawBatchLocked(tenantID, session, prizeName, n)
+}
+
+// DrawBatchOnce is DrawBatch guarded by an idempotency key, for requests that
+// may be submitted twice. The first call with a key draws; repeating the key
+// within idempotencyWindow returns the original results and error without drawing
+// again. Draws that produced no winner are not remembered. An empty key disables the guard.
+func (s *LotteryService) DrawBatchOnce(tenantID, key, prizeName string, count int) ([]*models.LotteryResult, error) {
+	session := s.lockSession(tenantID)
+	defer session.mu.Unlock()
+	if key == "" {
+		return s.drawBatchLocked(tenantID, session, prizeName, count)
+	}
+
+	now := time.Now()
+	for k, d := range session.processedDraws {
+		if now.Sub(d.at) > idempotencyWindow {
+			delete(session.processedDraws, k)
+		}
+	}
+	if d, ok := session.processedDraws[key]; ok {
+		s.logFor(tenantID).Infof("replayed draw for idempotency key %q", key)
+		return copyResults(d.results), d.err
+	}
+
+	results, err := s.drawBatchLocked(tenantID, session, prizeName, count)
+	if len(results) > 0 { // A draw that consumed nothing is safe to retry with the same key
+		if session.processedDraws == nil {
+			session.processedDraws = make(map[string]processedDraw)
+		}
+		session.processedDraws[key] = processedDraw{at: now, results: copyResults(results), err: err}
+	}
+	return results, err
+}
+
+// drawBatchLocked implements DrawBatch. The caller must hold the session's mu.
+func (s *LotteryService) drawBatchLocked(tenantID string, session *LotterySession, prizeName string, count int) ([]*models.LotteryResult, error) {
+	if count <= 0 {
+		return nil, i18n.NewError("draw_count_invalid")
+	}
+
+	targetPrize := findPrize(session, prizeName)
+	if targetPrize == nil {
+		return nil, i18n.NewError(i18n.WithDetail, ErrPrizeNotFound, prizeName)
+	}
+
+	if targetPrize.Quantity <= 0 {
+		return nil, i18n.NewError(i18n.WithDetail, ErrPrizeExhausted, prizeName)
+	}
+
+	if err := checkMinParticipants(session); err != nil {
+		return nil, err
+	}
+
+	eligibleParticipants, err := eligibleLocked(session, targetPrize)
+	if err != nil {
+		return nil, err
+	}
+
+	// Shuffle a copy so the session's participant order is left untouched.
+	pool := make([]*models.Participant, len(eligibleParticipants))
+	copy(pool, eligibleParticipants)
+
+	if targetPrize.AllRemaining {
+		targetPrize.Quantity = len(pool)
+		count = len(pool)
+	}
+
+	n := count
+	if n > targetPrize.Quantity {
+		n = targetPrize.Quantity
+	}
+	if n > len(pool) && targetPrize.MaxWinners == 0 {
+		n = len(pool)
+	}
+
+	// Batches of more than one winner share a BatchID so they can be undone together.
+	batchID := ""
+	if n > 1 {
+		batchID = fmt.Sprintf("batch-%d", session.ResultSeq+1)
+	}
+	results := make([]*models.LotteryResult, 0, n)
+	var drawErr error
+	for i := 0; i < n; i++ {
+		if targetPrize.MaxWinners > 0 {
+			// Every win can change who is eligible next, so start each pick afresh.
+			pool = append(pool[:i], computeEligible(session, targetPrize)...)
+			if len(pool) == i {
+				n = i
+				break
+			}
+		}
+		j := s.takeReservation(tenantID, session, prizeName, pool[i:])
+		if j < 0 {
+			j, err = weightedIndex(pool[i:], session.intn)
+			if err != nil {
+				drawErr = err
+				break
+			}
+		}
+		pool[i], pool[i+j] = pool[i+j], pool[i]
+		result := recordWin(session, targetPrize, pool[i])
+		result.BatchID = batchID
+		results = append(results, copyResult(result))
+	}
+	s.metrics.draws.Add(int64(len(results)))
+	s.logFor(tenantID).Infof("batch-drew %d of %d winners for prize %q", len(results), count, prizeName)
+	if len(results) > 0 {
+		audit(tenantID, session, AuditDraw, results...)
+	}
+	if len(results) == 1 {
+		s.publish(tenantID, EventDraw, results...)
+	} else if len(results) > 1 {
+		s.publish(tenantID, EventBatchDraw, results...)
+	}
+	s.archiveLocked(tenantID, session)
+
+	if drawErr != nil {
+		return results, drawErr
+	}
+	if n < count {
+		return results, i18n.NewError("draw_shortfall", n, count)
+	}
+	return results, nil
+}
+
+// DrawAllRemaining draws every remaining prize unit in display order, each prize
+// as one batch, until the prize is exhausted or nobody eligible is left. It
+// returns all results and, per prize name, how many units are still undrawn;
+// prizes that were fully drawn are not listed. Running out of eligible
+// participants is not an error; the error is only set when drawing could not
+// start at all.
+func (s *LotteryService) DrawAllRemaining(tenantID string) ([]*models.LotteryResult, map[string]int, error) {
+	session := s.lockSession(tenantID)
+	defer session.mu.Unlock()
+
+	if err := checkMinParticipants(session); err != nil {
+		return nil, nil, err
+	}
+
+	prizes := slices.Clone(session.Prizes)
+	slices.SortStableFunc(prizes, func(a, b *models.Prize) int { return a.Order - b.Order })
+
+	var results []*models.LotteryResult
+	remaining := make(map[string]int)
+	for _, p := range prizes {
+		if p.Quantity <= 0 {
+			continue
+		}
+		drawn, _ := s.drawBatchLocked(tenantID, session, p.Name, p.Quantity) // Shortfalls are reported through remaining
+		results = append(results, drawn...)
+		if p.Quantity > 0 {
+			remaining[p.Name] = p.Quantity
+		}
+	}
+	s.logFor(tenantID).Infof("drew all remaining prizes: %d winners, %d prizes left undrawn", len(results), len(remaining))
+	return results, remaining, nil
+}
+
+// AllocatePrizes draws every remaining prize unit from a single shuffle of the
+// roster: the participants are put in one weighted random order, then each
+// prize, in display order, goes to the first participants in that order who
+// are still eligible for it. Unlike DrawAllRemaining, the whole allocation
+// consumes one shuffle, so a seeded session allocates the same winners for the
+// same roster and prizes. Reservations are honored first. Prizes left short of
+// eligible participants keep their remaining quantity; all results share one
+// BatchID.
+func (s *LotteryService) AllocatePrizes(tenantID string) ([]*models.LotteryResult, error) {
+	session := s.lockSession(tenantID)
+	defer session.mu.Unlock()
+
+	if len(session.Participants) == 0 {
+		return nil, ErrNoParticipants
+	}
+	if err := checkMinParticipants(session); err != nil {
+		return nil, err
+	}
+
+	// A weighted shuffle: repeatedly move a weighted pick to the front of the rest.
+	order := slices.Clone(session.Participants)
+	for i := range order {
+		j, err := weightedIndex(order[i:], session.intn)
+		if err != nil {
+			return nil, err
+		}
+		order[i], order[i+j] = order[i+j], order[i]
+	}
+
+	prizes := slices.Clone(session.Prizes)
+	slices.SortStableFunc(prizes, func(a, b *models.Prize) int { return a.Order - b.Order })
+
+	var recorded []*models.LotteryResult
+	for _, p := range prizes {
+		if p.Quantity <= 0 {
+			continue // Exhausted, including AllRemaining prizes already drawn; see DrawBatch
+		}
+		pool := allocationPool(session, p, order)
+		if p.AllRemaining {
+			p.Quantity = len(pool)
+		}
+		for p.Quantity > 0 && len(pool) > 0 {
+			i := s.takeReservation(tenantID, session, p.Name, pool)
+			if i < 0 {
+				i = 0
+			}
+			recorded = append(recorded, recordWin(session, p, pool[i]))
+			if p.MaxWinners > 0 {
+				pool = allocationPool(session, p, order) // Winners come back once the limit is reached
+			} else {
+				pool = slices.Delete(pool, i, i+1)
+			}
+		}
+	}
+
+	results := make([]*models.LotteryResult, len(recorded))
+	for i, r := range recorded {
+		if len(recorded) > 1 {
+			r.BatchID = "batch-" + recorded[0].ID
+		}
+		results[i] = copyResult(r)
+	}
+	s.metrics.draws.Add(int64(len(results)))
+	s.logFor(tenantID).Infof("allocated %d winners from one shuffle", len(results))
+	if len(results) > 0 {
+		audit(tenantID, session, AuditDraw, results...)
+	}
+	if len(results) == 1 {
+		s.publish(tenantID, EventDraw, results...)
+	} else if len(results) > 1 {
+		s.publish(tenantID, EventBatchDraw, results...)
+	}
+	s.archiveLocked(tenantID, session)
+	return results, nil
+}
+
+// allocationPool returns the participants eligible for prize in the shuffled
+// order used by AllocatePrizes. The caller must hold the session's mu.
+func allocationPool(session *LotterySession, prize *models.Prize, order []*models.Participant) []*models.Participant {
+	eligible := make(map[string]bool)
+	for _, e := range computeEligible(session, prize) {
+		eligible[e.ID] = true
+	}
+	var pool []*models.Participant
+	for _, candidate := range order {
+		if eligible[candidate.ID] {
+			pool = append(pool, candidate)
+		}
+	}
+	return pool
+}
+
+// DrawTransaction draws one winner for each named prize as a single
+// all-or-nothing operation, e.g. for a button covering a whole round. All draws
+// happen under one hold of the lock; if any prize cannot be drawn, every win
+// recorded so far is rolled back and the error names the first failing prize.
+// Listing a prize twice draws two of its units. The results of a transaction
+// with more than one winner share a BatchID, so UndoBatch reverts them together.
+// A seeded session's random sequence is not rewound by a rollback.
+func (s *LotteryService) DrawTransaction(tenantID string, prizeNames []string) ([]*models.LotteryResult, error) {
+	if len(prizeNames) == 0 {
+		return nil, i18n.NewError("draw_no_prizes")
+	}
+
+	session := s.lockSession(tenantID)
+	defer session.mu.Unlock()
+
+	// takeReservation only reslices or deletes entries, so a shallow copy is
+	// enough to put consumed reservations back.
+	reservations := maps.Clone(session.Reservations)
+	seq := session.ResultSeq
+	drawn := make([]*models.LotteryResult, 0, len(prizeNames))
+	for _, name := range prizeNames {
+		result, err := s.drawWinnerLocked(tenantID, session, name)
+		if err != nil {
+			session.LotteryResults = session.LotteryResults[:len(session.LotteryResults)-len(drawn)]
+			for i := len(drawn) - 1; i >= 0; i-- {
+				reverseWin(session, drawn[i])
+			}
+			session.ResultSeq = seq
+			session.Reservations = reservations
+			s.logFor(tenantID).Infof("rolled back draw transaction at prize %q: %v", name, err)
+			return nil, i18n.NewError("draw_transaction_failed", name, err)
+		}
+		drawn = append(drawn, result)
+	}
+
+	results := make([]*models.LotteryResult, len(drawn))
+	for i, r := range drawn {
+		if len(drawn) > 1 {
+			r.BatchID = fmt.Sprintf("batch-%d", seq+1)
+		}
+		results[i] = copyResult(r)
+	}
+	s.metrics.draws.Add(int64(len(results)))
+	s.logFor(tenantID).Infof("drew a transaction of %d prizes", len(results))
+	audit(tenantID, session, AuditDraw, results...)
+	if len(results) == 1 {
+		s.publish(tenantID, EventDraw, results...)
+	} else {
+		s.publish(tenantID, EventBatchDraw, results...)
+	}
+	s.archiveLocked(tenantID, session)
+	return results, nil
+}
+
+// findPrize returns the prize with the given name, or nil if the session has none.
+func findPrize(session *LotterySession, prizeName string) *models.Prize {
+	for _, p := range session.Prizes {
+		if p.Name == prizeName {
+			return p
+		}
+	}
+	return nil
+}
+
+// recordWin consumes one unit of the prize, marks the participant as a winner
+// and appends the resulting LotteryResult to the session. See nextPrizeItem for
+// the item the result records.
+func recordWin(session *LotterySession, prize *models.Prize, winner *models.Participant) *models.LotteryResult {
+	item := nextPrizeItem(session, prize)
+	prize.Quantity--
+	if session.Winners[winner.ID] == nil {
+		session.Winners[winner.ID] = make(map[string]bool)
+	}
+	session.Winners[winner.ID][prize.Name] = true
+	session.invalidateEligible()
+
+	session.ResultSeq++
+	result := &models.LotteryResult{
+		ID:         strconv.Itoa(session.ResultSeq),
+		PrizeName:  prize.Name,
+		PrizeItem:  item,
+		WinnerID:   winner.ID,
+		WinnerName: winner.Name,
+		DrawnAt:    time.Now(),
+	}
+	session.LotteryResults = append(session.LotteryResults, result)
+	return result
+}
+
+// nextPrizeItem returns the item the prize's next winner receives: the first of
+// its Items no current result holds, so an undone win frees its item for the
+// next draw, or Item once all of them are taken or the prize has no Items.
+func nextPrizeItem(session *LotterySession, prize *models.Prize) string {
+	if len(prize.Items) == 0 {
+		return prize.Item
+	}
+	taken := make(map[string]int)
+	for _, r := range allResults(session) {
+		if r.PrizeName == prize.Name {
+			taken[r.PrizeItem]++
+		}
+	}
+	for _, item := range prize.Items {
+		if taken[item] > 0 {
+			taken[item]-- // Items may repeat in the list; each entry is one unit
+			continue
+		}
+		return item
+	}
+	return prize.Item
+}
+
+// LockResult marks a result as final. Locked results are rejected with
+// ErrResultLocked by any operation that would undo or replace them.
+func (s *LotteryService) LockResult(tenantID, resultID string) error {
+	session := s.lockSession(tenantID)
+	defer session.mu.Unlock()
+	for _, r := range allResults(session) {
+		if r.ID == resultID {
+			r.Locked = true
+			s.logFor(tenantID).Infof("locked result %s", resultID)
+			return nil
+		}
+	}
+	return i18n.NewError("result_not_found")
+}
+
+// MarkClaimed records that the winner of a result has collected their prize.
+// Claiming an already claimed result is a no-op and keeps the original ClaimedAt.
+func (s *LotteryService) MarkClaimed(tenantID, resultID string) error {
+	session := s.lockSession(tenantID)
+	defer session.mu.Unlock()
+	for _, r := range allResults(session) {
+		if r.ID == resultID {
+			if !r.Claimed {
+				now := time.Now()
+				r.Claimed = true
+				r.ClaimedAt = &now
+				s.logFor(tenantID).Infof("result %s claimed (prize %q, participant %q)", resultID, r.PrizeName, r.WinnerID)
+			}
+			return nil
+		}
+	}
+	return i18n.NewError("result_not_found")
+}
+
+// UndoLastDraw reverses the most recent draw: the result is removed, the prize
+// quantity is restored and the winner becomes eligible for that prize again.
+func (s *LotteryService) UndoLastDraw(tenantID string) (*models.LotteryResult, error) {
+	session := s.lockSession(tenantID)
+	defer session.mu.Unlock()
+
+	if len(session.LotteryResults) == 0 {
+		return nil, i18n.NewError("nothing_to_undo")
+	}
+	last := session.LotteryResults[len(session.LotteryResults)-1]
+	if last.Locked {
+		return nil, ErrResultLocked
+	}
+
+	session.LotteryResults = session.LotteryResults[:len(session.LotteryResults)-1]
+	reverseWin(session, last)
+	s.logFor(tenantID).Infof("undid result %s (prize %q, participant %q)", last.ID, last.PrizeName, last.WinnerID)
+	audit(tenantID, session, AuditUndo, last)
+	s.publish(tenantID, EventUndo, last)
+	return copyResult(last), nil
+}
+
+// RedrawWinner voids the result of a winner who is not present to accept the
+// prize and draws a replacement for the same slot. The absent winner is added
+// to the session's excluded set so no later draw picks them again. If nobody
+// else is eligible, the void stands, the slot stays open and an error is returned.
+func (s *LotteryService) RedrawWinner(tenantID, prizeName, absentWinnerID string) (*models.LotteryResult, error) {
+	session := s.lockSession(tenantID)
+	defer session.mu.Unlock()
+
+	prize := findPrize(session, prizeName)
+	if prize == nil {
+		return nil, i18n.NewError(i18n.WithDetail, ErrPrizeNotFound, prizeName)
+	}
+
+	index := -1
+	for i, r := range session.LotteryResults {
+		if r.PrizeName == prizeName && r.WinnerID == absentWinnerID {
+			index = i
+		}
+	}
+	if index < 0 {
+		return nil, i18n.NewError("win_not_found")
+	}
+	absent := session.LotteryResults[index]
+	if absent.Locked {
+		return nil, ErrResultLocked
+	}
+
+	session.LotteryResults = append(session.LotteryResults[:index], session.LotteryResults[index+1:]...)
+	reverseWin(session, absent)
+	session.Excluded[absentWinnerID] = true
+	s.logFor(tenantID).Infof("voided result %s of absent participant %q for prize %q", absent.ID, absentWinnerID, prizeName)
+
+	eligibleParticipants, err := eligibleLocked(session, prize)
+	if err != nil {
+		audit(tenantID, session, AuditUndo, absent)
+		s.publish(tenantID, EventUndo, absent)
+		return nil, err
+	}
+	winnerIndex, err := weightedIndex(eligibleParticipants, session.intn)
+	if err != nil {
+		audit(tenantID, session, AuditUndo, absent)
+		s.publish(tenantID, EventUndo, absent)
+		return nil, err
+	}
+	result := recordWin(session, prize, eligibleParticipants[winnerIndex])
+	result.BatchID = absent.BatchID // The replacement fills the same slot of the batch
+	s.metrics.draws.Add(1)
+	s.logFor(tenantID).Infof("redrew participant %q for prize %q (result %s)", result.WinnerID, prizeName, result.ID)
+	audit(tenantID, session, AuditRedraw, absent, result)
+	s.publish(tenantID, EventRedraw, absent, result)
+	s.archiveLocked(tenantID, session)
+	return copyResult(result), nil
+}
+
+// ResetResults wipes every result for a rehearsal while keeping the prizes and
+// the roster: winners and absent-winner exclusions are forgotten and each prize
+// is restored to its OriginalQuantity. Nothing is changed if any result is locked.
+func (s *LotteryService) ResetResults(tenantID string) error {
+	session := s.lockSession(tenantID)
+	defer session.mu.Unlock()
+
+	for _, r := range allResults(session) {
+		if r.Locked {
+			return ErrResultLocked
+		}
+	}
+
+	s.resetResultsLocked(tenantID, session)
+	return nil
+}
+
+// resetResultsLocked clears every result and restores the prize quantities.
+// Callers must hold the session's mu and have checked that no result is locked.
+func (s *LotteryService) resetResultsLocked(tenantID string, session *LotterySession) {
+	removed := allResults(session)
+	session.LotteryResults = make([]*models.LotteryResult, 0)
+	session.ArchivedResults = nil
+	session.Winners = make(map[string]map[string]bool)
+	session.Excluded = make(map[string]bool)
+	session.processedDraws = nil
+	for _, p := range session.Prizes {
+		p.Quantity = p.OriginalQuantity
+	}
+	session.invalidateEligible()
+
+	audit(tenantID, session, AuditReset, removed...)
+	s.logFor(tenantID).Infof("reset %d results", len(removed))
+	if len(removed) > 0 {
+		s.publish(tenantID, EventReset, removed...)
+	}
+}
+
+// ClearParticipants removes participants in bulk and returns how many were removed.
+//
+// With alsoClearResults set, every participant is removed and the results are
+// reset as by ResetResults (failing with ErrResultLocked if any is locked).
+// Otherwise the results are preserved, so participants who have already won
+// are kept on the roster and only those without a win are removed.
+func (s *LotteryService) ClearParticipants(tenantID string, alsoClearResults bool) (int, error) {
+	session := s.lockSession(tenantID)
+	defer session.mu.Unlock()
+
+	if alsoClearResults {
+		for _, r := range allResults(session) {
+			if r.Locked {
+				return 0, ErrResultLocked
+			}
+		}
+		removed := len(session.Participants)
+		session.Participants = make([]*models.Participant, 0)
+		s.resetResultsLocked(tenantID, session)
+		s.logFor(tenantID).Infof("cleared %d participants", removed)
+		return removed, nil
+	}
+
+	kept := make([]*models.Participant, 0)
+	for _, p := range session.Participants {
+		if len(session.Winners[p.ID]) > 0 {
+			kept = append(kept, p)
+		}
+	}
+	removed := len(session.Participants) - len(kept)
+	session.Participants = kept
+	session.invalidateEligible()
+	s.logFor(tenantID).Infof("cleared %d participants, kept %d winners", removed, len(kept))
+	return removed, nil
+}
+
+// UndoBatch reverses every result of a batch draw as a unit. If any result of
+// the batch is locked, nothing is changed and ErrResultLocked is returned.
+func (s *LotteryService) UndoBatch(tenantID, batchID string) error {
+	session := s.lockSession(tenantID)
+	defer session.mu.Unlock()
+
+	found := false
+	for _, r := range session.LotteryResults {
+		if batchID != "" && r.BatchID == batchID {
+			found = true
+			if r.Locked {
+				return ErrResultLocked
+			}
+		}
+	}
+	if !found {
+		return i18n.NewError("batch_not_found")
+	}
+	if slices.ContainsFunc(session.ArchivedResults, func(r *models.LotteryResult) bool { return r.BatchID == batchID }) {
+		return ErrResultLocked // Part of the batch is archived, and archived results are final
+	}
+
+	var removed []*models.LotteryResult
+	kept := session.LotteryResults[:0]
+	for _, r := range session.LotteryResults {
+		if r.BatchID == batchID {
+			removed = append(removed, r)
+			continue
+		}
+		kept = append(kept, r)
+	}
+	session.LotteryResults = kept
+	for _, r := range removed {
+		reverseWin(session, r)
+	}
+	audit(tenantID, session, AuditUndo, removed...)
+	s.logFor(tenantID).Infof("undid batch %s", batchID)
+	s.publish(tenantID, EventUndo, removed...)
+	return nil
+}
+
+// reverseWin undoes the side effects of recordWin for a result that has been
+// removed from the session: the prize unit is returned and the win forgotten,
+// unless the winner holds another unit of the same prize.
+func reverseWin(session *LotterySession, result *models.LotteryResult) {
+	if prize := findPrize(session, result.PrizeName); prize != nil {
+		if prize.AllRemaining {
+			prize.Quantity = 1 // Reopen the prize; its quantity is recomputed on the next batch
+		} else {
+			prize.Quantity++
+		}
+	}
+	// A prize with MaxWinners may have gone to the same winner more than once.
+	stillWon := slices.ContainsFunc(allResults(session), func(r *models.LotteryResult) bool {
+		return r != result && r.WinnerID == result.WinnerID && r.PrizeName == result.PrizeName
+	})
+	if wins := session.Winners[result.WinnerID]; wins != nil && !stillWon {
+		delete(wins, result.PrizeName)
+		if len(wins) == 0 {
+			delete(session.Winners, result.WinnerID)
+		}
+	}
+	session.invalidateEligible()
+}
+
+// GetEligibleParticipants returns a slice of participants eligible for a specific prize draw.
+// DrawFromAll prizes draw from everyone who has not yet won that same prize;
+// other prizes draw only from participants who have not won anything.
+// Prizes with a Group only consider participants of that group. Participants
+// who reached MaxWinsPerParticipant or were excluded by RedrawWinner are never eligible.
+//
+// A prize with MaxWinners goes to that many distinct participants first; from
+// then on only its winners are eligible, those holding the fewest units first.
+// For example 10 headphones with MaxWinners 5 go to 5 people, 2 each.
+func (s *LotteryService) GetEligibleParticipants(tenantID, prizeName string) ([]*models.Participant, error) {
+	session := s.lockSession(tenantID)
+	defer session.mu.Unlock()
+
+	targetPrize := findPrize(session, prizeName)
+	if targetPrize == nil {
+		return nil, i18n.NewError(i18n.WithDetail, ErrPrizeNotFound, prizeName)
+	}
+
+	eligibleParticipants, err := eligibleLocked(session, targetPrize)
+	if err != nil {
+		return nil, err
+	}
+	return copyParticipants(eligibleParticipants), nil
+}
+
+// PreviewEligible is a dry run of Draw: it applies the same checks and returns
+// who could win the prize right now, without consuming a unit or recording anything.
+func (s *LotteryService) PreviewEligible(tenantID, prizeName string) ([]*models.Participant, error) {
+	session := s.lockSession(tenantID)
+	defer session.mu.Unlock()
+
+	targetPrize := findPrize(session, prizeName)
+	if targetPrize == nil {
+		return nil, i18n.NewError(i18n.WithDetail, ErrPrizeNotFound, prizeName)
+	}
+	if targetPrize.Quantity <= 0 {
+		return nil, i18n.NewError(i18n.WithDetail, ErrPrizeExhausted, prizeName)
+	}
+	if err := checkMinParticipants(session); err != nil {
+		return nil, err
+	}
+
+	eligibleParticipants, err := eligibleLocked(session, targetPrize)
+	if err != nil {
+		return nil, err
+	}
+	return copyParticipants(eligibleParticipants), nil
+}
+
+// CountEligible reports how many participants could currently win a prize. It
+// ignores the remaining quantity, so an exhausted prize still reports its pool.
+func (s *LotteryService) CountEligible(tenantID, prizeName string) (int, error) {
+	session := s.lockSession(tenantID)
+	defer session.mu.Unlock()
+
+	targetPrize := findPrize(session, prizeName)
+	if targetPrize == nil {
+		return 0, i18n.NewError(i18n.WithDetail, ErrPrizeNotFound, prizeName)
+	}
+	eligibleParticipants, err := eligibleLocked(session, targetPrize)
+	if err != nil {
+		return 0, nil // An empty pool is a valid count, not a failure
+	}
+	return len(eligibleParticipants), nil
+}
+
+// DrawOdds reports how many participants could win the next draw of a prize
+// and the chance that a given one of them does. With weights the chance is
+// that of a participant of weight 1, and a participant of weight w has w
+// times it; without them it is simply 1/eligible. An exhausted prize or an
+// empty pool is reported with the error Draw would return.
+func (s *LotteryService) DrawOdds(tenantID, prizeName string) (eligible int, odds float64, err error) {
+	session := s.lockSession(tenantID)
+	defer session.mu.Unlock()
+
+	targetPrize := findPrize(session, prizeName)
+	if targetPrize == nil {
+		return 0, 0, i18n.NewError(i18n.WithDetail, ErrPrizeNotFound, prizeName)
+	}
+	if targetPrize.Quantity <= 0 {
+		return 0, 0, i18n.NewError(i18n.WithDetail, ErrPrizeExhausted, prizeName)
+	}
+	eligibleParticipants, err := eligibleLocked(session, targetPrize)
+	if err != nil {
+		return 0, 0, err
+	}
+	total := 0
+	for _, p := range eligibleParticipants {
+		total += p.EffectiveWeight()
+	}
+	return len(eligibleParticipants), 1 / float64(total), nil
+}
+
+// PrizeSummary is the draw progress of one prize, as reported by GetPrizeSummary.
+type PrizeSummary struct {
+	Name             string `json:"name"`
+	Item             string `json:"item"`
+	OriginalQuantity int    `json:"originalQuantity"`
+	Remaining        int    `json:"remaining"`
+	Awarded          int    `json:"awarded"`  // Results currently recorded for the prize
+	Eligible         int    `json:"eligible"` // Participants who could win the next draw of it
+}
+
+// GetPrizeSummary reports, for every prize in display order, how many units
+// were configured, awarded and remain, and how many participants are eligible.
+// It changes nothing.
+func (s *LotteryService) GetPrizeSummary(tenantID string) []PrizeSummary {
+	session := s.lockSession(tenantID)
+	defer session.mu.Unlock()
+
+	awarded := make(map[string]int)
+	for _, r := range allResults(session) {
+		awarded[r.PrizeName]++
+	}
+	prizes := slices.Clone(session.Prizes)
+	slices.SortStableFunc(prizes, func(a, b *models.Prize) int { return a.Order - b.Order })
+
+	summary := make([]PrizeSummary, 0, len(prizes))
+	for _, p := range prizes {
+		eligible, _ := eligibleLocked(session, p) // An empty pool counts as zero
+		summary = append(summary, PrizeSummary{
+			Name:             p.Name,
+			Item:             p.Item,
+			OriginalQuantity: p.OriginalQuantity,
+			Remaining:        max(p.Quantity, 0),
+			Awarded:          awarded[p.Name],
+			Eligible:         len(eligible),
+		})
+	}
+	return summary
+}
+
+// GetDrawProgress reports how far the tenant's event has come: drawn is the
+// number of results recorded, archived ones included, for the current prizes
+// and total is the sum of those prizes' configured quantities. Drawing raises
+// drawn and undoing lowers it, while total only changes with the prizes.
+func (s *LotteryService) GetDrawProgress(tenantID string) (drawn, total int) {
+	session := s.lockSession(tenantID)
+	defer session.mu.Unlock()
+
+	current := make(map[string]bool, len(session.Prizes))
+	for _, p := range session.Prizes {
+		current[p.Name] = true
+		total += p.OriginalQuantity
+	}
+	for _, r := range allResults(session) {
+		if current[r.PrizeName] {
+			drawn++
+		}
+	}
+	return drawn, total
+}
+
+// ValidateSetup returns ValidateSetupIn's warnings in i18n.Default.
+func (s *LotteryService) ValidateSetup(tenantID string) []string {
+	return s.ValidateSetupIn(i18n.Default, tenantID)
+}
+
+// ValidateSetupIn returns warnings, in lang, about prizes that cannot be
+// fully drawn because their remaining quantity exceeds the current eligible
+// pool. Exhausted and AllRemaining prizes are not checked. An empty result
+// means every prize can be drawn out as configured.
+func (s *LotteryService) ValidateSetupIn(lang i18n.Lang, tenantID string) []string {
+	session := s.lockSession(tenantID)
+	defer session.mu.Unlock()
+
+	prizes := slices.Clone(session.Prizes)
+	slices.SortStableFunc(prizes, func(a, b *models.Prize) int { return a.Order - b.Order })
+
+	var warnings []string
+	for _, p := range prizes {
+		if p.Quantity <= 0 || p.AllRemaining {
+			continue
+		}
+		eligible, _ := eligibleLocked(session, p)
+		if p.Quantity > len(eligible) {
+			warnings = append(warnings, i18n.T(lang, "setup_over_pool", p.Name, p.Quantity, len(eligible)))
+		}
+	}
+	return warnings
+}
+
+// eligibleLocked returns the eligible pool for a prize, served from the session's
+// cache when possible. The returned slice is shared with the cache and must not be modified.
+func eligibleLocked(session *LotterySession, targetPrize *models.Prize) ([]*models.Participant, error) {
+	if len(session.Participants) == 0 {
+		return nil, ErrNoParticipants
+	}
+	eligibleParticipants, cached := session.eligibleCache[targetPrize.Name]
+	if !cached {
+		eligibleParticipants = computeEligible(session, targetPrize)
+		if session.eligibleCache == nil {
+			session.eligibleCache = make(map[string][]*models.Participant)
+		}
+		session.eligibleCache[targetPrize.Name] = eligibleParticipants
+	}
+
+	if len(eligibleParticipants) == 0 {
+		return nil, i18n.NewError(i18n.WithDetail, ErrNoEligibleParticipants, targetPrize.Name)
+	}
+
+	return eligibleParticipants, nil
+}
+
+// computeEligible applies the eligibility rules for a prize to the session's roster.
+func computeEligible(session *LotterySession, targetPrize *models.Prize) []*models.Participant {
+	var eligibleParticipants []*models.Participant
+	if counts := prizeWinCounts(session, targetPrize); len(counts) > 0 && len(counts) >= targetPrize.MaxWinners {
+		eligibleParticipants = repeatWinners(session, targetPrize, counts)
+	} else {
+		eligibleParticipants = firstTimeEligible(session, targetPrize)
+	}
+	if last := lastResult(session); session.NoConsecutiveRepeat && last != nil {
+		rest := slices.DeleteFunc(slices.Clone(eligibleParticipants), func(p *models.Participant) bool { return p.ID == last.WinnerID })
+		if len(rest) > 0 {
+			eligibleParticipants = rest
+		}
+	}
+	return eligibleParticipants
+}
+
+// firstTimeEligible returns the participants who may win targetPrize under the
+// session's rules when no winner limit applies; see GetEligibleParticipants.
+func firstTimeEligible(session *LotterySession, targetPrize *models.Prize) []*models.Participant {
+	var eligibleParticipants []*models.Participant
+	totals := winTotals(session)
+	for _, p := range session.Participants {
+		if exclusionReason(session, targetPrize, p, totals, false) == nil {
+			eligibleParticipants = append(eligibleParticipants, p)
+		}
+	}
+	return eligibleParticipants
+}
+
+// prizeWinCounts returns how many units of prize each of its winners holds, or
+// nil when the prize has no MaxWinners limit.
+func prizeWinCounts(session *LotterySession, prize *models.Prize) map[string]int {
+	if prize.MaxWinners <= 0 {
+		return nil
+	}
+	counts := make(map[string]int)
+	for _, r := range allResults(session) {
+		if r.PrizeName == prize.Name {
+			counts[r.WinnerID]++
+		}
+	}
+	return counts
+}
+
+// repeatWinners returns the participants in counts who still pass
+// exclusionReason for prize and hold the fewest units, so the units of a prize
+// that has reached MaxWinners are spread evenly over its winners.
+func repeatWinners(session *LotterySession, prize *models.Prize, counts map[string]int) []*models.Participant {
+	var pool []*models.Participant
+	fewest := 0
+	totals := winTotals(session)
+	for _, p := range session.Participants {
+		n, won := counts[p.ID]
+		if !won || exclusionReason(session, prize, p, totals, true) != nil {
+			continue
+		}
+		if len(pool) == 0 || n < fewest {
+			pool, fewest = pool[:0], n
+		}
+		if n == fewest {
+			pool = append(pool, p)
+		}
+	}
+	return pool
+}
+
+// lastResult returns the session's most recent result, or nil if nothing has been drawn.
+func lastResult(session *LotterySession) *models.LotteryResult {
+	if n := len(session.LotteryResults); n > 0 {
+		return session.LotteryResults[n-1]
+	}
+	if n := len(session.ArchivedResults); n > 0 {
+		return session.ArchivedResults[n-1]
+	}
+	return nil
+}
+
+// CleanUpInactiveSessions removes sessions that have been inactive for longer
+// than the session TTL and returns how many it removed. Each eviction is logged
+// with the tenant ID and the number of sessions left only; the evicted
+// session's contents are logged too once SetCleanupDebug is enabled.
+func (s *LotteryService) CleanUpInactiveSessions() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	evicted := 0
+	for tenantID, session := range s.sessions {
+		// A session busy with an operation is active, so it is skipped rather
+		// than waited for while every other tenant is locked out of s.mu.
+		if !session.mu.TryLock() {
+			continue
+		}
+		if time.Since(session.LastActivity) > s.sessionTTL {
+			delete(s.sessions, tenantID)
+			evicted++
+			s.metrics.evictions.Add(1)
+			s.logFor(tenantID).Infof("evicted session inactive since %s, %d sessions remain", session.LastActivity.Format(time.RFC3339), len(s.sessions))
+			if s.cleanupDebug {
+				dump, _ := json.Marshal(session)
+				s.logFor(tenantID).Infof("evicted session contents: %s", dump)
+			}
+		}
+		session.mu.Unlock()
+	}
+	return evicted
+}
+
+// SetCleanupDebug makes CleanUpInactiveSessions also log the full contents of
+// every session it evicts, participant names included. It is meant for
+// debugging only and must be called before the service is shared.
+func (s *LotteryService) SetCleanupDebug(enabled bool) {
+	s.cleanupDebug = enabled
+}
+
+// ServiceStats summarizes every session held by the service.
+type ServiceStats struct {
+	Sessions     int `json:"sessions"`
+	Participants int `json:"participants"` // Summed over all sessions
+}
+
+// Stats counts the active sessions and their participants. It locks one
+// session at a time and does not touch any session's LastActivity.
+func (s *LotteryService) Stats() ServiceStats {
+	sessions := s.snapshotSessions()
+	stats := ServiceStats{Sessions: len(sessions)}
+	for _, session := range sessions {
+		session.mu.Lock()
+		stats.Participants += len(session.Participants)
+		session.mu.Unlock()
+	}
+	return stats
+}
+
+// SessionInfo describes one tenant's session for the admin view.
+type SessionInfo struct {
+	TenantID     string    `json:"tenantId"`
+	LastActivity time.Time `json:"lastActivity"`
+	Prizes       int       `json:"prizes"`
+	Participants int       `json:"participants"`
+	Results      int       `json:"results"` // Archived results included
+}
+
+// ListSessions describes every session, sorted by tenant ID. Like Stats it
+// locks one session at a time and does not touch any session's LastActivity.
+func (s *LotteryService) ListSessions() []SessionInfo {
+	var infos []SessionInfo
+	for tenantID, session := range s.snapshotSessions() {
+		session.mu.Lock()
+		infos = append(infos, SessionInfo{
+			TenantID:     tenantID,
+			LastActivity: session.LastActivity,
+			Prizes:       len(session.Prizes),
+			Participants: len(session.Participants),
+			Results:      len(session.ArchivedResults) + len(session.LotteryResults),
+		})
+		session.mu.Unlock()
+	}
+	slices.SortFunc(infos, func(a, b SessionInfo) int { return strings.Compare(a.TenantID, b.TenantID) })
+	return infos
+}
+
+// RunJanitor calls CleanUpInactiveSessions every interval until ctx is done.
+func (s *LotteryService) RunJanitor(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.CleanUpInactiveSessions()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// ClearSession removes all data associated with a specific tenant.
+func (s *LotteryService) ClearSession(tenantID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, tenantID)
+	s.logFor(tenantID).Infof("cleared session")
+}
+
+// MoveSession re-keys a session from one tenant ID to another, for example when
+// the way tenants are identified changes. It does nothing and reports false if
+// fromID has no session or toID already has one.
+func (s *LotteryService) MoveSession(fromID, toID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, ok := s.sessions[fromID]
+	if !ok {
+		return false
+	}
+	if _, taken := s.sessions[toID]; taken {
+		return false
+	}
+	delete(s.sessions, fromID)
+	s.sessions[toID] = session
+	s.logFor(toID).Infof("moved session from %q", fromID)
+	return true
+}
+
+// Errors returned by CloneConfig.
+var (
+	ErrCloneSourceMissing  = i18n.NewError("clone_source_missing")
+	ErrCloneTargetNotEmpty = i18n.NewError("clone_target_not_empty")
+)
+
+// CloneConfig copies the prizes, participants and draw settings of srcTenantID
+// into a new session for dstTenantID, so the same setup can be run again in
+// another room. Prizes start at their original quantities; results, winners,
+// absences, reservations and the seed are not copied. It fails with
+// ErrCloneTargetNotEmpty if dstTenantID already has prizes, participants or results.
+func (s *LotteryService) CloneConfig(srcTenantID, dstTenantID string) error {
+	s.mu.RLock()
+	_, exists := s.sessions[srcTenantID]
+	s.mu.RUnlock()
+	if !exists {
+		return ErrCloneSourceMissing
+	}
+
+	// Copy under the source lock only, so two clones in opposite directions cannot deadlock.
+	src := s.lockSession(srcTenantID)
+	prizes := copyPrizes(src.Prizes)
+	participants := copyParticipants(src.Participants)
+	settings := LotterySession{
+		MinParticipants:       src.MinParticipants,
+		MaxWinsPerParticipant: src.MaxWinsPerParticipant,
+		UniqueAcrossAll:       src.UniqueAcrossAll,
+		AllowRepeatWins:       src.AllowRepeatWins,
+		NoConsecutiveRepeat:   src.NoConsecutiveRepeat,
+		AnnouncementTemplate:  src.AnnouncementTemplate,
+		MaskIDs:               src.MaskIDs,
+	}
+	src.mu.Unlock()
+	for _, p := range prizes {
+		p.Quantity = p.OriginalQuantity
+	}
+
+	dst := s.lockSession(dstTenantID)
+	defer dst.mu.Unlock()
+	if len(dst.Prizes) > 0 || len(dst.Participants) > 0 || len(allResults(dst)) > 0 {
+		return ErrCloneTargetNotEmpty
+	}
+	dst.Prizes = prizes
+	dst.Participants = participants
+	dst.MinParticipants = settings.MinParticipants
+	dst.MaxWinsPerParticipant = settings.MaxWinsPerParticipant
+	dst.UniqueAcrossAll = settings.UniqueAcrossAll
+	dst.AllowRepeatWins = settings.AllowRepeatWins
+	dst.NoConsecutiveRepeat = settings.NoConsecutiveRepeat
+	dst.AnnouncementTemplate = settings.AnnouncementTemplate
+	dst.MaskIDs = settings.MaskIDs
+	dst.invalidateEligible()
+
+	s.logFor(dstTenantID).Infof("cloned configuration from %q (%d prizes, %d participants)", srcTenantID, len(prizes), len(participants))
+	return nil
+}
+
+// copyPrizes returns deep copies of prizes so callers can read them without holding the lock.
+func copyPrizes(prizes []*models.Prize) []*models.Prize {
+	out := make([]*models.Prize, len(prizes))
+	for i, p := range prizes {
+		c := *p
+		c.ExcludeWinnersOf = slices.Clone(p.ExcludeWinnersOf)
+		c.Items = slices.Clone(p.Items)
+		out[i] = &c
+	}
+	return out
+}
+
+// copyParticipants returns deep copies of participants so callers can read them without holding the lock.
+func copyParticipants(participants []*models.Participant) []*models.Participant {
+	out := make([]*models.Participant, len(participants))
+	for i, p := range participants {
+		c := *p
+		out[i] = &c
+	}
+	return out
+}
+
+// copyResults returns deep copies of results so callers can read them without holding the lock.
+func copyResults(results []*models.LotteryResult) []*models.LotteryResult {
+	out := make([]*models.LotteryResult, len(results))
+	for i, r := range results {
+		out[i] = copyResult(r)
+	}
+	return out
+}
+
+// copyResult returns a copy of a single result.
+func copyResult(r *models.LotteryResult) *models.LotteryResult {
+	c := *r
+	if r.ClaimedAt != nil {
+		claimedAt := *r.ClaimedAt
+		c.ClaimedAt = &claimedAt
+	}
+	return &c
 }
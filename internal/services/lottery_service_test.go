@@ -1,7 +1,19 @@
 package services
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"lottery/internal/i18n"
+	"lottery/internal/models"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestLotteryService_Draw(t *testing.T) {
@@ -34,7 +46,7 @@ func TestLotteryService_Draw(t *testing.T) {
 
 		// Check if winner was recorded
 		session := service.getSession(testTenantID)
-		if !session.Winners[result.WinnerID] {
+		if !session.Winners[result.WinnerID]["大獎"] {
 			t.Errorf("Expected winner %s to be recorded in the winners map", result.WinnerID)
 		}
 
@@ -71,7 +83,7 @@ func TestLotteryService_Draw(t *testing.T) {
 		service.AddPrize(specialTenantID, "特別獎", "手機", 1, true) // DrawFromAll is true
 		service.AddParticipant(specialTenantID, "001", "Alice")
 		session := service.getSession(specialTenantID)
-		session.Winners["001"] = true // Alice is already a winner
+		session.Winners["001"] = map[string]bool{"大獎": true} // Alice is already a winner
 
 		result, err := service.Draw(specialTenantID, "特別獎")
 		if err != nil {
@@ -81,4 +93,2735 @@ func TestLotteryService_Draw(t *testing.T) {
 			t.Errorf("Expected winner to be 001, but got %s", result.WinnerID)
 		}
 	})
-}
\ No newline at end of file
+}
+
+func TestLotteryService_LockResult(t *testing.T) {
+	const testTenantID = "lock-tenant"
+	service := NewLotteryService()
+	service.AddPrize(testTenantID, "頭獎", "汽車", 1, false)
+	service.AddParticipant(testTenantID, "001", "Alice")
+
+	result, err := service.Draw(testTenantID, "頭獎")
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+	if result.ID == "" {
+		t.Fatal("Expected the result to be assigned an ID")
+	}
+
+	t.Run("Test locking an existing result", func(t *testing.T) {
+		if err := service.LockResult(testTenantID, result.ID); err != nil {
+			t.Fatalf("Expected no error, but got %v", err)
+		}
+		if !service.GetLotteryResults(testTenantID)[0].Locked {
+			t.Error("Expected the result to be locked")
+		}
+	})
+
+	t.Run("Test locking an unknown result", func(t *testing.T) {
+		if err := service.LockResult(testTenantID, "does-not-exist"); err == nil {
+			t.Fatal("Expected an error for an unknown result ID, but got nil")
+		}
+	})
+}
+
+func TestLotteryService_GetSetupStatus(t *testing.T) {
+	const testTenantID = "setup-tenant"
+	service := NewLotteryService()
+
+	status := service.GetSetupStatus(testTenantID)
+	if status.Step != models.SetupStepPrizes || status.ReadyToDraw {
+		t.Errorf("Expected a new session to be at the prize step, but got %+v", status)
+	}
+
+	service.AddPrize(testTenantID, "大獎", "電視", 1, false)
+	status = service.GetSetupStatus(testTenantID)
+	if status.Step != models.SetupStepParticipants || !status.HasPrizes || status.ReadyToDraw {
+		t.Errorf("Expected the participant step after adding a prize, but got %+v", status)
+	}
+
+	service.AddParticipant(testTenantID, "001", "Alice")
+	status = service.GetSetupStatus(testTenantID)
+	if status.Step != models.SetupStepReady || !status.ReadyToDraw {
+		t.Errorf("Expected the ready step after adding a participant, but got %+v", status)
+	}
+
+	if _, err := service.Draw(testTenantID, "大獎"); err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+	if service.GetSetupStatus(testTenantID).ReadyToDraw {
+		t.Error("Expected ReadyToDraw to be false once every prize is drawn")
+	}
+}
+
+func TestLotteryService_DrawBatch(t *testing.T) {
+	const testTenantID = "batch-tenant"
+	service := NewLotteryService()
+	service.AddPrize(testTenantID, "參加獎", "紅包", 10, false)
+	service.AddParticipant(testTenantID, "001", "Alice")
+	service.AddParticipant(testTenantID, "002", "Bob")
+	service.AddParticipant(testTenantID, "003", "Charlie")
+
+	t.Run("Test batch draws distinct winners", func(t *testing.T) {
+		results, err := service.DrawBatch(testTenantID, "參加獎", 2)
+		if err != nil {
+			t.Fatalf("Expected no error, but got %v", err)
+		}
+		if len(results) != 2 {
+			t.Fatalf("Expected 2 results, but got %d", len(results))
+		}
+		if results[0].WinnerID == results[1].WinnerID {
+			t.Errorf("Expected distinct winners, but both were %s", results[0].WinnerID)
+		}
+		if q := service.GetPrizes(testTenantID)[0].Quantity; q != 8 {
+			t.Errorf("Expected prize quantity to be 8, but got %d", q)
+		}
+	})
+
+	t.Run("Test partial draw when count exceeds eligible", func(t *testing.T) {
+		results, err := service.DrawBatch(testTenantID, "參加獎", 5)
+		if err == nil {
+			t.Fatal("Expected an error describing the partial draw, but got nil")
+		}
+		if len(results) != 1 {
+			t.Fatalf("Expected 1 partial result, but got %d", len(results))
+		}
+		if q := service.GetPrizes(testTenantID)[0].Quantity; q != 7 {
+			t.Errorf("Expected prize quantity to be 7, but got %d", q)
+		}
+		if n := len(service.GetLotteryResults(testTenantID)); n != 3 {
+			t.Errorf("Expected 3 results in total, but got %d", n)
+		}
+	})
+
+	t.Run("Test batch never exceeds remaining quantity", func(t *testing.T) {
+		service.AddPrize(testTenantID, "特別獎", "手機", 1, true)
+		results, err := service.DrawBatch(testTenantID, "特別獎", 3)
+		if err == nil {
+			t.Fatal("Expected an error when count exceeds the remaining quantity, but got nil")
+		}
+		if len(results) != 1 {
+			t.Errorf("Expected 1 result, but got %d", len(results))
+		}
+	})
+}
+
+func TestLotteryService_DrawGroup(t *testing.T) {
+	const testTenantID = "group-tenant"
+	service := NewLotteryService()
+	service.AddPrize(testTenantID, "參加獎", "紅包", 5, false)
+	for i := 1; i <= 4; i++ {
+		service.AddParticipant(testTenantID, strconv.Itoa(i), "P"+strconv.Itoa(i))
+	}
+
+	results, err := service.DrawGroup(testTenantID, "參加獎", 3)
+	if err != nil {
+		t.Fatalf("DrawGroup failed: %v", err)
+	}
+	winners := make(map[string]bool)
+	for _, r := range results {
+		winners[r.WinnerID] = true
+		if r.BatchID == "" || r.BatchID != results[0].BatchID {
+			t.Errorf("Expected the group to share one BatchID, but got %q and %q", results[0].BatchID, r.BatchID)
+		}
+	}
+	if len(results) != 3 || len(winners) != 3 {
+		t.Fatalf("Expected 3 distinct winners, but got %d results with %d distinct winners", len(results), len(winners))
+	}
+	if q := service.GetPrizes(testTenantID)[0].Quantity; q != 2 {
+		t.Errorf("Expected prize quantity to be 2, but got %d", q)
+	}
+	if n := len(service.GetLotteryResults(testTenantID)); n != 3 {
+		t.Errorf("Expected each winner to be recorded, but got %d results", n)
+	}
+
+	// Only one participant is left eligible, so a group of 2 draws nobody.
+	if results, err := service.DrawGroup(testTenantID, "參加獎", 2); err == nil || len(results) != 0 {
+		t.Errorf("Expected an oversized group to draw nothing, but got %d results and %v", len(results), err)
+	}
+	if q := service.GetPrizes(testTenantID)[0].Quantity; q != 2 {
+		t.Errorf("Expected the rejected group to leave quantity 2, but got %d", q)
+	}
+}
+
+func TestLotteryService_SetMinParticipants(t *testing.T) {
+	const testTenantID = "min-tenant"
+	service := NewLotteryService()
+	service.AddPrize(testTenantID, "頭獎", "電視", 2, true)
+	service.AddParticipant(testTenantID, "001", "Alice")
+	service.AddParticipant(testTenantID, "002", "Bob")
+
+	if got := service.GetMinParticipants(testTenantID); got != 1 {
+		t.Errorf("Expected default minimum of 1, but got %d", got)
+	}
+
+	service.SetMinParticipants(testTenantID, 3)
+
+	t.Run("Test draws blocked below the minimum", func(t *testing.T) {
+		if _, err := service.Draw(testTenantID, "頭獎"); err == nil {
+			t.Fatal("Expected Draw to be rejected below the minimum, but got nil")
+		}
+		if _, err := service.DrawBatch(testTenantID, "頭獎", 2); err == nil {
+			t.Fatal("Expected DrawBatch to be rejected below the minimum, but got nil")
+		}
+		if q := service.GetPrizes(testTenantID)[0].Quantity; q != 2 {
+			t.Errorf("Expected prize quantity to stay 2, but got %d", q)
+		}
+	})
+
+	t.Run("Test draws allowed at the minimum", func(t *testing.T) {
+		service.AddParticipant(testTenantID, "003", "Charlie")
+		if _, err := service.Draw(testTenantID, "頭獎"); err != nil {
+			t.Fatalf("Expected no error at the minimum, but got %v", err)
+		}
+	})
+}
+
+func TestLotteryService_DrawnAt(t *testing.T) {
+	const testTenantID = "time-tenant"
+	service := NewLotteryService()
+	service.AddPrize(testTenantID, "參加獎", "紅包", 3, true)
+	service.AddParticipant(testTenantID, "001", "Alice")
+	service.AddParticipant(testTenantID, "002", "Bob")
+	service.AddParticipant(testTenantID, "003", "Charlie")
+
+	before := time.Now()
+	for i := 0; i < 3; i++ {
+		if _, err := service.Draw(testTenantID, "參加獎"); err != nil {
+			t.Fatalf("Expected no error, but got %v", err)
+		}
+	}
+
+	results := service.GetLotteryResults(testTenantID)
+	for i, r := range results {
+		if r.DrawnAt.IsZero() || r.DrawnAt.Before(before) {
+			t.Errorf("Result %d: expected DrawnAt to be set after %v, but got %v", i, before, r.DrawnAt)
+		}
+		if i > 0 && r.DrawnAt.Before(results[i-1].DrawnAt) {
+			t.Errorf("Result %d: expected DrawnAt to be non-decreasing, but %v < %v", i, r.DrawnAt, results[i-1].DrawnAt)
+		}
+	}
+}
+
+func TestLotteryService_AddParticipants(t *testing.T) {
+	const testTenantID = "bulk-tenant"
+	service := NewLotteryService()
+	service.AddParticipant(testTenantID, "001", "Alice")
+
+	added, duplicates := service.AddParticipants(testTenantID, []*models.Participant{
+		{ID: "001", Name: "Alice again"},
+		{ID: "002", Name: "Bob"},
+		{ID: "002", Name: "Bob again"},
+		{ID: "003", Name: "Charlie"},
+	})
+	if added != 2 {
+		t.Errorf("Expected 2 participants to be added, but got %d", added)
+	}
+	if !reflect.DeepEqual(duplicates, []int{0, 2}) {
+		t.Errorf("Expected rows 0 and 2 to be reported as duplicates, but got %v", duplicates)
+	}
+	if n := len(service.GetParticipants(testTenantID)); n != 3 {
+		t.Errorf("Expected 3 participants in total, but got %d", n)
+	}
+}
+
+func TestLotteryService_PrizeItems(t *testing.T) {
+	service := NewLotteryService()
+	const testTenantID = "items-tenant"
+	if err := service.InsertPrize(testTenantID, models.Prize{Name: "禮品組", Item: "紀念品", Quantity: 4, DrawFromAll: true, Items: []string{"耳機", "手錶", "背包"}}); err != nil {
+		t.Fatalf("InsertPrize failed: %v", err)
+	}
+	for i := 1; i <= 4; i++ {
+		service.AddParticipant(testTenantID, strconv.Itoa(i), "P"+strconv.Itoa(i))
+	}
+	draw := func() string {
+		t.Helper()
+		result, err := service.Draw(testTenantID, "禮品組")
+		if err != nil {
+			t.Fatalf("Draw failed: %v", err)
+		}
+		return result.PrizeItem
+	}
+
+	if first, second := draw(), draw(); first != "耳機" || second != "手錶" {
+		t.Errorf("Expected successive draws to get 耳機 then 手錶, but got %s and %s", first, second)
+	}
+	// An undone win frees its item for the next draw.
+	if _, err := service.UndoLastDraw(testTenantID); err != nil {
+		t.Fatalf("UndoLastDraw failed: %v", err)
+	}
+	if item := draw(); item != "手錶" {
+		t.Errorf("Expected the undone 手錶 to be drawn again, but got %s", item)
+	}
+	if third, fourth := draw(), draw(); third != "背包" || fourth != "紀念品" {
+		t.Errorf("Expected 背包 and then the Item fallback 紀念品, but got %s and %s", third, fourth)
+	}
+
+	// Without a fallback Item, the list must cover the whole quantity.
+	err := service.InsertPrize(testTenantID, models.Prize{Name: "短缺獎", Quantity: 3, Items: []string{"A", "B"}})
+	if err == nil {
+		t.Error("Expected a prize with fewer items than units and no Item to be rejected")
+	}
+	if err := service.InsertPrize(testTenantID, models.Prize{Name: "足量獎", Quantity: 2, Items: []string{"A", "B"}}); err != nil {
+		t.Errorf("Expected a prize with one item per unit to be accepted, but got %v", err)
+	}
+}
+
+func TestLotteryService_MaxWinners(t *testing.T) {
+	service := NewLotteryService()
+	const testTenantID = "max-winners-tenant"
+	// 10 headphones for at most 5 people: each of the 5 winners gets 2.
+	if err := service.InsertPrize(testTenantID, models.Prize{Name: "耳機獎", Item: "耳機", Quantity: 10, MaxWinners: 5}); err != nil {
+		t.Fatalf("InsertPrize failed: %v", err)
+	}
+	for i := 1; i <= 8; i++ {
+		service.AddParticipant(testTenantID, strconv.Itoa(i), "P"+strconv.Itoa(i))
+	}
+
+	for i := 0; i < 5; i++ {
+		if _, err := service.Draw(testTenantID, "耳機獎"); err != nil {
+			t.Fatalf("Draw %d failed: %v", i+1, err)
+		}
+	}
+	counts := make(map[string]int)
+	for _, r := range service.GetLotteryResults(testTenantID) {
+		counts[r.WinnerID]++
+	}
+	if len(counts) != 5 {
+		t.Fatalf("Expected the first 5 draws to pick 5 distinct winners, but got %v", counts)
+	}
+	eligible, _ := service.GetEligibleParticipants(testTenantID, "耳機獎")
+	if len(eligible) != 5 || !slices.ContainsFunc(eligible, func(p *models.Participant) bool { return counts[p.ID] == 1 }) {
+		t.Fatalf("Expected only the 5 winners to stay eligible once the limit is reached, but got %d", len(eligible))
+	}
+
+	if _, err := service.DrawBatch(testTenantID, "耳機獎", 5); err != nil {
+		t.Fatalf("DrawBatch failed: %v", err)
+	}
+	counts = make(map[string]int)
+	for _, r := range service.GetLotteryResults(testTenantID) {
+		counts[r.WinnerID]++
+	}
+	for id, n := range counts {
+		if n != 2 {
+			t.Errorf("Expected each of the 5 winners to get 2 units, but %s got %d (%v)", id, n, counts)
+		}
+	}
+	if len(counts) != 5 {
+		t.Errorf("Expected still 5 distinct winners, but got %v", counts)
+	}
+
+	// Undoing one of two units leaves the winner holding the prize and first in line.
+	undone, err := service.UndoLastDraw(testTenantID)
+	if err != nil {
+		t.Fatalf("UndoLastDraw failed: %v", err)
+	}
+	eligible, _ = service.GetEligibleParticipants(testTenantID, "耳機獎")
+	if len(eligible) != 1 || eligible[0].ID != undone.WinnerID {
+		t.Errorf("Expected only %s, now holding one unit, to be eligible, but got %+v", undone.WinnerID, eligible)
+	}
+
+	if err := service.InsertPrize(testTenantID, models.Prize{Name: "負數獎", Quantity: 1, MaxWinners: -1}); err == nil {
+		t.Error("Expected a negative MaxWinners to be rejected")
+	}
+}
+
+func TestLotteryService_MaxWinnersKeepsMaxWinsPerParticipant(t *testing.T) {
+	service := NewLotteryService()
+	const testTenantID = "max-winners-cap"
+	service.InsertPrize(testTenantID, models.Prize{Name: "耳機獎", Item: "耳機", Quantity: 6, MaxWinners: 2})
+	for i := 1; i <= 4; i++ {
+		service.AddParticipant(testTenantID, strconv.Itoa(i), "P"+strconv.Itoa(i))
+	}
+	service.SetMaxWins(testTenantID, 2)
+
+	// Two winners, then one repeat unit each: both now hold the per-participant cap.
+	if _, err := service.DrawBatch(testTenantID, "耳機獎", 4); err != nil {
+		t.Fatalf("DrawBatch failed: %v", err)
+	}
+	if _, err := service.Draw(testTenantID, "耳機獎"); err == nil {
+		t.Error("Expected no repeat winner to be eligible past MaxWinsPerParticipant, but the draw succeeded")
+	}
+	winner := service.GetLotteryResults(testTenantID)[0].WinnerID
+	eligible, reason, err := service.ExplainEligibility(testTenantID, "耳機獎", winner)
+	if err != nil || eligible || reason != i18n.T(i18n.Default, "eligibility_max_wins", 2) {
+		t.Errorf("Expected %s to be excluded by the win cap, but got %v %q %v", winner, eligible, reason, err)
+	}
+}
+
+func TestLotteryService_MaxWinnersAllocate(t *testing.T) {
+	service := NewLotteryService()
+	const testTenantID = "max-winners-allocate"
+	service.InsertPrize(testTenantID, models.Prize{Name: "餐券", Item: "餐券", Quantity: 4, MaxWinners: 2})
+	for i := 1; i <= 5; i++ {
+		service.AddParticipant(testTenantID, strconv.Itoa(i), "P"+strconv.Itoa(i))
+	}
+
+	results, err := service.AllocatePrizes(testTenantID)
+	if err != nil {
+		t.Fatalf("AllocatePrizes failed: %v", err)
+	}
+	counts := make(map[string]int)
+	for _, r := range results {
+		counts[r.WinnerID]++
+	}
+	if len(results) != 4 || len(counts) != 2 {
+		t.Errorf("Expected 4 units split over 2 winners, but got %v", counts)
+	}
+}
+
+func TestLotteryService_AllocatePrizes_SkipsDrawnAllRemaining(t *testing.T) {
+	service := NewLotteryService()
+	const testTenantID = "allocate-all-remaining"
+	service.InsertPrize(testTenantID, models.Prize{Name: "參加獎", Item: "紅包", Quantity: 1, DrawFromAll: true, AllRemaining: true})
+	service.AddParticipant(testTenantID, "1", "Alice")
+	if _, err := service.DrawBatch(testTenantID, "參加獎", 1); err != nil {
+		t.Fatalf("DrawBatch failed: %v", err)
+	}
+
+	// Bob arrives after the prize was handed out to everyone present.
+	service.AddParticipant(testTenantID, "2", "Bob")
+	results, err := service.AllocatePrizes(testTenantID)
+	if err != nil {
+		t.Fatalf("AllocatePrizes failed: %v", err)
+	}
+	if len(results) != 0 {
+		t.Errorf("Expected the drawn AllRemaining prize not to be awarded again, but got %+v", results)
+	}
+}
+
+func TestLotteryService_UndoLastDraw(t *testing.T) {
+	const testTenantID = "undo-tenant"
+	service := NewLotteryService()
+	service.AddPrize(testTenantID, "頭獎", "電視", 1, false)
+	service.AddPrize(testTenantID, "二獎", "手機", 1, false)
+	service.AddParticipant(testTenantID, "001", "Alice")
+
+	t.Run("Test undo with no results", func(t *testing.T) {
+		if _, err := service.UndoLastDraw(testTenantID); err == nil {
+			t.Fatal("Expected an error when there is nothing to undo, but got nil")
+		}
+	})
+
+	t.Run("Test winner becomes eligible again", func(t *testing.T) {
+		result, err := service.Draw(testTenantID, "頭獎")
+		if err != nil {
+			t.Fatalf("Expected no error, but got %v", err)
+		}
+		if _, err := service.GetEligibleParticipants(testTenantID, "二獎"); err == nil {
+			t.Fatal("Expected the only participant to be ineligible after winning")
+		}
+
+		undone, err := service.UndoLastDraw(testTenantID)
+		if err != nil {
+			t.Fatalf("Expected no error, but got %v", err)
+		}
+		if undone.ID != result.ID {
+			t.Errorf("Expected result %s to be undone, but got %s", result.ID, undone.ID)
+		}
+		if q := service.GetPrizes(testTenantID)[0].Quantity; q != 1 {
+			t.Errorf("Expected prize quantity to be restored to 1, but got %d", q)
+		}
+		if n := len(service.GetLotteryResults(testTenantID)); n != 0 {
+			t.Errorf("Expected no results after undo, but got %d", n)
+		}
+		if _, ok := service.getSession(testTenantID).Winners["001"]; ok {
+			t.Error("Expected the winner entry to be removed entirely")
+		}
+		eligible, err := service.GetEligibleParticipants(testTenantID, "二獎")
+		if err != nil || len(eligible) != 1 {
+			t.Errorf("Expected Alice to be eligible again, but got %v, %v", eligible, err)
+		}
+	})
+
+	t.Run("Test undo keeps other prize wins", func(t *testing.T) {
+		service.AddPrize(testTenantID, "普獎", "紅包", 1, true)
+		if _, err := service.Draw(testTenantID, "頭獎"); err != nil {
+			t.Fatalf("Expected no error, but got %v", err)
+		}
+		if _, err := service.Draw(testTenantID, "普獎"); err != nil {
+			t.Fatalf("Expected no error, but got %v", err)
+		}
+		if _, err := service.UndoLastDraw(testTenantID); err != nil {
+			t.Fatalf("Expected no error, but got %v", err)
+		}
+		wins := service.getSession(testTenantID).Winners["001"]
+		if !wins["頭獎"] || wins["普獎"] {
+			t.Errorf("Expected only the 頭獎 win to remain, but got %v", wins)
+		}
+	})
+
+	t.Run("Test undo rejects a locked result", func(t *testing.T) {
+		results := service.GetLotteryResults(testTenantID)
+		if err := service.LockResult(testTenantID, results[len(results)-1].ID); err != nil {
+			t.Fatalf("Expected no error, but got %v", err)
+		}
+		if _, err := service.UndoLastDraw(testTenantID); err != ErrResultLocked {
+			t.Errorf("Expected ErrResultLocked, but got %v", err)
+		}
+	})
+}
+
+func TestLotteryService_EligibleCache(t *testing.T) {
+	const testTenantID = "cache-tenant"
+	service := NewLotteryService()
+	service.AddPrize(testTenantID, "頭獎", "電視", 1, false)
+	service.AddPrize(testTenantID, "二獎", "手機", 1, false)
+	service.AddParticipant(testTenantID, "001", "Alice")
+	service.AddParticipant(testTenantID, "002", "Bob")
+
+	first, err := service.GetEligibleParticipants(testTenantID, "二獎")
+	if err != nil || len(first) != 2 {
+		t.Fatalf("Expected 2 eligible participants, but got %v, %v", first, err)
+	}
+	if _, cached := service.getSession(testTenantID).eligibleCache["二獎"]; !cached {
+		t.Error("Expected the eligible list to be cached")
+	}
+
+	t.Run("Test cache invalidated after a draw", func(t *testing.T) {
+		result, err := service.Draw(testTenantID, "頭獎")
+		if err != nil {
+			t.Fatalf("Expected no error, but got %v", err)
+		}
+		eligible, err := service.GetEligibleParticipants(testTenantID, "二獎")
+		if err != nil || len(eligible) != 1 || eligible[0].ID == result.WinnerID {
+			t.Errorf("Expected only the non-winner to be eligible, but got %v, %v", eligible, err)
+		}
+	})
+
+	t.Run("Test cache invalidated after adding a participant", func(t *testing.T) {
+		service.AddParticipant(testTenantID, "003", "Charlie")
+		eligible, _ := service.GetEligibleParticipants(testTenantID, "二獎")
+		if len(eligible) != 2 {
+			t.Errorf("Expected 2 eligible participants, but got %d", len(eligible))
+		}
+	})
+
+	t.Run("Test cache invalidated after undo", func(t *testing.T) {
+		if _, err := service.UndoLastDraw(testTenantID); err != nil {
+			t.Fatalf("Expected no error, but got %v", err)
+		}
+		eligible, _ := service.GetEligibleParticipants(testTenantID, "二獎")
+		if len(eligible) != 3 {
+			t.Errorf("Expected 3 eligible participants, but got %d", len(eligible))
+		}
+	})
+}
+
+// benchmarkEligible measures GetEligibleParticipants on a large roster,
+// optionally dropping the cache before each call to show the uncached cost.
+func benchmarkEligible(b *testing.B, invalidate bool) {
+	const testTenantID = "bench-tenant"
+	service := NewLotteryService()
+	service.AddPrize(testTenantID, "頭獎", "電視", 1, false)
+	for i := 0; i < 5000; i++ {
+		service.AddParticipant(testTenantID, strconv.Itoa(i), "Participant")
+	}
+	session := service.getSession(testTenantID)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if invalidate {
+			session.invalidateEligible()
+		}
+		if _, err := service.GetEligibleParticipants(testTenantID, "頭獎"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGetEligibleParticipants_Cached(b *testing.B)   { benchmarkEligible(b, false) }
+func BenchmarkGetEligibleParticipants_Uncached(b *testing.B) { benchmarkEligible(b, true) }
+
+// TestLotteryService_ConcurrentAccess is meant to be run with -race: it reads
+// session snapshots while draws mutate the same tenant.
+func TestLotteryService_ConcurrentAccess(t *testing.T) {
+	const testTenantID = "race-tenant"
+	service := NewLotteryService()
+	service.AddPrize(testTenantID, "參加獎", "紅包", 50, true)
+	for i := 0; i < 50; i++ {
+		service.AddParticipant(testTenantID, strconv.Itoa(i), "Participant")
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 5; j++ {
+				service.Draw(testTenantID, "參加獎")
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 5; j++ {
+				for _, p := range service.GetPrizes(testTenantID) {
+					_ = p.Quantity
+				}
+				_ = service.GetLotteryResults(testTenantID)
+				_ = service.GetParticipants(testTenantID)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if q := service.GetPrizes(testTenantID)[0].Quantity; q != 0 {
+		t.Errorf("Expected all 50 units to be drawn, but %d remain", q)
+	}
+}
+
+func TestLotteryService_MergeParticipants(t *testing.T) {
+	const testTenantID = "merge-tenant"
+	service := NewLotteryService()
+	service.AddPrize(testTenantID, "頭獎", "電視", 1, true)
+	service.AddPrize(testTenantID, "普獎", "紅包", 2, true)
+	service.AddParticipant(testTenantID, "001", "Alice")
+	service.AddParticipant(testTenantID, "A001", "Alice (HR import)")
+
+	// Force both IDs to win 普獎 and only the duplicate to win 頭獎.
+	session := service.getSession(testTenantID)
+	recordWin(session, session.Prizes[1], session.Participants[0])
+	recordWin(session, session.Prizes[1], session.Participants[1])
+	recordWin(session, session.Prizes[0], session.Participants[1])
+
+	if err := service.MergeParticipants(testTenantID, "001", "A001"); err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	participants := service.GetParticipants(testTenantID)
+	if len(participants) != 1 || participants[0].ID != "001" {
+		t.Fatalf("Expected only participant 001 to remain, but got %v", participants)
+	}
+
+	results := service.GetLotteryResults(testTenantID)
+	if len(results) != 2 {
+		t.Fatalf("Expected the duplicate 普獎 win to be dropped leaving 2 results, but got %d", len(results))
+	}
+	for _, r := range results {
+		if r.WinnerID != "001" || r.WinnerName != "Alice" {
+			t.Errorf("Expected result %s to be reassigned to 001/Alice, but got %s/%s", r.ID, r.WinnerID, r.WinnerName)
+		}
+	}
+
+	wins := service.getSession(testTenantID).Winners
+	if !wins["001"]["頭獎"] || !wins["001"]["普獎"] {
+		t.Errorf("Expected 001 to hold both wins, but got %v", wins["001"])
+	}
+	if _, ok := wins["A001"]; ok {
+		t.Error("Expected the merged ID to be removed from winners")
+	}
+	if q := service.GetPrizes(testTenantID)[1].Quantity; q != 1 {
+		t.Errorf("Expected the dropped duplicate to return one 普獎 unit, but quantity is %d", q)
+	}
+
+	if err := service.MergeParticipants(testTenantID, "001", "missing"); err == nil {
+		t.Error("Expected an error when merging an unknown participant, but got nil")
+	}
+}
+
+func TestLotteryService_MergeParticipants_CarriesOverState(t *testing.T) {
+	const testTenantID = "merge-state-tenant"
+	service := NewLotteryService()
+	service.AddPrize(testTenantID, "頭獎", "電視", 1, true)
+	service.AddParticipant(testTenantID, "001", "Alice")
+	service.AddParticipant(testTenantID, "A001", "Alice (HR import)")
+	session := service.getSession(testTenantID)
+	result := recordWin(session, session.Prizes[0], session.Participants[1])
+	session.Excluded["A001"] = true
+	if err := service.SetPresence(testTenantID, "A001", false); err != nil {
+		t.Fatalf("SetPresence failed: %v", err)
+	}
+
+	events, unsubscribe := service.Subscribe(testTenantID, CoalesceConfig{})
+	defer unsubscribe()
+	if err := service.MergeParticipants(testTenantID, "001", "A001"); err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	if excluded := service.getSession(testTenantID).Excluded; !excluded["001"] || excluded["A001"] {
+		t.Errorf("Expected the exclusion to move to 001, but got %v", excluded)
+	}
+	if p := service.GetParticipants(testTenantID)[0]; p.Present {
+		t.Error("Expected 001 to be absent after merging an absent duplicate")
+	}
+
+	log := service.GetAuditLog(testTenantID)
+	if len(log) == 0 || log[len(log)-1].Action != AuditMerge || !reflect.DeepEqual(log[len(log)-1].ResultIDs, []string{result.ID}) {
+		t.Errorf("Expected a merge audit entry for %s, but got %+v", result.ID, log)
+	}
+	select {
+	case batch := <-events:
+		if len(batch) != 1 || batch[0].Type != EventMerge || batch[0].Results[0].WinnerID != "001" {
+			t.Errorf("Expected a merge event reassigning %s to 001, but got %+v", result.ID, batch)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for the merge event")
+	}
+}
+
+func TestLotteryService_DeletePrize(t *testing.T) {
+	const testTenantID = "delete-prize-tenant"
+	service := NewLotteryService()
+	service.AddPrize(testTenantID, "頭獎", "電視", 1, false)
+	service.AddPrize(testTenantID, "二獎", "手機", 1, false)
+	service.AddPrize(testTenantID, "三獎", "耳機", 1, false)
+
+	if err := service.DeletePrize(testTenantID, "二獎"); err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	prizes := service.GetPrizes(testTenantID)
+	if len(prizes) != 2 {
+		t.Fatalf("Expected 2 prizes to remain, but got %d", len(prizes))
+	}
+	if prizes[0].Name != "頭獎" || prizes[1].Name != "三獎" {
+		t.Errorf("Expected 頭獎, 三獎 in order, but got %s, %s", prizes[0].Name, prizes[1].Name)
+	}
+
+	if err := service.DeletePrize(testTenantID, "二獎"); err == nil {
+		t.Error("Expected an error deleting a missing prize, but got nil")
+	}
+}
+
+func TestLotteryService_RemoveParticipant(t *testing.T) {
+	const testTenantID = "remove-participant-tenant"
+	service := NewLotteryService()
+	service.AddPrize(testTenantID, "頭獎", "電視", 1, false)
+	service.AddPrize(testTenantID, "二獎", "手機", 1, false)
+	service.AddParticipant(testTenantID, "001", "Alice")
+
+	if _, err := service.Draw(testTenantID, "頭獎"); err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	if err := service.RemoveParticipant(testTenantID, "001"); err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+	if n := len(service.GetParticipants(testTenantID)); n != 0 {
+		t.Errorf("Expected no participants, but got %d", n)
+	}
+	if _, ok := service.getSession(testTenantID).Winners["001"]; ok {
+		t.Error("Expected the winner record to be purged")
+	}
+
+	// Re-adding the ID must not be blocked by the stale win.
+	service.AddParticipant(testTenantID, "001", "Alice")
+	if eligible, err := service.GetEligibleParticipants(testTenantID, "二獎"); err != nil || len(eligible) != 1 {
+		t.Errorf("Expected 001 to be eligible again, but got %v, %v", eligible, err)
+	}
+
+	// Nor by an absent-winner exclusion or a reservation held before the removal.
+	session := service.getSession(testTenantID)
+	session.Excluded["001"] = true
+	if err := service.ReserveWinner(testTenantID, "二獎", "001"); err != nil {
+		t.Fatalf("ReserveWinner failed: %v", err)
+	}
+	if err := service.RemoveParticipant(testTenantID, "001"); err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+	if session.Excluded["001"] || len(session.Reservations) != 0 {
+		t.Errorf("Expected the exclusion and reservation to be purged, but got %v and %v", session.Excluded, session.Reservations)
+	}
+
+	if err := service.RemoveParticipant(testTenantID, "missing"); err == nil {
+		t.Error("Expected an error removing a missing participant, but got nil")
+	}
+}
+
+func TestLotteryService_AllRemainingPrize(t *testing.T) {
+	const testTenantID = "all-remaining-tenant"
+	service := NewLotteryService()
+	service.AddPrize(testTenantID, "頭獎", "電視", 1, false)
+	service.InsertPrize(testTenantID, models.Prize{Name: "陽光普照獎", Item: "紅包", Quantity: 1, AllRemaining: true})
+	for _, id := range []string{"001", "002", "003", "004"} {
+		service.AddParticipant(testTenantID, id, "Participant "+id)
+	}
+
+	first, err := service.Draw(testTenantID, "頭獎")
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	results, err := service.DrawBatch(testTenantID, "陽光普照獎", 1)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("Expected all 3 remaining non-winners to win, but got %d", len(results))
+	}
+	for _, r := range results {
+		if r.WinnerID == first.WinnerID {
+			t.Errorf("Expected previous winner %s to be excluded", first.WinnerID)
+		}
+	}
+	if q := service.GetPrizes(testTenantID)[1].Quantity; q != 0 {
+		t.Errorf("Expected the prize to be exhausted, but quantity is %d", q)
+	}
+	if _, err := service.Draw(testTenantID, "陽光普照獎"); err == nil {
+		t.Error("Expected drawing an exhausted prize to fail, but got nil")
+	}
+}
+
+func TestLotteryService_UndoBatch(t *testing.T) {
+	const testTenantID = "undo-batch-tenant"
+	service := NewLotteryService()
+	service.AddPrize(testTenantID, "頭獎", "電視", 1, false)
+	service.AddPrize(testTenantID, "參加獎", "紅包", 5, false)
+	for _, id := range []string{"001", "002", "003", "004"} {
+		service.AddParticipant(testTenantID, id, "Participant "+id)
+	}
+	if _, err := service.Draw(testTenantID, "頭獎"); err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	prizesBefore := service.GetPrizes(testTenantID)
+	resultsBefore := service.GetLotteryResults(testTenantID)
+	winnersBefore := map[string]map[string]bool{}
+	for id, wins := range service.getSession(testTenantID).Winners {
+		winnersBefore[id] = map[string]bool{}
+		for prize := range wins {
+			winnersBefore[id][prize] = true
+		}
+	}
+
+	batch, err := service.DrawBatch(testTenantID, "參加獎", 3)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+	batchID := batch[0].BatchID
+	for _, r := range batch {
+		if r.BatchID == "" || r.BatchID != batchID {
+			t.Fatalf("Expected every batch result to share a BatchID, but got %q and %q", r.BatchID, batchID)
+		}
+	}
+
+	if err := service.UndoBatch(testTenantID, batchID); err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	if got := service.GetPrizes(testTenantID); !reflect.DeepEqual(got, prizesBefore) {
+		t.Errorf("Expected prizes %+v, but got %+v", prizesBefore, got)
+	}
+	if got := service.GetLotteryResults(testTenantID); !reflect.DeepEqual(got, resultsBefore) {
+		t.Errorf("Expected results %+v, but got %+v", resultsBefore, got)
+	}
+	if got := service.getSession(testTenantID).Winners; !reflect.DeepEqual(got, winnersBefore) {
+		t.Errorf("Expected winners %v, but got %v", winnersBefore, got)
+	}
+
+	if err := service.UndoBatch(testTenantID, batchID); err == nil {
+		t.Error("Expected an error undoing the same batch twice, but got nil")
+	}
+}
+
+func TestLotteryService_WeightedDraw(t *testing.T) {
+	const testTenantID = "weight-tenant"
+	const iterations = 5000
+	service := NewLotteryService()
+	service.AddPrize(testTenantID, "頭獎", "電視", 1, false)
+	service.InsertParticipant(testTenantID, models.Participant{ID: "light", Name: "Light", Weight: 1})
+	service.InsertParticipant(testTenantID, models.Participant{ID: "heavy", Name: "Heavy", Weight: 9})
+
+	heavyWins := 0
+	for i := 0; i < iterations; i++ {
+		result, err := service.Draw(testTenantID, "頭獎")
+		if err != nil {
+			t.Fatalf("Expected no error, but got %v", err)
+		}
+		if result.WinnerID == "heavy" {
+			heavyWins++
+		}
+		if _, err := service.UndoLastDraw(testTenantID); err != nil {
+			t.Fatalf("Expected no error, but got %v", err)
+		}
+	}
+
+	ratio := float64(heavyWins) / iterations
+	if ratio < 0.87 || ratio > 0.93 {
+		t.Errorf("Expected the weight-9 participant to win ~90%% of draws, but got %.3f", ratio)
+	}
+}
+
+func TestWeightedIndex_DefaultsToUniform(t *testing.T) {
+	pool := []*models.Participant{{ID: "a"}, {ID: "b", Weight: 0}}
+	counts := map[int]int{}
+	for i := 0; i < 4000; i++ {
+		idx, err := weightedIndex(pool, secureIntn)
+		if err != nil {
+			t.Fatalf("Expected no error, but got %v", err)
+		}
+		counts[idx]++
+	}
+	if counts[0] < 1800 || counts[1] < 1800 {
+		t.Errorf("Expected zero weights to behave like weight 1, but got %v", counts)
+	}
+}
+
+func TestLotteryService_SetSeed(t *testing.T) {
+	const testTenantID = "seed-tenant"
+	newSeeded := func() *LotteryService {
+		service := NewLotteryService()
+		service.AddPrize(testTenantID, "頭獎", "電視", 1, false)
+		service.AddPrize(testTenantID, "參加獎", "紅包", 5, false)
+		for i := 0; i < 20; i++ {
+			service.AddParticipant(testTenantID, strconv.Itoa(i), "Participant")
+		}
+		service.SetSeed(testTenantID, 20251231)
+		return service
+	}
+
+	winners := func(service *LotteryService) []string {
+		var ids []string
+		if r, err := service.Draw(testTenantID, "頭獎"); err == nil {
+			ids = append(ids, r.WinnerID)
+		}
+		results, _ := service.DrawBatch(testTenantID, "參加獎", 5)
+		for _, r := range results {
+			ids = append(ids, r.WinnerID)
+		}
+		return ids
+	}
+
+	a, b := winners(newSeeded()), winners(newSeeded())
+	if len(a) != 6 || !reflect.DeepEqual(a, b) {
+		t.Errorf("Expected identical winner sequences for the same seed, but got %v and %v", a, b)
+	}
+
+	service := newSeeded()
+	if seed := service.GetSeed(testTenantID); seed == nil || *seed != 20251231 {
+		t.Errorf("Expected the seed to be recorded in the session, but got %v", seed)
+	}
+	service.ClearSeed(testTenantID)
+	if seed := service.GetSeed(testTenantID); seed != nil {
+		t.Errorf("Expected no seed after clearing, but got %v", *seed)
+	}
+}
+
+func TestLotteryService_CleanUpInactiveSessionsWithTTL(t *testing.T) {
+	service := NewLotteryServiceWithTTL(50 * time.Millisecond)
+	if service.SessionTTL() != 50*time.Millisecond {
+		t.Fatalf("Expected TTL of 50ms, but got %v", service.SessionTTL())
+	}
+	service.AddPrize("idle", "頭獎", "電視", 1, false)
+	service.AddPrize("active", "頭獎", "電視", 1, false)
+
+	time.Sleep(30 * time.Millisecond)
+	service.GetPrizes("active") // Touch the active session halfway through the TTL
+	time.Sleep(30 * time.Millisecond)
+	if n := service.CleanUpInactiveSessions(); n != 1 {
+		t.Errorf("Expected 1 session to be reaped, but got %d", n)
+	}
+
+	service.mu.Lock()
+	_, idleExists := service.sessions["idle"]
+	_, activeExists := service.sessions["active"]
+	service.mu.Unlock()
+	if idleExists {
+		t.Error("Expected the idle session to be reaped")
+	}
+	if !activeExists {
+		t.Error("Expected the active session to survive")
+	}
+
+	if NewLotteryService().SessionTTL() != DefaultSessionTTL {
+		t.Errorf("Expected NewLotteryService to default to %v", DefaultSessionTTL)
+	}
+}
+
+func TestLotteryService_MarkClaimed(t *testing.T) {
+	service := NewLotteryService()
+	const testTenantID = "claim-tenant"
+	service.AddPrize(testTenantID, "頭獎", "電視", 1, false)
+	service.AddParticipant(testTenantID, "1", "Alice")
+	result, err := service.Draw(testTenantID, "頭獎")
+	if err != nil {
+		t.Fatalf("Draw failed: %v", err)
+	}
+
+	if err := service.MarkClaimed(testTenantID, result.ID); err != nil {
+		t.Fatalf("MarkClaimed failed: %v", err)
+	}
+	first := service.GetLotteryResults(testTenantID)[0]
+	if !first.Claimed || first.ClaimedAt == nil {
+		t.Fatalf("Expected the result to be claimed with a timestamp, but got %+v", first)
+	}
+
+	time.Sleep(time.Millisecond)
+	if err := service.MarkClaimed(testTenantID, result.ID); err != nil {
+		t.Errorf("Expected claiming twice to succeed, but got %v", err)
+	}
+	second := service.GetLotteryResults(testTenantID)[0]
+	if !second.Claimed || !second.ClaimedAt.Equal(*first.ClaimedAt) {
+		t.Errorf("Expected a repeated claim to keep ClaimedAt %v, but got %v", first.ClaimedAt, second.ClaimedAt)
+	}
+
+	if err := service.MarkClaimed(testTenantID, "999"); err == nil {
+		t.Error("Expected an error when claiming a result that does not exist")
+	}
+}
+
+func TestLotteryService_MarkClaimed_RepeatWins(t *testing.T) {
+	service := NewLotteryService()
+	const testTenantID = "claim-repeat-tenant"
+	service.AddPrize(testTenantID, "普獎", "紅包", 2, true)
+	service.AddParticipant(testTenantID, "1", "Alice")
+	session := service.getSession(testTenantID)
+	recordWin(session, session.Prizes[0], session.Participants[0])
+	second := recordWin(session, session.Prizes[0], session.Participants[0])
+
+	if err := service.MarkClaimed(testTenantID, second.ID); err != nil {
+		t.Fatalf("MarkClaimed failed: %v", err)
+	}
+	results := service.GetLotteryResults(testTenantID)
+	if results[0].Claimed || !results[1].Claimed {
+		t.Errorf("Expected only the second win to be claimed, but got %+v and %+v", results[0], results[1])
+	}
+}
+
+func TestLotteryService_RedrawWinner(t *testing.T) {
+	service := NewLotteryService()
+	const testTenantID = "redraw-tenant"
+	service.AddPrize(testTenantID, "頭獎", "電視", 1, false)
+	service.AddParticipant(testTenantID, "1", "Alice")
+	service.AddParticipant(testTenantID, "2", "Bob")
+
+	first, err := service.Draw(testTenantID, "頭獎")
+	if err != nil {
+		t.Fatalf("Draw failed: %v", err)
+	}
+	other := "1"
+	if first.WinnerID == "1" {
+		other = "2"
+	}
+
+	replacement, err := service.RedrawWinner(testTenantID, "頭獎", first.WinnerID)
+	if err != nil {
+		t.Fatalf("RedrawWinner failed: %v", err)
+	}
+	if replacement.WinnerID != other {
+		t.Errorf("Expected the only other participant %s to be redrawn, but got %s", other, replacement.WinnerID)
+	}
+
+	results := service.GetLotteryResults(testTenantID)
+	if len(results) != 1 || results[0].WinnerID != other {
+		t.Errorf("Expected only the replacement result to remain, but got %+v", results)
+	}
+	if q := service.GetPrizes(testTenantID)[0].Quantity; q != 0 {
+		t.Errorf("Expected the prize quantity to stay net-neutral at 0, but got %d", q)
+	}
+
+	// The absent winner stays excluded even when another slot opens up.
+	service.UndoLastDraw(testTenantID)
+	eligible, _ := service.GetEligibleParticipants(testTenantID, "頭獎")
+	if len(eligible) != 1 || eligible[0].ID != other {
+		t.Errorf("Expected the absent winner to be excluded, but eligible was %+v", eligible)
+	}
+
+	// Locked results cannot be redrawn.
+	locked, _ := service.Draw(testTenantID, "頭獎")
+	service.LockResult(testTenantID, locked.ID)
+	if _, err := service.RedrawWinner(testTenantID, "頭獎", locked.WinnerID); err != ErrResultLocked {
+		t.Errorf("Expected ErrResultLocked, but got %v", err)
+	}
+}
+
+func TestLotteryService_GroupRestrictedPrize(t *testing.T) {
+	service := NewLotteryService()
+	const testTenantID = "group-tenant"
+	service.InsertPrize(testTenantID, models.Prize{Name: "業務獎", Item: "禮券", Quantity: 3, Group: "Sales"})
+	service.InsertPrize(testTenantID, models.Prize{Name: "業務加碼", Item: "紅包", Quantity: 2, DrawFromAll: true, Group: "Sales"})
+	service.InsertParticipant(testTenantID, models.Participant{ID: "1", Name: "Alice", Group: "Sales"})
+	service.InsertParticipant(testTenantID, models.Participant{ID: "2", Name: "Bob", Group: "Sales"})
+	service.InsertParticipant(testTenantID, models.Participant{ID: "3", Name: "Charlie", Group: "RD"})
+	service.InsertParticipant(testTenantID, models.Participant{ID: "4", Name: "Dave"})
+
+	eligible, err := service.GetEligibleParticipants(testTenantID, "業務獎")
+	if err != nil {
+		t.Fatalf("GetEligibleParticipants failed: %v", err)
+	}
+	if len(eligible) != 2 || eligible[0].ID != "1" || eligible[1].ID != "2" {
+		t.Fatalf("Expected only the Sales participants, but got %+v", eligible)
+	}
+
+	first, err := service.Draw(testTenantID, "業務獎")
+	if err != nil {
+		t.Fatalf("Draw failed: %v", err)
+	}
+	eligible, _ = service.GetEligibleParticipants(testTenantID, "業務獎")
+	if len(eligible) != 1 || eligible[0].ID == first.WinnerID {
+		t.Errorf("Expected the winner to be excluded from a non-DrawFromAll group prize, but got %+v", eligible)
+	}
+
+	// A DrawFromAll group prize still admits earlier winners of the group, but nobody outside it.
+	eligible, _ = service.GetEligibleParticipants(testTenantID, "業務加碼")
+	if len(eligible) != 2 || eligible[0].ID != "1" || eligible[1].ID != "2" {
+		t.Errorf("Expected both Sales participants for the DrawFromAll prize, but got %+v", eligible)
+	}
+
+	service.Draw(testTenantID, "業務獎")
+	if _, err := service.Draw(testTenantID, "業務獎"); err == nil {
+		t.Error("Expected an error once every Sales participant has won")
+	}
+}
+
+func TestLotteryService_AllowRepeatWins(t *testing.T) {
+	service := NewLotteryService()
+	const testTenantID = "repeat-tenant"
+	service.AddPrize(testTenantID, "頭獎", "電視", 2, false)
+	service.AddPrize(testTenantID, "二獎", "手機", 1, false)
+	service.AddParticipant(testTenantID, "1", "Alice")
+	service.AddParticipant(testTenantID, "2", "Bob")
+
+	first, err := service.Draw(testTenantID, "頭獎")
+	if err != nil {
+		t.Fatalf("Draw failed: %v", err)
+	}
+	eligibleIDs := func(prizeName string) map[string]bool {
+		eligible, _ := service.GetEligibleParticipants(testTenantID, prizeName)
+		ids := make(map[string]bool)
+		for _, p := range eligible {
+			ids[p.ID] = true
+		}
+		return ids
+	}
+
+	// Default: a win of any prize rules the winner out of every other one.
+	if service.GetAllowRepeatWins(testTenantID) {
+		t.Fatal("Expected repeat wins to be off by default")
+	}
+	if eligibleIDs("二獎")[first.WinnerID] {
+		t.Errorf("Expected prior winner %s not to be eligible for 二獎 by default", first.WinnerID)
+	}
+
+	service.SetAllowRepeatWins(testTenantID, true)
+	if !eligibleIDs("二獎")[first.WinnerID] {
+		t.Errorf("Expected prior winner %s to be eligible for 二獎 with repeat wins allowed", first.WinnerID)
+	}
+	if eligibleIDs("頭獎")[first.WinnerID] {
+		t.Errorf("Expected prior winner %s to still be limited to one 頭獎", first.WinnerID)
+	}
+
+	service.SetUniqueAcrossAll(testTenantID, true)
+	if eligibleIDs("二獎")[first.WinnerID] {
+		t.Error("Expected SetUniqueAcrossAll to take precedence over repeat wins")
+	}
+}
+
+func TestLotteryService_NoConsecutiveRepeat(t *testing.T) {
+	service := NewLotteryService()
+	const testTenantID = "consecutive-tenant"
+	service.AddPrize(testTenantID, "一獎", "電視", 1, true)
+	service.AddPrize(testTenantID, "二獎", "手機", 1, true)
+	service.AddParticipant(testTenantID, "1", "Alice")
+	service.AddParticipant(testTenantID, "2", "Bob")
+	eligibleIDs := func(prize string) map[string]bool {
+		eligible, err := service.GetEligibleParticipants(testTenantID, prize)
+		if err != nil {
+			t.Fatalf("GetEligibleParticipants(%s) failed: %v", prize, err)
+		}
+		ids := make(map[string]bool)
+		for _, p := range eligible {
+			ids[p.ID] = true
+		}
+		return ids
+	}
+
+	if service.GetNoConsecutiveRepeat(testTenantID) {
+		t.Fatal("Expected NoConsecutiveRepeat to be off by default")
+	}
+	first, err := service.Draw(testTenantID, "一獎")
+	if err != nil {
+		t.Fatalf("Draw failed: %v", err)
+	}
+	if !eligibleIDs("二獎")[first.WinnerID] {
+		t.Error("Expected the last winner to stay eligible while the rule is off")
+	}
+
+	service.SetNoConsecutiveRepeat(testTenantID, true)
+	if ids := eligibleIDs("二獎"); ids[first.WinnerID] || len(ids) != 1 {
+		t.Errorf("Expected only the other participant to be eligible, but got %v", ids)
+	}
+
+	// With nobody else left, the last winner is eligible after all.
+	other := "1"
+	if first.WinnerID == "1" {
+		other = "2"
+	}
+	if err := service.RemoveParticipant(testTenantID, other); err != nil {
+		t.Fatalf("RemoveParticipant failed: %v", err)
+	}
+	if ids := eligibleIDs("二獎"); !ids[first.WinnerID] {
+		t.Errorf("Expected the last winner as the fallback, but got %v", ids)
+	}
+}
+
+func TestLotteryService_UniqueAcrossAll(t *testing.T) {
+	service := NewLotteryService()
+	const testTenantID = "unique-tenant"
+	service.AddPrize(testTenantID, "頭獎", "電視", 1, true)
+	service.AddPrize(testTenantID, "二獎", "手機", 1, true)
+	service.AddPrize(testTenantID, "三獎", "耳機", 1, false)
+	service.AddParticipant(testTenantID, "1", "Alice")
+	service.AddParticipant(testTenantID, "2", "Bob")
+
+	first, err := service.Draw(testTenantID, "頭獎")
+	if err != nil {
+		t.Fatalf("Draw failed: %v", err)
+	}
+	eligibleIDs := func(prizeName string) map[string]bool {
+		eligible, _ := service.GetEligibleParticipants(testTenantID, prizeName)
+		ids := make(map[string]bool)
+		for _, p := range eligible {
+			ids[p.ID] = true
+		}
+		return ids
+	}
+
+	// Flag off: the prior winner may still win another DrawFromAll prize.
+	if !eligibleIDs("二獎")[first.WinnerID] {
+		t.Errorf("Expected prior winner %s to be eligible for 二獎 with the flag off", first.WinnerID)
+	}
+	if len(eligibleIDs("三獎")) != 1 {
+		t.Error("Expected 三獎 to exclude prior winners regardless of the flag")
+	}
+
+	service.SetUniqueAcrossAll(testTenantID, true)
+	if !service.GetUniqueAcrossAll(testTenantID) {
+		t.Fatal("Expected the flag to be set")
+	}
+	on := eligibleIDs("二獎")
+	if on[first.WinnerID] || len(on) != 1 {
+		t.Errorf("Expected only the non-winner to be eligible for 二獎 with the flag on, but got %v", on)
+	}
+
+	service.SetUniqueAcrossAll(testTenantID, false)
+	if !eligibleIDs("二獎")[first.WinnerID] {
+		t.Error("Expected clearing the flag to restore the DrawFromAll behavior")
+	}
+}
+
+func TestLotteryService_AddPrize_RejectsDuplicateName(t *testing.T) {
+	service := NewLotteryService()
+	const testTenantID = "duplicate-prize-tenant"
+	if err := service.AddPrize(testTenantID, "頭獎", "電視", 1, false); err != nil {
+		t.Fatalf("AddPrize failed: %v", err)
+	}
+	if err := service.AddPrize(testTenantID, "頭獎", "手機", 2, true); err != ErrDuplicatePrize {
+		t.Errorf("Expected ErrDuplicatePrize, but got %v", err)
+	}
+	if err := service.InsertPrize(testTenantID, models.Prize{Name: "頭獎", Item: "耳機", Quantity: 3}); err != ErrDuplicatePrize {
+		t.Errorf("Expected ErrDuplicatePrize from InsertPrize, but got %v", err)
+	}
+	prizes := service.GetPrizes(testTenantID)
+	if len(prizes) != 1 || prizes[0].Item != "電視" || prizes[0].Quantity != 1 {
+		t.Errorf("Expected the original prize to be left alone, but got %+v", prizes)
+	}
+	if err := service.AddPrize("other-tenant", "頭獎", "電視", 1, false); err != nil {
+		t.Errorf("Expected another tenant to be able to use the same name, but got %v", err)
+	}
+}
+
+func TestLotteryService_GetParticipantsPage(t *testing.T) {
+	service := NewLotteryService()
+	const testTenantID = "page-tenant"
+	service.AddParticipant(testTenantID, "A001", "Alice")
+	service.AddParticipant(testTenantID, "A002", "Bob")
+	service.AddParticipant(testTenantID, "B003", "alicia")
+	service.AddParticipant(testTenantID, "B004", "Charlie")
+	service.AddParticipant(testTenantID, "B005", "Dave")
+
+	ids := func(participants []*models.Participant) []string {
+		out := make([]string, len(participants))
+		for i, p := range participants {
+			out[i] = p.ID
+		}
+		return out
+	}
+
+	tests := []struct {
+		name          string
+		query         string
+		offset, limit int
+		want          []string
+		wantTotal     int
+	}{
+		{"empty query lists everyone", "", 0, 0, []string{"A001", "A002", "B003", "B004", "B005"}, 5},
+		{"first page", "", 0, 2, []string{"A001", "A002"}, 5},
+		{"last partial page", "", 4, 2, []string{"B005"}, 5},
+		{"name match is case-insensitive", "ALI", 0, 10, []string{"A001", "B003"}, 2},
+		{"ID match", "b00", 0, 10, []string{"B003", "B004", "B005"}, 3},
+		{"filtered page", "b00", 1, 1, []string{"B004"}, 3},
+		{"no match", "zzz", 0, 10, []string{}, 0},
+		{"offset past the end", "", 10, 2, []string{}, 5},
+		{"negative offset", "", -3, 1, []string{"A001"}, 5},
+	}
+	for _, tt := range tests {
+		page, total := service.GetParticipantsPage(testTenantID, tt.query, tt.offset, tt.limit)
+		if got := ids(page); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: expected %v, but got %v", tt.name, tt.want, got)
+		}
+		if total != tt.wantTotal {
+			t.Errorf("%s: expected total %d, but got %d", tt.name, tt.wantTotal, total)
+		}
+	}
+}
+
+func TestLotteryService_SetPresence(t *testing.T) {
+	service := NewLotteryService()
+	const testTenantID = "presence-tenant"
+	service.AddPrize(testTenantID, "頭獎", "電視", 1, true)
+	service.AddParticipant(testTenantID, "1", "Alice")
+	service.AddParticipant(testTenantID, "2", "Bob")
+
+	if err := service.SetPresence(testTenantID, "2", false); err != nil {
+		t.Fatalf("SetPresence failed: %v", err)
+	}
+	for i := 0; i < 20; i++ {
+		result, err := service.Draw(testTenantID, "頭獎")
+		if err != nil {
+			t.Fatalf("Draw failed: %v", err)
+		}
+		if result.WinnerID == "2" {
+			t.Fatal("Expected the absent participant never to be drawn")
+		}
+		service.UndoLastDraw(testTenantID)
+	}
+	if participants := service.GetParticipants(testTenantID); len(participants) != 2 || participants[1].Present {
+		t.Errorf("Expected Bob to stay on the roster marked absent, but got %+v", participants)
+	}
+
+	service.SetPresence(testTenantID, "1", false)
+	if _, err := service.Draw(testTenantID, "頭獎"); err == nil {
+		t.Fatal("Expected an error drawing with everyone absent")
+	}
+
+	service.SetPresence(testTenantID, "2", true)
+	result, err := service.Draw(testTenantID, "頭獎")
+	if err != nil {
+		t.Fatalf("Draw failed: %v", err)
+	}
+	if result.WinnerID != "2" {
+		t.Errorf("Expected Bob to be drawn once present again, but got %s", result.WinnerID)
+	}
+
+	if err := service.SetPresence(testTenantID, "999", true); err == nil {
+		t.Error("Expected an error for a non-existent participant")
+	}
+}
+
+func TestLotteryService_ExcludeWinnersOf(t *testing.T) {
+	service := NewLotteryService()
+	const testTenantID = "exclude-tenant"
+	service.InsertPrize(testTenantID, models.Prize{Name: "二獎", Item: "手機", Quantity: 1, DrawFromAll: true})
+	service.InsertPrize(testTenantID, models.Prize{Name: "三獎", Item: "耳機", Quantity: 1, DrawFromAll: true})
+	service.InsertPrize(testTenantID, models.Prize{Name: "頭獎", Item: "電視", Quantity: 1, DrawFromAll: true, ExcludeWinnersOf: []string{"二獎"}})
+	service.AddParticipant(testTenantID, "1", "Alice")
+	service.AddParticipant(testTenantID, "2", "Bob")
+	service.AddParticipant(testTenantID, "3", "Charlie")
+
+	second, err := service.Draw(testTenantID, "二獎")
+	if err != nil {
+		t.Fatalf("Draw 二獎 failed: %v", err)
+	}
+	third, err := service.Draw(testTenantID, "三獎")
+	if err != nil {
+		t.Fatalf("Draw 三獎 failed: %v", err)
+	}
+
+	eligible, err := service.GetEligibleParticipants(testTenantID, "頭獎")
+	if err != nil {
+		t.Fatalf("GetEligibleParticipants failed: %v", err)
+	}
+	ids := make(map[string]bool)
+	for _, p := range eligible {
+		ids[p.ID] = true
+	}
+	if ids[second.WinnerID] {
+		t.Errorf("Expected 二獎 winner %s to be excluded from 頭獎", second.WinnerID)
+	}
+	if third.WinnerID != second.WinnerID && !ids[third.WinnerID] {
+		t.Errorf("Expected 三獎 winner %s to remain eligible for 頭獎", third.WinnerID)
+	}
+	if want := 3 - 1; len(eligible) != want {
+		t.Errorf("Expected %d eligible participants, but got %d", want, len(eligible))
+	}
+
+	for i := 0; i < 20; i++ {
+		result, err := service.Draw(testTenantID, "頭獎")
+		if err != nil {
+			t.Fatalf("Draw 頭獎 failed: %v", err)
+		}
+		if result.WinnerID == second.WinnerID {
+			t.Fatalf("Expected 頭獎 never to go to the 二獎 winner %s", second.WinnerID)
+		}
+		if _, err := service.UndoLastDraw(testTenantID); err != nil {
+			t.Fatalf("UndoLastDraw failed: %v", err)
+		}
+	}
+}
+
+func TestLotteryService_GetResultsGroupedByPrize(t *testing.T) {
+	service := NewLotteryService()
+	const testTenantID = "grouped-tenant"
+	service.AddPrize(testTenantID, "頭獎", "電視", 2, false)
+	service.AddPrize(testTenantID, "二獎", "手機", 2, false)
+	for i := 1; i <= 4; i++ {
+		service.AddParticipant(testTenantID, strconv.Itoa(i), "Participant")
+	}
+
+	var want = map[string][]string{}
+	for _, prizeName := range []string{"頭獎", "二獎", "頭獎", "二獎"} {
+		r, err := service.Draw(testTenantID, prizeName)
+		if err != nil {
+			t.Fatalf("Draw %s failed: %v", prizeName, err)
+		}
+		want[prizeName] = append(want[prizeName], r.ID)
+	}
+
+	grouped := service.GetResultsGroupedByPrize(testTenantID)
+	if len(grouped) != 2 {
+		t.Fatalf("Expected 2 prize groups, but got %d", len(grouped))
+	}
+	for prizeName, ids := range want {
+		var got []string
+		for _, r := range grouped[prizeName] {
+			if r.PrizeName != prizeName {
+				t.Errorf("Expected only %s results in its group, but found %s", prizeName, r.PrizeName)
+			}
+			got = append(got, r.ID)
+		}
+		if !reflect.DeepEqual(got, ids) {
+			t.Errorf("Expected %s results in draw order %v, but got %v", prizeName, ids, got)
+		}
+	}
+
+	grouped["頭獎"][0].WinnerName = "mutated"
+	if service.GetLotteryResults(testTenantID)[0].WinnerName == "mutated" {
+		t.Error("Expected grouped results to be copies")
+	}
+}
+
+func TestLotteryService_SetMaxWins(t *testing.T) {
+	service := NewLotteryService()
+	const testTenantID = "max-wins-tenant"
+	for _, name := range []string{"一獎", "二獎", "三獎"} {
+		service.AddPrize(testTenantID, name, "紅包", 1, true)
+	}
+	service.AddParticipant(testTenantID, "1", "Alice")
+	service.SetMaxWins(testTenantID, 2)
+
+	for _, name := range []string{"一獎", "二獎"} {
+		if _, err := service.Draw(testTenantID, name); err != nil {
+			t.Fatalf("Draw %s failed: %v", name, err)
+		}
+	}
+
+	eligible, _ := service.GetEligibleParticipants(testTenantID, "三獎")
+	if len(eligible) != 0 {
+		t.Errorf("Expected Alice to be ineligible after 2 wins even for a DrawFromAll prize, but got %+v", eligible)
+	}
+	if _, err := service.Draw(testTenantID, "三獎"); err == nil {
+		t.Error("Expected the third draw to fail once the cap is reached")
+	}
+
+	service.SetMaxWins(testTenantID, 0)
+	if eligible, _ := service.GetEligibleParticipants(testTenantID, "三獎"); len(eligible) != 1 {
+		t.Errorf("Expected Alice to be eligible again with no cap, but got %+v", eligible)
+	}
+}
+
+// captureLogger records formatted log lines for assertions.
+type captureLogger struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (l *captureLogger) Infof(format string, args ...any)  { l.record(format, args...) }
+func (l *captureLogger) Errorf(format string, args ...any) { l.record(format, args...) }
+
+func (l *captureLogger) record(format string, args ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, fmt.Sprintf(format, args...))
+}
+
+func TestLotteryService_CleanUpLogsOnlyEvictedTenant(t *testing.T) {
+	service := NewLotteryServiceWithTTL(20 * time.Millisecond)
+	capture := &captureLogger{}
+	service.SetLogger(capture)
+
+	service.AddParticipant("idle-tenant", "emp-secret", "Secret Name")
+	time.Sleep(30 * time.Millisecond)
+	service.AddParticipant("active-tenant", "002", "Other Name")
+
+	capture.lines = nil
+	if n := service.CleanUpInactiveSessions(); n != 1 {
+		t.Errorf("Expected 1 session to be reaped, but got %d", n)
+	}
+
+	if len(capture.lines) != 1 {
+		t.Fatalf("Expected exactly one eviction log line, but got %q", capture.lines)
+	}
+	line := capture.lines[0]
+	if !strings.Contains(line, `tenant="idle-tenant"`) || !strings.Contains(line, "1 sessions remain") {
+		t.Errorf("Expected the evicted tenant ID and the remaining count in %q", line)
+	}
+	for _, leak := range []string{"active-tenant", "Secret Name", "emp-secret"} {
+		if strings.Contains(line, leak) {
+			t.Errorf("Expected the eviction log not to contain %q, but got %q", leak, line)
+		}
+	}
+	if n := service.CleanUpInactiveSessions(); n != 0 {
+		t.Errorf("Expected the active session to survive, but %d were reaped", n)
+	}
+
+	// The debug flag adds the evicted session's contents.
+	service.SetCleanupDebug(true)
+	time.Sleep(30 * time.Millisecond)
+	capture.lines = nil
+	if n := service.CleanUpInactiveSessions(); n != 1 {
+		t.Errorf("Expected 1 session to be reaped with debugging on, but got %d", n)
+	}
+	if len(capture.lines) != 2 || !strings.Contains(capture.lines[1], "Other Name") {
+		t.Errorf("Expected a second line dumping the evicted session, but got %q", capture.lines)
+	}
+}
+
+func TestLotteryService_PreviewEligible(t *testing.T) {
+	service := NewLotteryService()
+	const testTenantID = "preview-tenant"
+	service.AddPrize(testTenantID, "頭獎", "電視", 1, false)
+	service.AddPrize(testTenantID, "二獎", "手機", 2, false)
+	service.AddParticipant(testTenantID, "1", "Alice")
+	service.AddParticipant(testTenantID, "2", "Bob")
+	if _, err := service.Draw(testTenantID, "頭獎"); err != nil {
+		t.Fatalf("Draw failed: %v", err)
+	}
+
+	prizes := service.GetPrizes(testTenantID)
+	participants := service.GetParticipants(testTenantID)
+	results := service.GetLotteryResults(testTenantID)
+	winners := copyWinners(service.getSession(testTenantID).Winners)
+
+	for i := 0; i < 5; i++ {
+		eligible, err := service.PreviewEligible(testTenantID, "二獎")
+		if err != nil {
+			t.Fatalf("PreviewEligible failed: %v", err)
+		}
+		if len(eligible) != 1 {
+			t.Fatalf("Expected 1 eligible participant, but got %d", len(eligible))
+		}
+	}
+	if _, err := service.PreviewEligible(testTenantID, "頭獎"); err == nil {
+		t.Error("Expected an error previewing a prize that has been fully drawn")
+	}
+
+	if !reflect.DeepEqual(service.GetPrizes(testTenantID), prizes) {
+		t.Error("Expected preview to leave prizes unchanged")
+	}
+	if !reflect.DeepEqual(service.GetParticipants(testTenantID), participants) {
+		t.Error("Expected preview to leave participants unchanged")
+	}
+	if !reflect.DeepEqual(service.GetLotteryResults(testTenantID), results) {
+		t.Error("Expected preview to leave results unchanged")
+	}
+	if !reflect.DeepEqual(service.getSession(testTenantID).Winners, winners) {
+		t.Error("Expected preview to leave winners unchanged")
+	}
+}
+
+func TestLotteryService_CountEligible(t *testing.T) {
+	service := NewLotteryService()
+	const testTenantID = "count-tenant"
+	service.AddPrize(testTenantID, "頭獎", "電視", 3, false)
+	service.AddParticipant(testTenantID, "1", "Alice")
+	service.AddParticipant(testTenantID, "2", "Bob")
+	service.AddParticipant(testTenantID, "3", "Charlie")
+
+	for want := 3; want > 0; want-- {
+		n, err := service.CountEligible(testTenantID, "頭獎")
+		if err != nil {
+			t.Fatalf("CountEligible failed: %v", err)
+		}
+		if n != want {
+			t.Fatalf("Expected %d eligible participants, but got %d", want, n)
+		}
+		winners := copyWinners(service.getSession(testTenantID).Winners)
+		service.CountEligible(testTenantID, "頭獎")
+		if !reflect.DeepEqual(service.getSession(testTenantID).Winners, winners) {
+			t.Fatal("Expected CountEligible to leave winners unchanged")
+		}
+		if _, err := service.Draw(testTenantID, "頭獎"); err != nil {
+			t.Fatalf("Draw failed: %v", err)
+		}
+	}
+
+	if n, err := service.CountEligible(testTenantID, "頭獎"); err != nil || n != 0 {
+		t.Errorf("Expected 0 eligible participants once everyone has won, but got %d (err: %v)", n, err)
+	}
+	if _, err := service.CountEligible(testTenantID, "不存在"); err == nil {
+		t.Error("Expected an error for a non-existent prize")
+	}
+}
+
+// copyWinners returns a deep copy of a session's winners map.
+func copyWinners(winners map[string]map[string]bool) map[string]map[string]bool {
+	out := make(map[string]map[string]bool, len(winners))
+	for id, prizes := range winners {
+		out[id] = make(map[string]bool, len(prizes))
+		for name, won := range prizes {
+			out[id][name] = won
+		}
+	}
+	return out
+}
+
+func TestLotteryService_AddParticipantNormalizesID(t *testing.T) {
+	service := NewLotteryService()
+	const testTenantID = "normalize-tenant"
+
+	t.Run("Test surrounding whitespace is trimmed", func(t *testing.T) {
+		if err := service.AddParticipant(testTenantID, "  001\t", "Alice"); err != nil {
+			t.Fatalf("Expected no error, but got %v", err)
+		}
+		if id := service.GetParticipants(testTenantID)[0].ID; id != "001" {
+			t.Errorf("Expected ID %q, but got %q", "001", id)
+		}
+	})
+
+	t.Run("Test duplicate after trimming is rejected", func(t *testing.T) {
+		if err := service.AddParticipant(testTenantID, " 001", "Alice again"); err != ErrDuplicateParticipant {
+			t.Errorf("Expected ErrDuplicateParticipant, but got %v", err)
+		}
+		if n, _ := service.AddParticipants(testTenantID, []*models.Participant{{ID: "001 ", Name: "Alice bulk"}}); n != 0 {
+			t.Errorf("Expected the bulk insert to skip the padded duplicate, but added %d", n)
+		}
+	})
+
+	t.Run("Test commas and newlines are rejected", func(t *testing.T) {
+		for _, id := range []string{"00,2", "003\n004", "   "} {
+			if err := service.AddParticipant(testTenantID, id, "Bob"); err == nil || err == ErrDuplicateParticipant {
+				t.Errorf("Expected a validation error for ID %q, but got %v", id, err)
+			}
+		}
+		if n := len(service.GetParticipants(testTenantID)); n != 1 {
+			t.Errorf("Expected only the first participant on the roster, but got %d", n)
+		}
+	})
+}
+
+func TestLotteryService_UpdatePrize(t *testing.T) {
+	service := NewLotteryService()
+	const testTenantID = "update-prize-tenant"
+	service.AddPrize(testTenantID, "頭獎", "電視", 3, false)
+	for i := 1; i <= 3; i++ {
+		service.AddParticipant(testTenantID, strconv.Itoa(i), "Participant")
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := service.Draw(testTenantID, "頭獎"); err != nil {
+			t.Fatalf("Draw failed: %v", err)
+		}
+	}
+
+	if err := service.UpdatePrize(testTenantID, "頭獎", "電視", 1, false); err == nil {
+		t.Error("Expected an error lowering the quantity below the 2 awarded units")
+	}
+	if q := service.GetPrizes(testTenantID)[0].Quantity; q != 1 {
+		t.Errorf("Expected the rejected update to leave 1 remaining, but got %d", q)
+	}
+
+	if err := service.UpdatePrize(testTenantID, "頭獎", "大電視", 5, true); err != nil {
+		t.Fatalf("UpdatePrize failed: %v", err)
+	}
+	prize := service.GetPrizes(testTenantID)[0]
+	if prize.Item != "大電視" || prize.Quantity != 3 || !prize.DrawFromAll {
+		t.Errorf("Expected item 大電視 with 3 remaining and DrawFromAll, but got %+v", prize)
+	}
+
+	if err := service.UpdatePrize(testTenantID, "不存在", "x", 1, false); err == nil {
+		t.Error("Expected an error updating a prize that does not exist")
+	}
+}
+
+func TestLotteryService_DrawBatchOnce(t *testing.T) {
+	service := NewLotteryService()
+	const testTenantID = "idempotency-tenant"
+	service.AddPrize(testTenantID, "頭獎", "電視", 2, false)
+	service.AddParticipant(testTenantID, "1", "Alice")
+	service.AddParticipant(testTenantID, "2", "Bob")
+
+	first, err := service.DrawBatchOnce(testTenantID, "key-1", "頭獎", 1)
+	if err != nil {
+		t.Fatalf("DrawBatchOnce failed: %v", err)
+	}
+	again, err := service.DrawBatchOnce(testTenantID, "key-1", "頭獎", 1)
+	if err != nil {
+		t.Fatalf("Repeated DrawBatchOnce failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(first, again) {
+		t.Errorf("Expected the repeated key to return the original result %+v, but got %+v", first, again)
+	}
+	if n := len(service.GetLotteryResults(testTenantID)); n != 1 {
+		t.Errorf("Expected exactly one winner, but got %d", n)
+	}
+	if q := service.GetPrizes(testTenantID)[0].Quantity; q != 1 {
+		t.Errorf("Expected one quantity decrement leaving 1, but got %d", q)
+	}
+
+	if _, err := service.DrawBatchOnce(testTenantID, "key-2", "頭獎", 1); err != nil {
+		t.Fatalf("DrawBatchOnce with a new key failed: %v", err)
+	}
+	if n := len(service.GetLotteryResults(testTenantID)); n != 2 {
+		t.Errorf("Expected a new key to draw again, but got %d results", n)
+	}
+
+	// Expired keys are pruned and no longer replay.
+	session := service.getSession(testTenantID)
+	session.processedDraws["key-1"] = processedDraw{at: time.Now().Add(-2 * idempotencyWindow)}
+	service.DrawBatchOnce(testTenantID, "key-3", "頭獎", 1)
+	if _, ok := session.processedDraws["key-1"]; ok {
+		t.Error("Expected the expired key to be pruned")
+	}
+}
+
+func TestLotteryService_ResetResults(t *testing.T) {
+	service := NewLotteryService()
+	const testTenantID = "reset-tenant"
+	service.AddPrize(testTenantID, "頭獎", "電視", 2, false)
+	service.AddParticipant(testTenantID, "1", "Alice")
+	service.AddParticipant(testTenantID, "2", "Bob")
+	service.AddParticipant(testTenantID, "3", "Charlie")
+
+	if _, err := service.DrawBatch(testTenantID, "頭獎", 2); err != nil {
+		t.Fatalf("DrawBatch failed: %v", err)
+	}
+	if q := service.GetPrizes(testTenantID)[0].Quantity; q != 0 {
+		t.Fatalf("Expected the prize to be drawn down, but %d remain", q)
+	}
+
+	if err := service.ResetResults(testTenantID); err != nil {
+		t.Fatalf("ResetResults failed: %v", err)
+	}
+	prize := service.GetPrizes(testTenantID)[0]
+	if prize.Quantity != 2 || prize.OriginalQuantity != 2 {
+		t.Errorf("Expected the quantity to be restored to 2, but got %+v", prize)
+	}
+	if n := len(service.GetLotteryResults(testTenantID)); n != 0 {
+		t.Errorf("Expected no results after reset, but got %d", n)
+	}
+	if n := len(service.GetParticipants(testTenantID)); n != 3 {
+		t.Errorf("Expected the roster to be kept, but got %d participants", n)
+	}
+	eligible, _ := service.GetEligibleParticipants(testTenantID, "頭獎")
+	if len(eligible) != 3 {
+		t.Errorf("Expected everyone to be eligible again, but got %d", len(eligible))
+	}
+
+	result, _ := service.Draw(testTenantID, "頭獎")
+	service.LockResult(testTenantID, result.ID)
+	if err := service.ResetResults(testTenantID); err != ErrResultLocked {
+		t.Errorf("Expected ErrResultLocked when a result is locked, but got %v", err)
+	}
+}
+
+func TestLotteryService_ClearParticipants_KeepResults(t *testing.T) {
+	service := NewLotteryService()
+	const testTenantID = "clear-keep-tenant"
+	service.AddPrize(testTenantID, "頭獎", "電視", 1, false)
+	service.AddParticipant(testTenantID, "1", "Alice")
+	service.AddParticipant(testTenantID, "2", "Bob")
+	service.AddParticipant(testTenantID, "3", "Charlie")
+	result, err := service.Draw(testTenantID, "頭獎")
+	if err != nil {
+		t.Fatalf("Draw failed: %v", err)
+	}
+
+	removed, err := service.ClearParticipants(testTenantID, false)
+	if err != nil {
+		t.Fatalf("ClearParticipants failed: %v", err)
+	}
+	if removed != 2 {
+		t.Errorf("Expected 2 participants to be removed, but got %d", removed)
+	}
+	participants := service.GetParticipants(testTenantID)
+	if len(participants) != 1 || participants[0].ID != result.WinnerID {
+		t.Errorf("Expected only the winner %s to remain, but got %+v", result.WinnerID, participants)
+	}
+	if results := service.GetLotteryResults(testTenantID); len(results) != 1 {
+		t.Errorf("Expected the result to be preserved, but got %d results", len(results))
+	}
+}
+
+func TestLotteryService_ClearParticipants_ClearResults(t *testing.T) {
+	service := NewLotteryService()
+	const testTenantID = "clear-all-tenant"
+	service.AddPrize(testTenantID, "頭獎", "電視", 1, false)
+	service.AddParticipant(testTenantID, "1", "Alice")
+	service.AddParticipant(testTenantID, "2", "Bob")
+	result, err := service.Draw(testTenantID, "頭獎")
+	if err != nil {
+		t.Fatalf("Draw failed: %v", err)
+	}
+
+	removed, err := service.ClearParticipants(testTenantID, true)
+	if err != nil {
+		t.Fatalf("ClearParticipants failed: %v", err)
+	}
+	if removed != 2 || len(service.GetParticipants(testTenantID)) != 0 {
+		t.Errorf("Expected every participant to be removed, but removed %d", removed)
+	}
+	if n := len(service.GetLotteryResults(testTenantID)); n != 0 {
+		t.Errorf("Expected results to be cleared, but got %d", n)
+	}
+	if len(service.getSession(testTenantID).Winners) != 0 {
+		t.Error("Expected winners to be cleared")
+	}
+	if q := service.GetPrizes(testTenantID)[0].Quantity; q != 1 {
+		t.Errorf("Expected the prize quantity to be restored to 1, but got %d", q)
+	}
+
+	// A locked result can't be cleared away.
+	service.AddParticipant(testTenantID, "3", "Charlie")
+	result, err = service.Draw(testTenantID, "頭獎")
+	if err != nil {
+		t.Fatalf("Draw failed: %v", err)
+	}
+	if err := service.LockResult(testTenantID, result.ID); err != nil {
+		t.Fatalf("LockResult failed: %v", err)
+	}
+	if _, err := service.ClearParticipants(testTenantID, true); err != ErrResultLocked {
+		t.Fatalf("Expected ErrResultLocked, but got %v", err)
+	}
+	if n := len(service.GetParticipants(testTenantID)); n != 1 {
+		t.Errorf("Expected a refused clear to keep the participant, but got %d", n)
+	}
+}
+
+func TestLotteryService_ReorderPrizes(t *testing.T) {
+	service := NewLotteryService()
+	const testTenantID = "reorder-tenant"
+	for _, name := range []string{"頭獎", "二獎", "三獎", "參加獎"} {
+		service.AddPrize(testTenantID, name, "禮物", 1, false)
+	}
+	names := func() []string {
+		var out []string
+		for _, p := range service.GetPrizes(testTenantID) {
+			out = append(out, p.Name)
+		}
+		return out
+	}
+
+	if err := service.ReorderPrizes(testTenantID, []string{"參加獎", "三獎"}); err != nil {
+		t.Fatalf("ReorderPrizes failed: %v", err)
+	}
+	if got, want := names(), []string{"參加獎", "三獎", "頭獎", "二獎"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected order %v, but got %v", want, got)
+	}
+
+	service.AddPrize(testTenantID, "加碼獎", "紅包", 1, false)
+	if got := names(); got[len(got)-1] != "加碼獎" {
+		t.Errorf("Expected a new prize to be appended last, but got %v", got)
+	}
+
+	before := names()
+	for _, bad := range [][]string{{"二獎", "不存在"}, {"二獎", "二獎"}} {
+		if err := service.ReorderPrizes(testTenantID, bad); err == nil {
+			t.Errorf("Expected an error for %v", bad)
+		}
+		if got := names(); !reflect.DeepEqual(got, before) {
+			t.Errorf("Expected a rejected reorder %v to leave %v, but got %v", bad, before, got)
+		}
+	}
+}
+
+func TestLotteryService_ConcurrentDraws(t *testing.T) {
+	const (
+		testTenantID = "concurrent-draw-tenant"
+		quantity     = 20
+		drawers      = quantity * 3
+	)
+	service := NewLotteryService()
+	service.AddPrize(testTenantID, "頭獎", "電視", quantity, false)
+	for i := 0; i < drawers; i++ {
+		service.AddParticipant(testTenantID, strconv.Itoa(i), "Participant")
+	}
+
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i := 0; i < drawers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			service.Draw(testTenantID, "頭獎")
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	results := service.GetLotteryResults(testTenantID)
+	if len(results) != quantity {
+		t.Errorf("Expected exactly %d winners, but got %d", quantity, len(results))
+	}
+	if q := service.GetPrizes(testTenantID)[0].Quantity; q != 0 {
+		t.Errorf("Expected the prize to be drawn down to exactly 0, but got %d", q)
+	}
+	seen := make(map[string]bool)
+	for _, r := range results {
+		if seen[r.WinnerID] {
+			t.Errorf("Expected distinct winners, but %s won twice", r.WinnerID)
+		}
+		seen[r.WinnerID] = true
+	}
+}
+
+func TestLotteryService_Version(t *testing.T) {
+	const testTenantID = "version-tenant"
+	service := NewLotteryService()
+	if v := service.Version(testTenantID); v != 0 {
+		t.Errorf("Expected version 0 without a session, but got %d", v)
+	}
+
+	service.AddParticipant(testTenantID, "001", "Alice")
+	seen := map[uint64]bool{service.Version(testTenantID): true}
+	changed := func(what string) {
+		t.Helper()
+		v := service.Version(testTenantID)
+		if seen[v] {
+			t.Errorf("Expected %s to give a new version, but got %d again", what, v)
+		}
+		seen[v] = true
+	}
+
+	service.AddPrize(testTenantID, "頭獎", "電視", 1, false)
+	changed("AddPrize")
+	service.Draw(testTenantID, "頭獎")
+	changed("Draw")
+	service.ReorderPrizes(testTenantID, []string{"頭獎"})
+	changed("ReorderPrizes")
+
+	before := service.Version(testTenantID)
+	service.GetPrizes(testTenantID)
+	service.GetParticipants(testTenantID)
+	if v := service.Version(testTenantID); v != before {
+		t.Errorf("Expected reads to keep version %d, but got %d", before, v)
+	}
+
+	service.ClearSession(testTenantID)
+	service.GetPrizes(testTenantID)
+	changed("recreating the session")
+}
+
+func TestLotteryService_TenantIsolation(t *testing.T) {
+	const tenantA, tenantB = "TenantA", "TenantB"
+	service := NewLotteryService()
+
+	// Both tenants get the same prize and participant IDs from one shared slice,
+	// so anything stored by reference would show up in the other tenant.
+	shared := []*models.Participant{{ID: "001", Name: "Alice"}, {ID: "002", Name: "Bob"}}
+	for _, tenantID := range []string{tenantA, tenantB} {
+		service.AddPrize(tenantID, "頭獎", "電視", 1, false)
+		service.AddParticipants(tenantID, shared)
+	}
+	service.AddPrize(tenantA, "A獎", "手機", 1, false)
+	service.AddParticipant(tenantB, "B01", "Betty")
+
+	winnerA, err := service.Draw(tenantA, "A獎")
+	if err != nil {
+		t.Fatalf("Draw for %s failed: %v", tenantA, err)
+	}
+	service.SetPresence(tenantA, "002", false)
+	service.ReserveWinner(tenantB, "頭獎", "B01")
+	winnerB, err := service.Draw(tenantB, "頭獎")
+	if err != nil || winnerB.WinnerID != "B01" {
+		t.Fatalf("Expected %s's reserved draw to pick B01, but got %v (%v)", tenantB, winnerB, err)
+	}
+
+	names := func(prizes []*models.Prize) (out []string) {
+		for _, p := range prizes {
+			out = append(out, p.Name)
+		}
+		return out
+	}
+	if got := names(service.GetPrizes(tenantA)); !slices.Equal(got, []string{"頭獎", "A獎"}) {
+		t.Errorf("Expected %s's prizes [頭獎 A獎], but got %v", tenantA, got)
+	}
+	if got := names(service.GetPrizes(tenantB)); !slices.Equal(got, []string{"頭獎"}) {
+		t.Errorf("Expected %s's prizes [頭獎], but got %v", tenantB, got)
+	}
+	if q := service.GetPrizes(tenantA)[0].Quantity; q != 1 {
+		t.Errorf("Expected %s's 頭獎 to be untouched by %s's draw, but it has %d left", tenantA, tenantB, q)
+	}
+	for _, p := range service.GetParticipants(tenantB) {
+		if p.ID == "002" && !p.Present {
+			t.Errorf("Expected %s's presence change not to reach %s", tenantA, tenantB)
+		}
+	}
+	if got := len(service.GetParticipants(tenantA)); got != 2 {
+		t.Errorf("Expected %s to have 2 participants, but got %d", tenantA, got)
+	}
+	if results := service.GetLotteryResults(tenantA); len(results) != 1 || results[0].ID != winnerA.ID {
+		t.Errorf("Expected %s's results to hold only %s, but got %v", tenantA, winnerA.ID, results)
+	}
+	if results := service.GetLotteryResults(tenantB); len(results) != 1 || results[0].ID != winnerB.ID {
+		t.Errorf("Expected %s's results to hold only %s, but got %v", tenantB, winnerB.ID, results)
+	}
+	if wins, err := service.GetParticipantWins(tenantB, winnerA.WinnerID); err != nil || len(wins) != 0 {
+		t.Errorf("Expected %s's winner to have no wins in %s, but got %v (%v)", tenantA, tenantB, wins, err)
+	}
+
+	service.ClearSession(tenantA)
+	if len(service.GetPrizes(tenantA)) != 0 || len(service.GetLotteryResults(tenantA)) != 0 {
+		t.Errorf("Expected %s to be empty after ClearSession", tenantA)
+	}
+	if len(service.GetPrizes(tenantB)) != 1 || len(service.GetParticipants(tenantB)) != 3 || len(service.GetLotteryResults(tenantB)) != 1 {
+		t.Errorf("Expected ClearSession(%s) to leave %s intact", tenantA, tenantB)
+	}
+}
+
+func TestLotteryService_TenantsDoNotBlockEachOther(t *testing.T) {
+	service := NewLotteryService()
+	for _, tenantID := range []string{"busy-tenant", "other-tenant"} {
+		service.AddPrize(tenantID, "頭獎", "電視", 1, false)
+		service.AddParticipant(tenantID, "001", "Alice")
+	}
+
+	// Hold the busy tenant's session as a long upload would.
+	busy := service.lockSession("busy-tenant")
+
+	drawn := make(chan error)
+	go func() {
+		_, err := service.Draw("other-tenant", "頭獎")
+		drawn <- err
+	}()
+	select {
+	case err := <-drawn:
+		if err != nil {
+			t.Errorf("Expected the other tenant's draw to succeed, but got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the other tenant's draw not to wait for the busy tenant")
+	}
+	if n := service.Metrics().ActiveSessions; n != 2 {
+		t.Errorf("Expected 2 sessions, but got %d", n)
+	}
+
+	// A same-tenant call waits, and still lands once the session is cleared
+	// under it: it starts over on the fresh session instead of the removed one.
+	added := make(chan error)
+	go func() { added <- service.AddPrize("busy-tenant", "二獎", "手機", 1, false) }()
+	select {
+	case <-added:
+		t.Fatal("Expected a same-tenant call to wait for the held session")
+	case <-time.After(20 * time.Millisecond):
+	}
+	service.ClearSession("busy-tenant")
+	busy.mu.Unlock()
+	if err := <-added; err != nil {
+		t.Fatalf("AddPrize failed: %v", err)
+	}
+	if prizes := service.GetPrizes("busy-tenant"); len(prizes) != 1 || prizes[0].Name != "二獎" {
+		t.Errorf("Expected only 二獎 in the recreated session, but got %v", prizes)
+	}
+}
+
+// BenchmarkDraw_ParallelTenants draws for a different tenant on every
+// goroutine; with per-session locks it scales with GOMAXPROCS.
+func BenchmarkDraw_ParallelTenants(b *testing.B) {
+	service := NewLotteryService()
+	var next atomic.Int64
+	b.RunParallel(func(pb *testing.PB) {
+		tenantID := fmt.Sprintf("bench-tenant-%d", next.Add(1))
+		participants := make([]*models.Participant, 1000)
+		for i := range participants {
+			participants[i] = &models.Participant{ID: strconv.Itoa(i), Name: "Participant"}
+		}
+		service.AddParticipants(tenantID, participants)
+		service.AddPrize(tenantID, "頭獎", "電視", 1<<30, false)
+		for pb.Next() {
+			if _, err := service.Draw(tenantID, "頭獎"); errors.Is(err, ErrNoEligibleParticipants) {
+				service.ResetResults(tenantID) // Everyone has won; start the roster over
+			}
+		}
+	})
+}
+
+func TestLotteryService_ListSessions(t *testing.T) {
+	service := NewLotteryService()
+	service.AddPrize("tenant-b", "頭獎", "電視", 1, false)
+	service.AddParticipant("tenant-b", "001", "Alice")
+	service.AddParticipant("tenant-b", "002", "Bob")
+	service.Draw("tenant-b", "頭獎")
+	service.AddParticipant("tenant-a", "001", "Alice")
+
+	infos := service.ListSessions()
+	if len(infos) != 2 || infos[0].TenantID != "tenant-a" || infos[1].TenantID != "tenant-b" {
+		t.Fatalf("Expected tenant-a and tenant-b in order, but got %+v", infos)
+	}
+	if b := infos[1]; b.Prizes != 1 || b.Participants != 2 || b.Results != 1 || b.LastActivity.IsZero() {
+		t.Errorf("Expected tenant-b to report 1 prize, 2 participants and 1 result, but got %+v", b)
+	}
+
+	last := infos[0].LastActivity
+	time.Sleep(time.Millisecond)
+	if again := service.ListSessions(); !again[0].LastActivity.Equal(last) {
+		t.Errorf("Expected ListSessions not to refresh LastActivity, but it moved from %v to %v", last, again[0].LastActivity)
+	}
+}
+
+func TestLotteryService_CloneConfig(t *testing.T) {
+	service := NewLotteryService()
+	service.AddPrize("room-a", "頭獎", "電視", 2, false)
+	service.AddParticipant("room-a", "001", "Alice")
+	service.AddParticipant("room-a", "002", "Bob")
+	service.SetMaskIDs("room-a", true)
+	if _, err := service.Draw("room-a", "頭獎"); err != nil {
+		t.Fatalf("Draw failed: %v", err)
+	}
+
+	if err := service.CloneConfig("room-a", "room-b"); err != nil {
+		t.Fatalf("CloneConfig failed: %v", err)
+	}
+	prizes := service.GetPrizes("room-b")
+	if len(prizes) != 1 || prizes[0].Quantity != 2 || prizes[0].OriginalQuantity != 2 {
+		t.Errorf("Expected the clone to have the prize at its full quantity of 2, but got %+v", prizes)
+	}
+	if n := len(service.GetParticipants("room-b")); n != 2 {
+		t.Errorf("Expected 2 cloned participants, but got %d", n)
+	}
+	if n := len(service.GetLotteryResults("room-b")); n != 0 {
+		t.Errorf("Expected the clone to have no results, but got %d", n)
+	}
+	if !service.GetMaskIDs("room-b") {
+		t.Error("Expected the clone to keep the source's settings")
+	}
+	if eligible, _ := service.GetEligibleParticipants("room-b", "頭獎"); len(eligible) != 2 {
+		t.Errorf("Expected both participants to be eligible in the clone, but got %d", len(eligible))
+	}
+
+	// Drawing in the clone leaves the source alone.
+	service.Draw("room-b", "頭獎")
+	if q := service.GetPrizes("room-a")[0].Quantity; q != 1 {
+		t.Errorf("Expected the source prize to keep quantity 1, but got %d", q)
+	}
+
+	if err := service.CloneConfig("room-a", "room-b"); !errors.Is(err, ErrCloneTargetNotEmpty) {
+		t.Errorf("Expected ErrCloneTargetNotEmpty, but got %v", err)
+	}
+	if err := service.CloneConfig("missing", "room-c"); !errors.Is(err, ErrCloneSourceMissing) {
+		t.Errorf("Expected ErrCloneSourceMissing, but got %v", err)
+	}
+	if infos := service.ListSessions(); len(infos) != 2 {
+		t.Errorf("Expected failed clones not to create sessions, but got %+v", infos)
+	}
+}
+
+func TestLotteryService_RunJanitor(t *testing.T) {
+	service := NewLotteryServiceWithTTL(time.Millisecond)
+	const testTenantID = "janitor-tenant"
+	service.AddPrize(testTenantID, "頭獎", "電視", 1, false)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		service.RunJanitor(ctx, 5*time.Millisecond)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		service.mu.Lock()
+		_, exists := service.sessions[testTenantID]
+		service.mu.Unlock()
+		if !exists {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the janitor to evict the inactive session")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected RunJanitor to return once its context is cancelled")
+	}
+}
+
+func TestLotteryService_DrawAllRemaining(t *testing.T) {
+	service := NewLotteryService()
+	const testTenantID = "draw-all-tenant"
+	service.AddPrize(testTenantID, "頭獎", "電視", 1, false)
+	service.AddPrize(testTenantID, "二獎", "手機", 2, false)
+	service.AddPrize(testTenantID, "三獎", "耳機", 3, false)
+	for i := 1; i <= 4; i++ {
+		service.AddParticipant(testTenantID, strconv.Itoa(i), "P"+strconv.Itoa(i))
+	}
+
+	results, remaining, err := service.DrawAllRemaining(testTenantID)
+	if err != nil {
+		t.Fatalf("DrawAllRemaining failed: %v", err)
+	}
+	if len(results) != 4 {
+		t.Fatalf("Expected every participant to win once (4 results), but got %d", len(results))
+	}
+	perPrize := make(map[string]int)
+	winners := make(map[string]bool)
+	for _, r := range results {
+		perPrize[r.PrizeName]++
+		if winners[r.WinnerID] {
+			t.Errorf("Expected %s to win only once", r.WinnerID)
+		}
+		winners[r.WinnerID] = true
+	}
+	if want := map[string]int{"頭獎": 1, "二獎": 2, "三獎": 1}; !reflect.DeepEqual(perPrize, want) {
+		t.Errorf("Expected prizes to be drawn in order as %v, but got %v", want, perPrize)
+	}
+	if want := map[string]int{"三獎": 2}; !reflect.DeepEqual(remaining, want) {
+		t.Errorf("Expected %v left undrawn, but got %v", want, remaining)
+	}
+
+	// With the pool exhausted, a second run draws nothing and reports the same leftovers.
+	results, remaining, err = service.DrawAllRemaining(testTenantID)
+	if err != nil {
+		t.Fatalf("Second DrawAllRemaining failed: %v", err)
+	}
+	if len(results) != 0 || remaining["三獎"] != 2 {
+		t.Errorf("Expected no further results and 2 units of 三獎 left, but got %d results and %v", len(results), remaining)
+	}
+}
+
+func TestLotteryService_AllocatePrizes(t *testing.T) {
+	setup := func(tenantID string) *LotteryService {
+		service := NewLotteryService()
+		service.AddPrize(tenantID, "頭獎", "電視", 1, false)
+		service.AddPrize(tenantID, "二獎", "手機", 2, false)
+		service.AddPrize(tenantID, "參加獎", "禮券", 4, true)
+		for i := 1; i <= 4; i++ {
+			service.AddParticipant(tenantID, strconv.Itoa(i), "P"+strconv.Itoa(i))
+		}
+		service.SetSeed(tenantID, 42)
+		return service
+	}
+
+	const testTenantID = "allocate-tenant"
+	service := setup(testTenantID)
+	results, err := service.AllocatePrizes(testTenantID)
+	if err != nil {
+		t.Fatalf("AllocatePrizes failed: %v", err)
+	}
+	perPrize := make(map[string]int)
+	mainWinners := make(map[string]bool)
+	for _, r := range results {
+		perPrize[r.PrizeName]++
+		if r.PrizeName == "參加獎" {
+			continue
+		}
+		if mainWinners[r.WinnerID] {
+			t.Errorf("Expected %s to win only one non-DrawFromAll prize", r.WinnerID)
+		}
+		mainWinners[r.WinnerID] = true
+	}
+	// 參加獎 draws from everyone, so the three main winners are eligible for it too.
+	if want := map[string]int{"頭獎": 1, "二獎": 2, "參加獎": 4}; !reflect.DeepEqual(perPrize, want) {
+		t.Errorf("Expected allocation %v, but got %v", want, perPrize)
+	}
+	for _, p := range service.GetPrizes(testTenantID) {
+		if p.Quantity != 0 {
+			t.Errorf("Expected prize %s to be used up, but %d units are left", p.Name, p.Quantity)
+		}
+	}
+	batchID := results[0].BatchID
+	for _, r := range results {
+		if batchID == "" || r.BatchID != batchID {
+			t.Fatalf("Expected every result to share one BatchID, but got %q and %q", batchID, r.BatchID)
+		}
+	}
+
+	// The same seed, roster and prizes allocate the same winners.
+	again, err := setup(testTenantID).AllocatePrizes(testTenantID)
+	if err != nil {
+		t.Fatalf("Second AllocatePrizes failed: %v", err)
+	}
+	for i := range results {
+		if results[i].PrizeName != again[i].PrizeName || results[i].WinnerID != again[i].WinnerID {
+			t.Fatalf("Expected seeded allocation to repeat, but result %d changed from %s/%s to %s/%s",
+				i, results[i].PrizeName, results[i].WinnerID, again[i].PrizeName, again[i].WinnerID)
+		}
+	}
+
+	// Quantity beyond the eligible pool is left undrawn rather than failing.
+	const shortTenantID = "allocate-short-tenant"
+	short := NewLotteryService()
+	short.AddPrize(shortTenantID, "頭獎", "電視", 3, false)
+	short.AddParticipant(shortTenantID, "1", "P1")
+	short.AddParticipant(shortTenantID, "2", "P2")
+	results, err = short.AllocatePrizes(shortTenantID)
+	if err != nil {
+		t.Fatalf("AllocatePrizes with a short pool failed: %v", err)
+	}
+	if len(results) != 2 || short.GetPrizes(shortTenantID)[0].Quantity != 1 {
+		t.Errorf("Expected 2 winners and 1 unit left, but got %d winners and %d left", len(results), short.GetPrizes(shortTenantID)[0].Quantity)
+	}
+
+	if _, err := NewLotteryService().AllocatePrizes("allocate-empty-tenant"); !errors.Is(err, ErrNoParticipants) {
+		t.Errorf("Expected ErrNoParticipants for an empty roster, but got %v", err)
+	}
+}
+
+func TestLotteryService_ValidateSetup(t *testing.T) {
+	service := NewLotteryService()
+	const testTenantID = "validate-tenant"
+	service.AddPrize(testTenantID, "頭獎", "電視", 1, false)
+	service.AddPrize(testTenantID, "參加獎", "禮券", 3, true)
+	for i := 1; i <= 4; i++ {
+		service.AddParticipant(testTenantID, strconv.Itoa(i), "P"+strconv.Itoa(i))
+	}
+	if warnings := service.ValidateSetup(testTenantID); len(warnings) != 0 {
+		t.Errorf("Expected no warnings for a clean setup, but got %v", warnings)
+	}
+
+	service.AddPrize(testTenantID, "二獎", "手機", 10, false)
+	service.InsertPrize(testTenantID, models.Prize{Name: "業務獎", Item: "耳機", Quantity: 2, Group: "Sales"})
+	warnings := service.ValidateSetup(testTenantID)
+	want := []string{"獎項二獎數量(10)超過合格人數(4)", "獎項業務獎數量(2)超過合格人數(0)"}
+	if !reflect.DeepEqual(warnings, want) {
+		t.Errorf("Expected warnings %v, but got %v", want, warnings)
+	}
+	if got := service.ValidateSetupIn(i18n.En, testTenantID); len(got) != 2 || got[0] != i18n.T(i18n.En, "setup_over_pool", "二獎", 10, 4) {
+		t.Errorf("Expected English warnings, but got %v", got)
+	}
+}
+
+func TestLotteryService_DrawWithReveal(t *testing.T) {
+	service := NewLotteryService()
+	const testTenantID = "reveal-tenant"
+	service.InsertPrize(testTenantID, models.Prize{Name: "業務獎", Item: "禮券", Quantity: 1, Group: "Sales"})
+	service.AddPrize(testTenantID, "頭獎", "電視", 1, false)
+	for i := 1; i <= 40; i++ {
+		group := "RD"
+		if i%2 == 0 {
+			group = "Sales"
+		}
+		service.InsertParticipant(testTenantID, models.Participant{ID: strconv.Itoa(i), Name: "P" + strconv.Itoa(i), Group: group})
+	}
+	// An earlier winner must not show up as a decoy.
+	earlier, err := service.Draw(testTenantID, "頭獎")
+	if err != nil {
+		t.Fatalf("Setup draw failed: %v", err)
+	}
+
+	eligibleBefore, err := service.GetEligibleParticipants(testTenantID, "業務獎")
+	if err != nil {
+		t.Fatalf("GetEligibleParticipants failed: %v", err)
+	}
+	eligibleNames := make(map[string]bool)
+	for _, p := range eligibleBefore {
+		eligibleNames[p.Name] = true
+	}
+
+	reveal, err := service.DrawWithReveal(testTenantID, "業務獎")
+	if err != nil {
+		t.Fatalf("DrawWithReveal failed: %v", err)
+	}
+	if !slices.Contains(reveal.Decoys, reveal.Result.WinnerName) {
+		t.Errorf("Expected decoys %v to contain the winner %s", reveal.Decoys, reveal.Result.WinnerName)
+	}
+	if want := min(len(eligibleBefore), maxRevealDecoys); len(reveal.Decoys) != want {
+		t.Errorf("Expected %d decoys, but got %d", want, len(reveal.Decoys))
+	}
+	for _, name := range reveal.Decoys {
+		if !eligibleNames[name] {
+			t.Errorf("Decoy %s was not eligible for the prize", name)
+		}
+		if name == earlier.WinnerName {
+			t.Errorf("Earlier winner %s should not be a decoy", name)
+		}
+	}
+
+	if _, err := service.DrawWithReveal(testTenantID, "業務獎"); err == nil {
+		t.Error("Expected an error when drawing an exhausted prize, but got nil")
+	}
+}
+
+func TestLotteryService_SentinelErrors(t *testing.T) {
+	service := NewLotteryService()
+	const testTenantID = "sentinel-tenant"
+	service.AddPrize(testTenantID, "頭獎", "電視", 1, false)
+	service.InsertPrize(testTenantID, models.Prize{Name: "業務獎", Item: "禮券", Quantity: 1, Group: "Sales"})
+	service.AddParticipant(testTenantID, "001", "Alice")
+	if _, err := service.Draw(testTenantID, "頭獎"); err != nil {
+		t.Fatalf("Setup draw failed: %v", err)
+	}
+
+	if _, err := service.Draw(testTenantID, "不存在"); !errors.Is(err, ErrPrizeNotFound) {
+		t.Errorf("Expected ErrPrizeNotFound, but got %v", err)
+	}
+	if _, err := service.Draw(testTenantID, "頭獎"); !errors.Is(err, ErrPrizeExhausted) {
+		t.Errorf("Expected ErrPrizeExhausted, but got %v", err)
+	}
+	_, err := service.GetEligibleParticipants(testTenantID, "業務獎")
+	if !errors.Is(err, ErrNoEligibleParticipants) {
+		t.Errorf("Expected ErrNoEligibleParticipants, but got %v", err)
+	}
+	if err != nil && !strings.Contains(err.Error(), "業務獎") {
+		t.Errorf("Expected the error to name the prize, but got %q", err)
+	}
+}
+
+func TestLotteryService_ReserveWinner(t *testing.T) {
+	const testTenantID = "reserve-tenant"
+	newService := func() *LotteryService {
+		service := NewLotteryService()
+		service.AddPrize(testTenantID, "贊助獎", "機票", 2, false)
+		for i := 1; i <= 20; i++ {
+			service.AddParticipant(testTenantID, strconv.Itoa(i), "P"+strconv.Itoa(i))
+		}
+		return service
+	}
+
+	t.Run("Test reserved draw honors the reservation", func(t *testing.T) {
+		service := newService()
+		if err := service.ReserveWinner(testTenantID, "贊助獎", "17"); err != nil {
+			t.Fatalf("ReserveWinner failed: %v", err)
+		}
+		result, err := service.Draw(testTenantID, "贊助獎")
+		if err != nil {
+			t.Fatalf("Draw failed: %v", err)
+		}
+		if result.WinnerID != "17" {
+			t.Errorf("Expected the reserved participant 17 to win, but got %s", result.WinnerID)
+		}
+		if q := service.GetPrizes(testTenantID)[0].Quantity; q != 1 {
+			t.Errorf("Expected the reservation to consume a unit, but %d remain", q)
+		}
+		if got := service.getSession(testTenantID).Reservations; len(got) != 0 {
+			t.Errorf("Expected the reservation to be consumed, but got %v", got)
+		}
+	})
+
+	t.Run("Test ineligible reservation falls back to random", func(t *testing.T) {
+		service := newService()
+		if err := service.ReserveWinner(testTenantID, "贊助獎", "5"); err != nil {
+			t.Fatalf("ReserveWinner failed: %v", err)
+		}
+		service.SetPresence(testTenantID, "5", false)
+		result, err := service.Draw(testTenantID, "贊助獎")
+		if err != nil {
+			t.Fatalf("Draw failed: %v", err)
+		}
+		if result.WinnerID == "5" {
+			t.Error("Expected an absent reserved participant not to win")
+		}
+	})
+
+	t.Run("Test invalid reservations", func(t *testing.T) {
+		service := newService()
+		if err := service.ReserveWinner(testTenantID, "贊助獎", "999"); err == nil {
+			t.Error("Expected an error reserving for a nonexistent participant, but got nil")
+		}
+		if err := service.ReserveWinner(testTenantID, "不存在", "1"); !errors.Is(err, ErrPrizeNotFound) {
+			t.Errorf("Expected ErrPrizeNotFound, but got %v", err)
+		}
+		service.ReserveWinner(testTenantID, "贊助獎", "1")
+		service.ReserveWinner(testTenantID, "贊助獎", "2")
+		if err := service.ReserveWinner(testTenantID, "贊助獎", "3"); err == nil {
+			t.Error("Expected an error reserving more winners than units left, but got nil")
+		}
+	})
+}
+
+func TestLotteryService_EmptyRosterError(t *testing.T) {
+	service := NewLotteryService()
+	const testTenantID = "empty-roster-tenant"
+	service.AddPrize(testTenantID, "頭獎", "電視", 1, false)
+	service.AddPrize(testTenantID, "二獎", "手機", 1, false)
+
+	_, err := service.GetEligibleParticipants(testTenantID, "頭獎")
+	if !errors.Is(err, ErrNoParticipants) || errors.Is(err, ErrNoEligibleParticipants) {
+		t.Errorf("Expected ErrNoParticipants for an empty roster, but got %v", err)
+	}
+	if _, err := service.Draw(testTenantID, "頭獎"); !errors.Is(err, ErrNoParticipants) {
+		t.Errorf("Expected Draw to report ErrNoParticipants, but got %v", err)
+	}
+
+	// Once everyone has won, the pool is empty for a different reason.
+	service.AddParticipant(testTenantID, "001", "Alice")
+	if _, err := service.Draw(testTenantID, "頭獎"); err != nil {
+		t.Fatalf("Draw failed: %v", err)
+	}
+	_, err = service.GetEligibleParticipants(testTenantID, "二獎")
+	if !errors.Is(err, ErrNoEligibleParticipants) || errors.Is(err, ErrNoParticipants) {
+		t.Errorf("Expected ErrNoEligibleParticipants once everyone has won, but got %v", err)
+	}
+}
+
+func TestLotteryService_DrawTransaction(t *testing.T) {
+	const testTenantID = "transaction-tenant"
+	newService := func() *LotteryService {
+		service := NewLotteryService()
+		service.AddPrize(testTenantID, "一獎", "電視", 1, false)
+		service.AddPrize(testTenantID, "二獎", "冰箱", 1, false)
+		service.InsertPrize(testTenantID, models.Prize{Name: "研發獎", Item: "筆電", Quantity: 1, Group: "研發部"})
+		for i := 1; i <= 5; i++ {
+			service.InsertParticipant(testTenantID, models.Participant{ID: strconv.Itoa(i), Name: "P" + strconv.Itoa(i), Group: "業務部", Present: true})
+		}
+		return service
+	}
+
+	t.Run("Test failing third prize leaves the first two untouched", func(t *testing.T) {
+		service := newService()
+		service.ReserveWinner(testTenantID, "一獎", "3")
+
+		results, err := service.DrawTransaction(testTenantID, []string{"一獎", "二獎", "研發獎"})
+		if !errors.Is(err, ErrNoEligibleParticipants) || !strings.Contains(err.Error(), "研發獎") {
+			t.Fatalf("Expected ErrNoEligibleParticipants naming 研發獎, but got %v", err)
+		}
+		if results != nil {
+			t.Errorf("Expected no results from a rolled-back transaction, but got %d", len(results))
+		}
+		for _, p := range service.GetPrizes(testTenantID) {
+			if p.Quantity != 1 {
+				t.Errorf("Expected prize %s to keep its unit, but %d remain", p.Name, p.Quantity)
+			}
+		}
+		session := service.getSession(testTenantID)
+		if len(session.LotteryResults) != 0 || len(session.Winners) != 0 || session.ResultSeq != 0 {
+			t.Errorf("Expected no recorded wins, but got %d results, winners %v, seq %d",
+				len(session.LotteryResults), session.Winners, session.ResultSeq)
+		}
+		if got := session.Reservations["一獎"]; !slices.Equal(got, []string{"3"}) {
+			t.Errorf("Expected the reservation to be restored, but got %v", got)
+		}
+		if n := len(service.GetAuditLog(testTenantID)); n != 0 {
+			t.Errorf("Expected nothing to be audited, but got %d entries", n)
+		}
+	})
+
+	t.Run("Test successful transaction commits every prize as one batch", func(t *testing.T) {
+		service := newService()
+		results, err := service.DrawTransaction(testTenantID, []string{"一獎", "二獎"})
+		if err != nil {
+			t.Fatalf("DrawTransaction failed: %v", err)
+		}
+		if len(results) != 2 || results[0].PrizeName != "一獎" || results[1].PrizeName != "二獎" {
+			t.Fatalf("Expected one result per prize in order, but got %v", results)
+		}
+		if results[0].WinnerID == results[1].WinnerID {
+			t.Error("Expected two different winners")
+		}
+		if results[0].BatchID == "" || results[0].BatchID != results[1].BatchID {
+			t.Errorf("Expected the results to share a BatchID, but got %q and %q", results[0].BatchID, results[1].BatchID)
+		}
+		if err := service.UndoBatch(testTenantID, results[0].BatchID); err != nil {
+			t.Errorf("Expected the transaction to be undoable as a batch, but got %v", err)
+		}
+	})
+
+	t.Run("Test empty prize list", func(t *testing.T) {
+		if _, err := newService().DrawTransaction(testTenantID, nil); err == nil {
+			t.Error("Expected an error for an empty prize list, but got nil")
+		}
+	})
+}
+
+func TestMaskID(t *testing.T) {
+	tests := []struct {
+		id   string
+		want string
+	}{
+		{"123445", "****45"},
+		{"A01", "*01"},
+		{"12", "**"},
+		{"7", "*"},
+		{"", ""},
+		{"王小明", "*小明"},
+	}
+	for _, tt := range tests {
+		if got := MaskID(tt.id); got != tt.want {
+			t.Errorf("MaskID(%q) = %q, want %q", tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestLotteryService_AnnounceMasksID(t *testing.T) {
+	service := NewLotteryService()
+	const testTenantID = "mask-tenant"
+	result := &models.LotteryResult{PrizeName: "頭獎", PrizeItem: "電視", WinnerName: "Alice", WinnerID: "123445"}
+
+	service.SetMaskIDs(testTenantID, true)
+	if got := service.Announce(testTenantID, result); !strings.Contains(got, "員編****45") {
+		t.Errorf("Expected the announcement to mask the ID, but got %q", got)
+	}
+	service.SetMaskIDs(testTenantID, false)
+	if got := service.Announce(testTenantID, result); !strings.Contains(got, "員編123445") {
+		t.Errorf("Expected the announcement to show the full ID, but got %q", got)
+	}
+}
+
+func TestLotteryService_DrawOdds(t *testing.T) {
+	const testTenantID = "odds-tenant"
+	service := NewLotteryService()
+	service.AddPrize(testTenantID, "頭獎", "電視", 3, false)
+	for _, id := range []string{"001", "002", "003", "004"} {
+		service.AddParticipant(testTenantID, id, "Participant")
+	}
+
+	// The pool shrinks by one with every winner, so the odds rise.
+	for _, want := range []int{4, 3, 2} {
+		eligible, odds, err := service.DrawOdds(testTenantID, "頭獎")
+		if err != nil {
+			t.Fatalf("DrawOdds failed: %v", err)
+		}
+		if eligible != want || odds != 1/float64(want) {
+			t.Errorf("Expected %d eligible at odds 1/%d, but got %d at %v", want, want, eligible, odds)
+		}
+		if _, err := service.Draw(testTenantID, "頭獎"); err != nil {
+			t.Fatalf("Draw failed: %v", err)
+		}
+	}
+	if _, _, err := service.DrawOdds(testTenantID, "頭獎"); !errors.Is(err, ErrPrizeExhausted) {
+		t.Errorf("Expected ErrPrizeExhausted for a drawn-out prize, but got %v", err)
+	}
+
+	service.AddPrize(testTenantID, "二獎", "手機", 1, false)
+	service.InsertParticipant(testTenantID, models.Participant{ID: "005", Name: "Heavy", Weight: 3})
+	eligible, odds, err := service.DrawOdds(testTenantID, "二獎")
+	if err != nil || eligible != 2 || odds != 0.25 {
+		t.Errorf("Expected the 2 non-winners with a total weight of 4, but got %d at %v (%v)", eligible, odds, err)
+	}
+
+	service.InsertPrize(testTenantID, models.Prize{Name: "業務獎", Item: "禮券", Quantity: 1, Group: "Sales"})
+	if _, _, err := service.DrawOdds(testTenantID, "業務獎"); !errors.Is(err, ErrNoEligibleParticipants) {
+		t.Errorf("Expected ErrNoEligibleParticipants, but got %v", err)
+	}
+	if _, _, err := service.DrawOdds(testTenantID, "不存在"); !errors.Is(err, ErrPrizeNotFound) {
+		t.Errorf("Expected ErrPrizeNotFound, but got %v", err)
+	}
+}
+
+func TestLotteryService_GetDrawProgress(t *testing.T) {
+	service := NewLotteryService()
+	const testTenantID = "progress-tenant"
+	service.AddPrize(testTenantID, "頭獎", "電視", 1, false)
+	service.AddPrize(testTenantID, "參加獎", "禮券", 3, true)
+	for i := 1; i <= 3; i++ {
+		service.AddParticipant(testTenantID, strconv.Itoa(i), "P"+strconv.Itoa(i))
+	}
+
+	if drawn, total := service.GetDrawProgress(testTenantID); drawn != 0 || total != 4 {
+		t.Fatalf("Expected 0 / 4 before drawing, but got %d / %d", drawn, total)
+	}
+	for i, prize := range []string{"頭獎", "參加獎", "參加獎"} {
+		if _, err := service.Draw(testTenantID, prize); err != nil {
+			t.Fatalf("Draw %s failed: %v", prize, err)
+		}
+		if drawn, total := service.GetDrawProgress(testTenantID); drawn != i+1 || total != 4 {
+			t.Errorf("Expected %d / 4 after draw %d, but got %d / %d", i+1, i+1, drawn, total)
+		}
+	}
+
+	if _, err := service.UndoLastDraw(testTenantID); err != nil {
+		t.Fatalf("UndoLastDraw failed: %v", err)
+	}
+	if drawn, total := service.GetDrawProgress(testTenantID); drawn != 2 || total != 4 {
+		t.Errorf("Expected 2 / 4 after undoing a draw, but got %d / %d", drawn, total)
+	}
+}
+
+func TestLotteryService_GetPrizeSummary(t *testing.T) {
+	service := NewLotteryService()
+	const testTenantID = "summary-tenant"
+	service.AddPrize(testTenantID, "頭獎", "電視", 2, false)
+	service.AddPrize(testTenantID, "二獎", "冰箱", 3, false)
+	service.AddPrize(testTenantID, "參加獎", "紅包", 4, true)
+	for i := 1; i <= 6; i++ {
+		service.AddParticipant(testTenantID, strconv.Itoa(i), "P"+strconv.Itoa(i))
+	}
+	service.Draw(testTenantID, "頭獎")
+	service.DrawBatch(testTenantID, "二獎", 2)
+	service.DrawBatch(testTenantID, "參加獎", 4)
+	service.UndoLastDraw(testTenantID)
+
+	summary := service.GetPrizeSummary(testTenantID)
+	if len(summary) != 3 || summary[0].Name != "頭獎" || summary[1].Name != "二獎" || summary[2].Name != "參加獎" {
+		t.Fatalf("Expected one entry per prize in display order, but got %+v", summary)
+	}
+	wantAwarded := map[string]int{"頭獎": 1, "二獎": 2, "參加獎": 3}
+	for _, p := range summary {
+		if p.Awarded+p.Remaining != p.OriginalQuantity {
+			t.Errorf("Prize %s: awarded %d + remaining %d != original %d", p.Name, p.Awarded, p.Remaining, p.OriginalQuantity)
+		}
+		if p.Awarded != wantAwarded[p.Name] {
+			t.Errorf("Prize %s: expected %d awarded, but got %d", p.Name, wantAwarded[p.Name], p.Awarded)
+		}
+		if n, _ := service.CountEligible(testTenantID, p.Name); p.Eligible != n {
+			t.Errorf("Prize %s: expected %d eligible, but got %d", p.Name, n, p.Eligible)
+		}
+	}
+}
+
+func TestLotteryService_RenamePrize(t *testing.T) {
+	service := NewLotteryService()
+	const testTenantID = "rename-tenant"
+	service.AddPrize(testTenantID, "參加獎", "紅包", 3, true)
+	service.InsertPrize(testTenantID, models.Prize{Name: "加碼獎", Item: "禮券", Quantity: 3, DrawFromAll: true, ExcludeWinnersOf: []string{"參加獎"}})
+	service.AddParticipant(testTenantID, "001", "Alice")
+	service.AddParticipant(testTenantID, "002", "Bob")
+	won, err := service.Draw(testTenantID, "參加獎")
+	if err != nil {
+		t.Fatalf("Draw failed: %v", err)
+	}
+
+	if err := service.RenamePrize(testTenantID, "參加獎", "加碼獎"); !errors.Is(err, ErrDuplicatePrize) {
+		t.Errorf("Expected ErrDuplicatePrize renaming onto an existing prize, but got %v", err)
+	}
+	if err := service.RenamePrize(testTenantID, "不存在", "新獎"); !errors.Is(err, ErrPrizeNotFound) {
+		t.Errorf("Expected ErrPrizeNotFound, but got %v", err)
+	}
+	if err := service.RenamePrize(testTenantID, "參加獎", "普獎"); err != nil {
+		t.Fatalf("RenamePrize failed: %v", err)
+	}
+
+	if results := service.GetLotteryResults(testTenantID); results[0].PrizeName != "普獎" {
+		t.Errorf("Expected the result to follow the rename, but got %q", results[0].PrizeName)
+	}
+	// The winner may not win the renamed DrawFromAll prize again...
+	eligible, err := service.GetEligibleParticipants(testTenantID, "普獎")
+	if err != nil || len(eligible) != 1 || eligible[0].ID == won.WinnerID {
+		t.Errorf("Expected only the other participant to stay eligible for 普獎, but got %v (%v)", eligible, err)
+	}
+	// ...and 加碼獎 still excludes the winners of the prize it referenced by the old name.
+	eligible, err = service.GetEligibleParticipants(testTenantID, "加碼獎")
+	if err != nil || len(eligible) != 1 || eligible[0].ID == won.WinnerID {
+		t.Errorf("Expected 加碼獎 to keep excluding the winner, but got %v (%v)", eligible, err)
+	}
+	if next, err := service.Draw(testTenantID, "普獎"); err != nil || next.WinnerID == won.WinnerID {
+		t.Errorf("Expected the next draw of 普獎 to pick the other participant, but got %v (%v)", next, err)
+	}
+	if _, err := service.GetEligibleParticipants(testTenantID, "參加獎"); !errors.Is(err, ErrPrizeNotFound) {
+		t.Errorf("Expected the old name to be gone, but got %v", err)
+	}
+}
+
+func TestLotteryService_AddPrizeQuantity(t *testing.T) {
+	service := NewLotteryService()
+	const testTenantID = "quantity-tenant"
+	for _, q := range []int{0, -1} {
+		if err := service.AddPrize(testTenantID, "獎"+strconv.Itoa(q), "電視", q, false); !errors.Is(err, ErrInvalidQuantity) {
+			t.Errorf("Expected ErrInvalidQuantity for quantity %d, but got %v", q, err)
+		}
+	}
+	if err := service.AddPrize(testTenantID, "頭獎", "電視", 1, false); err != nil {
+		t.Errorf("Expected quantity 1 to be accepted, but got %v", err)
+	}
+	if err := service.AddPrize(testTenantID, " ", "電視", 1, false); err == nil {
+		t.Error("Expected a blank prize name to be rejected, but got nil")
+	}
+	if n := len(service.GetPrizes(testTenantID)); n != 1 {
+		t.Errorf("Expected only the valid prize to be added, but got %d", n)
+	}
+}
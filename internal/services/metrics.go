@@ -0,0 +1,32 @@
+package services
+
+import "sync/atomic"
+
+// serviceMetrics counts service activity for monitoring. The counters are
+// atomics, so recording them adds no work under a session lock beyond an increment.
+type serviceMetrics struct {
+	draws             atomic.Int64
+	participantsAdded atomic.Int64
+	evictions         atomic.Int64
+}
+
+// MetricsSnapshot is a point-in-time copy of the service counters.
+type MetricsSnapshot struct {
+	Draws             int64 // Winners recorded by draws, batch draws and redraws
+	ParticipantsAdded int64 // Participants added one by one or in bulk
+	Evictions         int64 // Sessions dropped by CleanUpInactiveSessions
+	ActiveSessions    int
+}
+
+// Metrics returns the current counters together with the number of active sessions.
+func (s *LotteryService) Metrics() MetricsSnapshot {
+	s.mu.RLock()
+	sessions := len(s.sessions)
+	s.mu.RUnlock()
+	return MetricsSnapshot{
+		Draws:             s.metrics.draws.Load(),
+		ParticipantsAdded: s.metrics.participantsAdded.Load(),
+		Evictions:         s.metrics.evictions.Load(),
+		ActiveSessions:    sessions,
+	}
+}
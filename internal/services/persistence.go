@@ -0,0 +1,179 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"lottery/internal/i18n"
+	"lottery/internal/models"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// SaveToFile writes every session to path as JSON. The file is written to a
+// temporary sibling first and then renamed, so a crash never leaves a torn file.
+// Sessions are encoded one at a time, each under its own lock.
+func (s *LotteryService) SaveToFile(path string) error {
+	sessions := make(map[string]json.RawMessage)
+	for tenantID, session := range s.snapshotSessions() {
+		session.mu.Lock()
+		data, err := json.Marshal(session)
+		session.mu.Unlock()
+		if err != nil {
+			return err
+		}
+		sessions[tenantID] = data
+	}
+	data, err := json.Marshal(sessions)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// LoadFromFile replaces all sessions with the ones saved in path.
+// A missing file is not an error; the service simply starts empty.
+func (s *LotteryService) LoadFromFile(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	sessions := make(map[string]*LotterySession)
+	if err := json.Unmarshal(data, &sessions); err != nil {
+		return err
+	}
+	for _, session := range sessions {
+		normalizeSession(session)
+	}
+
+	s.mu.Lock()
+	s.sessions = sessions
+	s.mu.Unlock()
+	return nil
+}
+
+// ExportSession returns a JSON snapshot of one tenant's session: prizes,
+// participants, winners, results and settings.
+func (s *LotteryService) ExportSession(tenantID string) ([]byte, error) {
+	session := s.lockSession(tenantID)
+	defer session.mu.Unlock()
+	return json.MarshalIndent(session, "", "  ")
+}
+
+// ImportSession replaces a tenant's session with a snapshot produced by
+// ExportSession. The snapshot is validated first; on error the current
+// session is left untouched.
+func (s *LotteryService) ImportSession(tenantID string, data []byte) error {
+	var session LotterySession
+	if err := json.Unmarshal(data, &session); err != nil {
+		return i18n.NewError("snapshot_unreadable", err)
+	}
+	normalizeSession(&session)
+	if err := validateSession(&session); err != nil {
+		return err
+	}
+	session.LastActivity = time.Now()
+	s.logFor(tenantID).Infof("imported session snapshot (%d prizes, %d participants, %d results)",
+		len(session.Prizes), len(session.Participants), len(session.ArchivedResults)+len(session.LotteryResults))
+
+	s.mu.Lock()
+	s.sessions[tenantID] = &session
+	s.mu.Unlock()
+	return nil
+}
+
+// validateSession checks an imported snapshot for inconsistencies that the
+// rest of the service assumes cannot happen.
+func validateSession(session *LotterySession) error {
+	names := make(map[string]bool, len(session.Prizes))
+	for _, p := range session.Prizes {
+		if p.Quantity < 0 {
+			return i18n.NewError("snapshot_negative", p.Name)
+		}
+		if names[p.Name] {
+			return i18n.NewError("snapshot_prize_repeated", p.Name)
+		}
+		names[p.Name] = true
+	}
+	ids := make(map[string]bool, len(session.Participants))
+	for _, p := range session.Participants {
+		if ids[p.ID] {
+			return i18n.NewError("snapshot_participant_dup", p.ID)
+		}
+		ids[p.ID] = true
+	}
+	for id := range session.Winners {
+		if !ids[id] {
+			return i18n.NewError("snapshot_unknown_winner", id)
+		}
+	}
+	// Results of a deleted prize are kept, and so is the prize in its winners'
+	// Winners entries, so those names count as known too.
+	for _, prizes := range session.Winners {
+		for name := range prizes {
+			names[name] = true
+		}
+	}
+	for _, r := range allResults(session) {
+		if !ids[r.WinnerID] {
+			return i18n.NewError("snapshot_result_unknown_winner", r.ID, r.WinnerID)
+		}
+		if !names[r.PrizeName] {
+			return i18n.NewError("snapshot_result_unknown_prize", r.ID, r.PrizeName)
+		}
+	}
+	return nil
+}
+
+// normalizeSession fills in collections and defaults that may be missing from
+// decoded JSON, so a loaded session behaves like one created by sessionLocked.
+func normalizeSession(session *LotterySession) {
+	if session.Prizes == nil {
+		session.Prizes = make([]*models.Prize, 0)
+	}
+	if session.Participants == nil {
+		session.Participants = make([]*models.Participant, 0)
+	}
+	if session.Winners == nil {
+		session.Winners = make(map[string]map[string]bool)
+	}
+	if session.LotteryResults == nil {
+		session.LotteryResults = make([]*models.LotteryResult, 0)
+	}
+	if session.Excluded == nil {
+		session.Excluded = make(map[string]bool)
+	}
+	// Sessions saved before OriginalQuantity existed: rebuild it from the draws so far.
+	for _, p := range session.Prizes {
+		if p.OriginalQuantity == 0 {
+			p.OriginalQuantity = p.Quantity
+			for _, r := range allResults(session) {
+				if r.PrizeName == p.Name {
+					p.OriginalQuantity++
+				}
+			}
+		}
+	}
+	if session.MinParticipants < 1 {
+		session.MinParticipants = 1
+	}
+	session.markChanged()
+}
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLotteryService_SaveAndLoad(t *testing.T) {
+	const testTenantID = "persist-tenant"
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	saved := NewLotteryService()
+	saved.AddPrize(testTenantID, "頭獎", "電視", 1, false)
+	saved.AddPrize(testTenantID, "普獎", "紅包", 3, true)
+	saved.AddParticipant(testTenantID, "001", "Alice")
+	saved.AddParticipant(testTenantID, "002", "Bob")
+	if _, err := saved.Draw(testTenantID, "頭獎"); err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+	if _, err := saved.Draw(testTenantID, "普獎"); err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	if err := saved.SaveToFile(path); err != nil {
+		t.Fatalf("Expected no error saving, but got %v", err)
+	}
+
+	loaded := NewLotteryService()
+	if err := loaded.LoadFromFile(path); err != nil {
+		t.Fatalf("Expected no error loading, but got %v", err)
+	}
+
+	if got, want := loaded.GetPrizes(testTenantID), saved.GetPrizes(testTenantID); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected prizes %+v, but got %+v", want, got)
+	}
+	if got, want := loaded.GetParticipants(testTenantID), saved.GetParticipants(testTenantID); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected participants %+v, but got %+v", want, got)
+	}
+
+	gotResults, wantResults := loaded.GetLotteryResults(testTenantID), saved.GetLotteryResults(testTenantID)
+	if len(gotResults) != len(wantResults) {
+		t.Fatalf("Expected %d results, but got %d", len(wantResults), len(gotResults))
+	}
+	for i := range wantResults {
+		got, want := gotResults[i], wantResults[i]
+		if got.ID != want.ID || got.PrizeName != want.PrizeName || got.WinnerID != want.WinnerID || !got.DrawnAt.Equal(want.DrawnAt) {
+			t.Errorf("Result %d: expected %+v, but got %+v", i, want, got)
+		}
+	}
+
+	if got, want := loaded.getSession(testTenantID).Winners, saved.getSession(testTenantID).Winners; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected winners %v, but got %v", want, got)
+	}
+
+	// The winner of the non-DrawFromAll prize must still be excluded after a restart.
+	eligible, _ := loaded.GetEligibleParticipants(testTenantID, "普獎")
+	for _, p := range eligible {
+		if loaded.getSession(testTenantID).Winners[p.ID]["普獎"] {
+			t.Errorf("Expected %s to stay ineligible for 普獎 after loading", p.ID)
+		}
+	}
+}
+
+func TestLotteryService_LoadMissingFile(t *testing.T) {
+	service := NewLotteryService()
+	path := filepath.Join(t.TempDir(), "does-not-exist.json")
+	if err := service.LoadFromFile(path); err != nil {
+		t.Fatalf("Expected a missing file to be ignored, but got %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected LoadFromFile not to create the file")
+	}
+}
+
+func TestLotteryService_ImportSession_DefaultsPresence(t *testing.T) {
+	service := NewLotteryService()
+	snapshot := `{"participants":[{"id":"1","name":"Alice"},{"id":"2","name":"Bob","present":false}]}`
+	if err := service.ImportSession("tenant", []byte(snapshot)); err != nil {
+		t.Fatalf("ImportSession failed: %v", err)
+	}
+	participants := service.GetParticipants("tenant")
+	if !participants[0].Present {
+		t.Error("Expected a participant without a present field to load as present")
+	}
+	if participants[1].Present {
+		t.Error("Expected an explicitly absent participant to stay absent")
+	}
+}
+
+func TestLotteryService_ImportSession_RejectsDanglingResults(t *testing.T) {
+	snapshots := map[string]string{
+		"unknown winner": `{"prizes":[{"name":"頭獎","quantity":0}],"participants":[{"id":"1","name":"Alice"}],
+			"lotteryResults":[{"id":"r1","prizeName":"頭獎","winnerId":"9"}]}`,
+		"unknown prize": `{"prizes":[{"name":"頭獎","quantity":0}],"participants":[{"id":"1","name":"Alice"}],
+			"archivedResults":[{"id":"r1","prizeName":"特獎","winnerId":"1"}]}`,
+	}
+	for name, snapshot := range snapshots {
+		service := NewLotteryService()
+		if err := service.ImportSession("tenant", []byte(snapshot)); err == nil {
+			t.Errorf("%s: expected the snapshot to be rejected", name)
+		}
+	}
+}
+
+func TestLotteryService_ImportSession_KeepsResultsOfDeletedPrize(t *testing.T) {
+	service := NewLotteryService()
+	service.AddPrize("tenant", "頭獎", "電視", 1, false)
+	service.AddParticipant("tenant", "001", "Alice")
+	if _, err := service.Draw("tenant", "頭獎"); err != nil {
+		t.Fatalf("Draw failed: %v", err)
+	}
+	if err := service.DeletePrize("tenant", "頭獎"); err != nil {
+		t.Fatalf("DeletePrize failed: %v", err)
+	}
+	data, err := service.ExportSession("tenant")
+	if err != nil {
+		t.Fatalf("ExportSession failed: %v", err)
+	}
+	if err := NewLotteryService().ImportSession("tenant", data); err != nil {
+		t.Errorf("Expected a snapshot with results of a deleted prize to import, but got %v", err)
+	}
+}
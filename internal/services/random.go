@@ -0,0 +1,43 @@
+package services
+
+import (
+	"crypto/rand"
+	"errors"
+	"lottery/internal/models"
+	"math/big"
+)
+
+// secureIntn returns a uniformly distributed random integer in [0, n) read from
+// crypto/rand. crypto/rand.Int uses rejection sampling, so the result has no
+// modulo bias.
+func secureIntn(n int) (int, error) {
+	if n <= 0 {
+		return 0, errors.New("secureIntn: n must be positive")
+	}
+	v, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, err
+	}
+	return int(v.Int64()), nil
+}
+
+// weightedIndex picks an index into pool with probability proportional to each
+// participant's EffectiveWeight, using intn as the source of randomness.
+// When every weight is 1 this is a uniform pick.
+func weightedIndex(pool []*models.Participant, intn func(int) (int, error)) (int, error) {
+	total := 0
+	for _, p := range pool {
+		total += p.EffectiveWeight()
+	}
+	r, err := intn(total)
+	if err != nil {
+		return 0, err
+	}
+	for i, p := range pool {
+		r -= p.EffectiveWeight()
+		if r < 0 {
+			return i, nil
+		}
+	}
+	return len(pool) - 1, nil
+}
@@ -0,0 +1,40 @@
+package services
+
+import (
+	"testing"
+)
+
+func TestSecureIntn(t *testing.T) {
+	t.Run("Test rejecting non-positive n", func(t *testing.T) {
+		for _, n := range []int{0, -1} {
+			if _, err := secureIntn(n); err == nil {
+				t.Errorf("Expected an error for n=%d, but got nil", n)
+			}
+		}
+	})
+
+	t.Run("Test uniform distribution", func(t *testing.T) {
+		const buckets = 5
+		const iterations = 50000
+		counts := make([]int, buckets)
+		for i := 0; i < iterations; i++ {
+			v, err := secureIntn(buckets)
+			if err != nil {
+				t.Fatalf("Expected no error, but got %v", err)
+			}
+			if v < 0 || v >= buckets {
+				t.Fatalf("Expected a value in [0, %d), but got %d", buckets, v)
+			}
+			counts[v]++
+		}
+
+		// Each bucket should receive ~10000 hits; allow 5% deviation.
+		expected := iterations / buckets
+		tolerance := expected / 20
+		for i, c := range counts {
+			if c < expected-tolerance || c > expected+tolerance {
+				t.Errorf("Bucket %d got %d hits, expected %d±%d", i, c, expected, tolerance)
+			}
+		}
+	})
+}